@@ -1,9 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"text/tabwriter"
 
 	"github.com/ralexstokes/eth2-fork-mon/pkg/monitor"
 
@@ -12,31 +17,147 @@ import (
 
 var configFile = flag.String("config-file", "/config.yaml", "path to configuration")
 var outputDirectory = flag.String("output-dir", "public", "path to web assets")
+var readOnly = flag.Bool("read-only", false, "disable admin routes and anything but reads, overriding the configuration")
+
+const selftestUsage = `usage: eth2-fork-mon [flags] selftest`
+
+const snapshotUsage = `usage: eth2-fork-mon [flags] snapshot export [-out file]
+       eth2-fork-mon [flags] snapshot import [-in file]`
+
+// loadConfig reads the configuration at `path`, expanding environment
+// variables and reading any secrets kept in separate files.
+func loadConfig(path string) (*monitor.Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := monitor.ExpandEnv(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &monitor.Config{}
+	err = config.RecordSources(raw)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, err
+	}
+	err = config.LoadSecretFiles()
+	return config, err
+}
+
+// runSnapshot handles `snapshot export` and `snapshot import`, reading from
+// stdin and writing to stdout unless a file is given.
+func runSnapshot(config *monitor.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(snapshotUsage)
+	}
+
+	flags := flag.NewFlagSet("snapshot "+args[0], flag.ExitOnError)
+	switch args[0] {
+	case "export":
+		out := flags.String("out", "", "path to write the snapshot to (default stdout)")
+		flags.Parse(args[1:])
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return monitor.ExportSnapshot(config, w)
+	case "import":
+		in := flags.String("in", "", "path to read the snapshot from (default stdin)")
+		flags.Parse(args[1:])
+
+		var r io.Reader = os.Stdin
+		if *in != "" {
+			f, err := os.Open(*in)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		return monitor.ImportSnapshot(config, r)
+	default:
+		return errors.New(snapshotUsage)
+	}
+}
+
+// runSelftest exercises the pipeline once against the configured nodes and
+// prints the outcome of each check, failing if any of them failed.
+func runSelftest(config *monitor.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.New(selftestUsage)
+	}
+	err := config.ApplyDefaults()
+	if err != nil {
+		return err
+	}
+
+	results := monitor.SelfTest(config)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tDETAIL")
+	failed := 0
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Check, result.Result, result.Detail)
+		if result.Result == monitor.SelfTestFail {
+			failed++
+		}
+	}
+	w.Flush()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
 
 func main() {
 	flag.Parse()
 
-	configFile, err := os.Open(*configFile)
+	config, err := loadConfig(*configFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	decoder := yaml.NewDecoder(configFile)
-	config := &monitor.Config{}
-	err = decoder.Decode(config)
+	if flag.Arg(0) == "selftest" {
+		err = runSelftest(config, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "snapshot" {
+		err = runSnapshot(config, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	config.OutputDir = *outputDirectory
+	config.SetSource("outputdir", monitor.SettingFromFlag)
+	if *readOnly {
+		config.ReadOnly = true
+		config.SetSource("read_only", monitor.SettingFromFlag)
+	}
+	err = config.ApplyDefaults()
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = configFile.Close()
+	forkMonitor, err := monitor.FromConfig(config)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	config.OutputDir = *outputDirectory
-	config.Eth2.SecondsPerSlot = 12
-	config.Eth2.SlotsPerEpoch = 32
-	forkMonitor := monitor.FromConfig(config)
-
 	err = forkMonitor.Start()
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+type Alert struct {
+	Name     string    `json:"name"`
+	Severity Severity  `json:"severity"`
+	Message  string    `json:"message"`
+	Since    time.Time `json:"since"`
+}
+
+// alertSet tracks the currently firing alerts by name.
+type alertSet struct {
+	lock   sync.Mutex
+	active map[string]*Alert
+}
+
+func newAlertSet() *alertSet {
+	return &alertSet{active: make(map[string]*Alert)}
+}
+
+// raise fires the alert `name`, or updates its message if already firing.
+func (a *alertSet) raise(name string, severity Severity, message string) {
+	message = secrets.redact(message)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if alert, ok := a.active[name]; ok {
+		alert.Severity = severity
+		alert.Message = message
+		return
+	}
+	a.active[name] = &Alert{Name: name, Severity: severity, Message: message, Since: time.Now()}
+	log.Printf("alert [%s] %s: %s", severity, name, message)
+}
+
+func (a *alertSet) resolve(name string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, ok := a.active[name]; ok {
+		delete(a.active, name)
+		log.Printf("alert resolved: %s", name)
+	}
+}
+
+func (a *alertSet) list() []Alert {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	alerts := []Alert{}
+	for _, alert := range a.active {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Since.Before(alerts[j].Since) })
+	return alerts
+}
+
+type alertsResp struct {
+	// display name of this instance
+	Instance string  `json:"instance"`
+	Alerts   []Alert `json:"alerts"`
+}
+
+func (m *Monitor) sendAlerts(w http.ResponseWriter, r *http.Request) {
+	resp := alertsResp{
+		Instance: m.meta().DisplayName,
+		Alerts:   m.alerts.list(),
+	}
+	writeJSON(w, r, &resp)
+}
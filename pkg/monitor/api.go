@@ -0,0 +1,205 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type summaryResp struct {
+	CurrentSlot  int        `json:"current_slot"`
+	CurrentEpoch int        `json:"current_epoch"`
+	HeadSlot     int        `json:"head_slot,string"`
+	HeadRoot     string     `json:"head_root"`
+	InConsensus  bool       `json:"in_consensus"`
+	NodeCount    int        `json:"node_count"`
+	HealthyCount int        `json:"healthy_count"`
+	SyncingCount int        `json:"syncing_count"`
+	Justified    Checkpoint `json:"justified_checkpoint"`
+	Finalized    Checkpoint `json:"finalized_checkpoint"`
+	// the most recent restart of the chain from a new genesis, if any
+	GenesisReset *GenesisReset `json:"genesis_reset,omitempty"`
+	// which node supplied each section and when
+	Sources map[string]Provenance `json:"sources"`
+	// sections still waiting for their first fetch
+	Initializing []string `json:"initializing"`
+}
+
+// majorityHead returns the head shared by the most healthy nodes, preferring
+// the higher slot in case of a tie, and whether all healthy nodes agree on it.
+func majorityHead(nodes []*Node) (HeadRef, bool) {
+	counts := make(map[HeadRef]int)
+	for _, node := range nodes {
+		if node.isHealthy {
+			counts[node.latestHead]++
+		}
+	}
+
+	var head HeadRef
+	headCount := 0
+	for ref, count := range counts {
+		if count > headCount || (count == headCount && head.slot < ref.slot) {
+			head = ref
+			headCount = count
+		}
+	}
+	return head, len(counts) <= 1
+}
+
+func (m *Monitor) sendSummary(w http.ResponseWriter, r *http.Request) {
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+	nodes := m.nodeList()
+	head, inConsensus := majorityHead(nodes)
+
+	resp := summaryResp{
+		CurrentSlot:  currentSlot,
+		CurrentEpoch: currentSlot / m.config.Eth2.SlotsPerEpoch,
+		HeadSlot:     head.slot,
+		HeadRoot:     head.root,
+		InConsensus:  inConsensus,
+		NodeCount:    len(nodes),
+		Justified:    m.justifiedCheckpoint,
+		Finalized:    m.finalizedCheckpoint,
+		GenesisReset: m.latestGenesisReset(),
+		Sources:      m.sources.all(),
+		Initializing: m.initializingSections(),
+	}
+	for _, node := range nodes {
+		if node.isHealthy {
+			resp.HealthyCount++
+		}
+		if node.isSyncing {
+			resp.SyncingCount++
+		}
+	}
+
+	m.writeSignedJSON(w, r, &resp)
+}
+
+type clientSummary struct {
+	Client          string         `json:"client"`
+	Count           int            `json:"count"`
+	MinHeadSlot     int            `json:"min_head_slot"`
+	MaxHeadSlot     int            `json:"max_head_slot"`
+	MedianHeadSlot  int            `json:"median_head_slot"`
+	HealthyFraction float64        `json:"healthy_fraction"`
+	Versions        map[string]int `json:"versions"`
+}
+
+type clientsResp struct {
+	Clients []clientSummary `json:"clients"`
+}
+
+func median(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// summarizeClients groups `nodes` by client family.
+func summarizeClients(nodes []*Node) []clientSummary {
+	slotsByClient := make(map[string][]int)
+	summaries := make(map[string]*clientSummary)
+	for _, node := range nodes {
+		client := clientFamily(node.version)
+		summary, ok := summaries[client]
+		if !ok {
+			summary = &clientSummary{Client: client, Versions: make(map[string]int)}
+			summaries[client] = summary
+		}
+		summary.Count++
+		summary.Versions[node.version]++
+		if node.isHealthy {
+			summary.HealthyFraction++
+		}
+		if node.latestHead.root != "" {
+			slotsByClient[client] = append(slotsByClient[client], node.latestHead.slot)
+		}
+	}
+
+	var result []clientSummary
+	for client, summary := range summaries {
+		summary.HealthyFraction /= float64(summary.Count)
+		slots := slotsByClient[client]
+		for i, slot := range slots {
+			if i == 0 || slot < summary.MinHeadSlot {
+				summary.MinHeadSlot = slot
+			}
+			if slot > summary.MaxHeadSlot {
+				summary.MaxHeadSlot = slot
+			}
+		}
+		summary.MedianHeadSlot = median(slots)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Client < result[j].Client })
+	return result
+}
+
+func (m *Monitor) sendClients(w http.ResponseWriter, r *http.Request) {
+	resp := clientsResp{
+		Clients: summarizeClients(m.nodeList()),
+	}
+	writeJSON(w, r, &resp)
+}
+
+func (m *Monitor) nodeByID(id string) *Node {
+	for _, node := range m.nodeList() {
+		if node.id == id {
+			return node
+		}
+	}
+	return nil
+}
+
+type nodeDetailResp struct {
+	nodeResp
+	LastError *NodeError    `json:"last_error"`
+	Identity  *NodeIdentity `json:"identity,omitempty"`
+}
+
+func (m *Monitor) sendNodeDetail(w http.ResponseWriter, r *http.Request, node *Node) {
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+	resp := nodeDetailResp{
+		nodeResp:  m.nodeResponse(node, currentSlot),
+		LastError: node.latestError(),
+	}
+	if m.config.RevealIdentity {
+		identity := node.identity
+		resp.Identity = &identity
+	}
+	writeJSON(w, r, &resp)
+}
+
+// sendNodeAPI dispatches requests under `/api/v1/nodes/{id}/...`
+func (m *Monitor) sendNodeAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/nodes/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	node := m.nodeByID(parts[0])
+	if node == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		m.sendNodeDetail(w, r, node)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "timeseries" {
+		m.sendNodeTimeseries(w, r, node)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "heads" {
+		m.sendNodeHeads(w, r, node)
+		return
+	}
+	http.NotFound(w, r)
+}
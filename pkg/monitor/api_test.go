@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeClients(t *testing.T) {
+	nodes := []*Node{
+		{version: "Lighthouse/v1.0.0", latestHead: HeadRef{slot: 10, root: hash("10")}, isHealthy: true},
+		{version: "Lighthouse/v1.0.1", latestHead: HeadRef{slot: 14, root: hash("14")}, isHealthy: true},
+		{version: "Lighthouse/v1.0.1", latestHead: HeadRef{slot: 11, root: hash("11")}},
+		{version: "Lighthouse/v1.0.1", latestHead: HeadRef{slot: 13, root: hash("13")}, isHealthy: true},
+		{version: "teku/v20.11.0", latestHead: HeadRef{slot: 12, root: hash("12")}, isHealthy: true},
+	}
+
+	expected := []clientSummary{
+		{
+			Client:          "lighthouse",
+			Count:           4,
+			MinHeadSlot:     10,
+			MaxHeadSlot:     14,
+			MedianHeadSlot:  12,
+			HealthyFraction: 0.75,
+			Versions:        map[string]int{"Lighthouse/v1.0.0": 1, "Lighthouse/v1.0.1": 3},
+		},
+		{
+			Client:          "teku",
+			Count:           1,
+			MinHeadSlot:     12,
+			MaxHeadSlot:     12,
+			MedianHeadSlot:  12,
+			HealthyFraction: 1,
+			Versions:        map[string]int{"teku/v20.11.0": 1},
+		},
+	}
+
+	summaries := summarizeClients(nodes)
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Log(summaries)
+		t.Error("did not compute the expected client summaries")
+	}
+}
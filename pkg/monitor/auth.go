@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// tokenFromRequest reads the API token from the `Authorization` header,
+// falling back to the `token` query parameter for clients (e.g. embedded
+// widgets) that cannot set headers.
+func tokenFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if strings.HasPrefix(header, bearerPrefix) {
+		return strings.TrimPrefix(header, bearerPrefix)
+	}
+	return r.URL.Query().Get("token")
+}
+
+func matchRoute(pattern string, path string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == path
+}
+
+func (k *APIKey) allowsRoute(path string) bool {
+	if len(k.Routes) == 0 {
+		return true
+	}
+	for _, pattern := range k.Routes {
+		if matchRoute(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) allowsNetwork(network string) bool {
+	if len(k.Networks) == 0 {
+		return true
+	}
+	for _, allowed := range k.Networks {
+		if strings.EqualFold(allowed, network) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) lookupAPIKey(token string) *APIKey {
+	if token == "" {
+		return nil
+	}
+	// NOTE: check every key so the time taken does not leak which key matched
+	var match *APIKey
+	for i := range m.config.APIKeys {
+		key := &m.config.APIKeys[i]
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(token)) == 1 {
+			match = key
+		}
+	}
+	return match
+}
+
+// withAuth enforces the configured API keys on `handler`.
+// If no keys are configured, the API is open to everyone.
+func (m *Monitor) withAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(m.config.APIKeys) == 0 {
+			handler(w, r)
+			return
+		}
+
+		key := m.lookupAPIKey(tokenFromRequest(r))
+		if key == nil {
+			http.Error(w, "missing or unknown API key", http.StatusUnauthorized)
+			return
+		}
+		if !key.allowsNetwork(m.config.Eth2.Network) || !key.allowsRoute(r.URL.Path) {
+			http.Error(w, "API key not permitted for this resource", http.StatusForbidden)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// if no admin CIDRs are configured, admin routes are only reachable locally
+var defaultAdminCIDRs = []string{"127.0.0.0/8", "::1/128"}
+
+// parseCIDRs accepts both CIDR blocks and bare IP addresses.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid admin address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func (m *Monitor) isAdminAddress(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range m.adminNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAdmin guards management routes: the caller must connect from an
+// allowed network *and* pass the usual API key check. In read-only mode the
+// routes do not exist for anyone.
+func (m *Monitor) withAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	if m.config.ReadOnly {
+		return http.NotFound
+	}
+	authorized := m.withAuth(handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !m.isAdminAddress(remoteIP(r)) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		authorized(w, r)
+	}
+}
+
+// withReadOnly answers any request that could change state with a 404 in
+// read-only mode, whatever routes are registered and however they are
+// authorized. Only reads and CORS preflights get through.
+func (m *Monitor) withReadOnly(handler http.Handler) http.Handler {
+	if !m.config.ReadOnly {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
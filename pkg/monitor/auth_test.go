@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func statusFor(m *Monitor, path string, token string) int {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	m.withAuth(okHandler)(w, r)
+	return w.Code
+}
+
+func TestAuthOpenWithoutKeys(t *testing.T) {
+	m := &Monitor{config: &Config{}}
+	if code := statusFor(m, "/chain-monitor", ""); code != http.StatusOK {
+		t.Errorf("expected open access, got %d", code)
+	}
+}
+
+func TestAuthScopesKeys(t *testing.T) {
+	m := &Monitor{config: &Config{
+		Eth2: Eth2Config{Network: "mainnet"},
+		APIKeys: []APIKey{
+			{Name: "admin", Key: "admin-token"},
+			{Name: "partner", Key: "partner-token", Routes: []string{"/chain-monitor", "/api/v1/clients*"}},
+			{Name: "testnet", Key: "testnet-token", Networks: []string{"prater"}},
+		},
+	}}
+
+	cases := []struct {
+		path  string
+		token string
+		code  int
+	}{
+		{"/chain-monitor", "", http.StatusUnauthorized},
+		{"/chain-monitor", "wrong-token", http.StatusUnauthorized},
+		{"/fork-choice", "admin-token", http.StatusOK},
+		{"/chain-monitor", "partner-token", http.StatusOK},
+		{"/api/v1/clients", "partner-token", http.StatusOK},
+		{"/api/v1/nodes", "partner-token", http.StatusForbidden},
+		{"/chain-monitor", "testnet-token", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if code := statusFor(m, c.path, c.token); code != c.code {
+			t.Errorf("%s with %q: expected %d, got %d", c.path, c.token, c.code, code)
+		}
+	}
+}
+
+func TestAdminAllowlist(t *testing.T) {
+	adminNets, err := parseCIDRs([]string{"10.0.0.0/8", "192.168.1.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Monitor{config: &Config{}, adminNets: adminNets}
+
+	cases := []struct {
+		remoteAddr string
+		code       int
+	}{
+		{"10.1.2.3:5555", http.StatusOK},
+		{"192.168.1.7:5555", http.StatusOK},
+		{"192.168.1.8:5555", http.StatusForbidden},
+		{"[::1]:5555", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+		r.RemoteAddr = c.remoteAddr
+		w := httptest.NewRecorder()
+		m.withAdmin(okHandler)(w, r)
+		if w.Code != c.code {
+			t.Errorf("%s: expected %d, got %d", c.remoteAddr, c.code, w.Code)
+		}
+	}
+
+	if _, err := parseCIDRs([]string{"not-an-address"}); err == nil {
+		t.Error("expected invalid address to be rejected")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	m := &Monitor{config: &Config{ReadOnly: true}}
+	nets, _ := parseCIDRs(defaultAdminCIDRs)
+	m.adminNets = nets
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	r.RemoteAddr = "127.0.0.1:1234"
+	w := httptest.NewRecorder()
+	m.withAdmin(okHandler)(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected admin routes to be gone, got %d", w.Code)
+	}
+
+	handler := m.withReadOnly(m.withAuth(okHandler))
+	for method, code := range map[string]int{http.MethodGet: http.StatusOK, http.MethodPost: http.StatusNotFound, http.MethodDelete: http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/chain-monitor", nil))
+		if w.Code != code {
+			t.Errorf("%s: expected %d, got %d", method, code, w.Code)
+		}
+	}
+}
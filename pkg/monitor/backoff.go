@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const minBackoff = 2 * time.Second
+const maxBackoff = 5 * time.Minute
+
+var errBackingOff = errors.New("backing off after the node asked us to slow down")
+
+// isRateLimit reports whether the node asked us to slow down
+func isRateLimit(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter reads a `Retry-After` header given either as a number of
+// seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// backoff tracks when we may next send requests to a rate limiting node;
+// the zero value is ready to use.
+type backoff struct {
+	lock     sync.Mutex
+	until    time.Time
+	failures int
+}
+
+func (b *backoff) active(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return now.Before(b.until)
+}
+
+// fail backs off for `retryAfter` if the node gave us a delay, otherwise
+// exponentially in the number of consecutive rate limited responses.
+func (b *backoff) fail(retryAfter time.Duration, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures++
+	delay := retryAfter
+	if delay <= 0 {
+		delay = minBackoff << uint(b.failures-1)
+	}
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	b.until = now.Add(delay)
+}
+
+func (b *backoff) reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.until = time.Time{}
+	b.failures = 0
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cases := map[string]time.Duration{
+		"":     0,
+		"30":   30 * time.Second,
+		"-1":   0,
+		"soon": 0,
+		now.Add(time.Minute).UTC().Format(http.TimeFormat): time.Minute,
+	}
+	for header, expected := range cases {
+		if delay := parseRetryAfter(header, now); delay != expected {
+			t.Errorf("%q: expected %s, got %s", header, expected, delay)
+		}
+	}
+}
+
+func TestBackoffOnRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	node := &Node{endpoint: server.URL, version: "teku/v20.11.0"}
+	node.useTransport(http.DefaultTransport)
+	m := &Monitor{config: &Config{}, nodes: []*Node{node}, alerts: newAlertSet()}
+	for i := 0; i < 3; i++ {
+		err := m.fetchHeads()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected a single request while backing off, sent %d", requests)
+	}
+	if node.status != StatusRateLimited {
+		t.Errorf("expected node to be rate limited, got %s", node.status)
+	}
+	if remaining := time.Until(node.backoff.until); remaining < 50*time.Second || remaining > time.Minute {
+		t.Errorf("backoff does not honor Retry-After: %s", remaining)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"root": "0xaa", "header": {"message": {"slot": "10"}}}}`))
+	})
+	// requests are held back until the delay has passed
+	node.fetchLatestHead(&wg)
+	if node.status != StatusRateLimited || requests != 1 {
+		t.Errorf("expected no request while backing off, got status %s", node.status)
+	}
+	node.backoff.until = time.Now()
+	wg.Add(1)
+	node.fetchLatestHead(&wg)
+	if node.backoff.active(time.Now()) || node.backoff.failures != 0 || node.status != StatusOK {
+		t.Error("expected backoff to reset after a successful request")
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	now := time.Now()
+	b := backoff{}
+	b.fail(0, now)
+	if b.until.Sub(now) != minBackoff {
+		t.Errorf("unexpected first backoff %s", b.until.Sub(now))
+	}
+	b.fail(0, now)
+	if b.until.Sub(now) != 2*minBackoff {
+		t.Errorf("unexpected second backoff %s", b.until.Sub(now))
+	}
+	for i := 0; i < 100; i++ {
+		b.fail(0, now)
+	}
+	if b.until.Sub(now) != maxBackoff {
+		t.Errorf("expected backoff to be capped, got %s", b.until.Sub(now))
+	}
+}
+
+func TestBackoffCoversEveryFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"code":503,"message":"busy"}`))
+	}))
+	defer server.Close()
+
+	node := &Node{endpoint: server.URL}
+	node.useTransport(http.DefaultTransport)
+	if _, err := node.fetchProtoArray(); err == nil {
+		t.Fatal("expected the proto array fetch to fail")
+	}
+	if !node.backoff.active(time.Now()) {
+		t.Fatal("expected a rate limited proto array fetch to back off")
+	}
+	_, _, err := node.fetchFinalityCheckpoints()
+	if classifyError(err) != StatusRateLimited || requests != 1 {
+		t.Errorf("expected other queries to be held back, sent %d requests (%v)", requests, err)
+	}
+}
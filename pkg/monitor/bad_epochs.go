@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type badEpochLinks struct {
+	Report string `json:"report"`
+	Reorgs string `json:"reorgs"`
+}
+
+type badEpoch struct {
+	Epoch      int           `json:"epoch"`
+	Reasons    []string      `json:"reasons"`
+	TargetRate float64       `json:"target_rate"`
+	Reorgs     int           `json:"reorgs"`
+	Links      badEpochLinks `json:"links"`
+}
+
+type badEpochsResp struct {
+	Epochs []badEpoch `json:"epochs"`
+}
+
+type epochReport struct {
+	Epoch           int            `json:"epoch"`
+	Participation   *Participation `json:"participation"`
+	BadEpochReasons []string       `json:"bad_epoch_reasons"`
+	Reorgs          []Reorg        `json:"reorgs"`
+}
+
+// badEpochReasons explains why `participation` failed to justify, if it did.
+// Only entries that are complete, i.e. whose justification is known, are judged.
+func badEpochReasons(participation Participation) []string {
+	if participation.Justified == nil {
+		return nil
+	}
+	var reasons []string
+	if participation.TargetRate < justificationThreshold {
+		reasons = append(reasons, fmt.Sprintf("target participation of %.1f%% is below 2/3", participation.TargetRate))
+	}
+	if !*participation.Justified {
+		reasons = append(reasons, "justification did not advance")
+	}
+	return reasons
+}
+
+func epochLinks(epoch int) badEpochLinks {
+	return badEpochLinks{
+		Report: fmt.Sprintf("/api/v1/epochs/%d", epoch),
+		Reorgs: fmt.Sprintf("/api/v1/reorgs?epoch=%d", epoch),
+	}
+}
+
+func (m *Monitor) sendBadEpochs(w http.ResponseWriter, r *http.Request) {
+	resp := badEpochsResp{Epochs: []badEpoch{}}
+	for _, participation := range m.participationHistory() {
+		reasons := badEpochReasons(participation)
+		if len(reasons) == 0 {
+			continue
+		}
+		resp.Epochs = append(resp.Epochs, badEpoch{
+			Epoch:      participation.Epoch,
+			Reasons:    reasons,
+			TargetRate: participation.TargetRate,
+			Reorgs:     len(m.reorgHistory(participation.Epoch)),
+			Links:      epochLinks(participation.Epoch),
+		})
+	}
+	sort.Slice(resp.Epochs, func(i, j int) bool { return resp.Epochs[i].Epoch > resp.Epochs[j].Epoch })
+	writeJSON(w, r, &resp)
+}
+
+// sendEpochReport serves everything known about the epoch at `/api/v1/epochs/{epoch}`.
+func (m *Monitor) sendEpochReport(w http.ResponseWriter, r *http.Request) {
+	epoch, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/epochs/"), "/"))
+	if err != nil || epoch < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	report := epochReport{Epoch: epoch, Reorgs: m.reorgHistory(epoch)}
+	for _, participation := range m.participationHistory() {
+		if participation.Epoch == epoch {
+			participation := participation
+			report.Participation = &participation
+			report.BadEpochReasons = badEpochReasons(participation)
+		}
+	}
+	writeJSON(w, r, &report)
+}
@@ -0,0 +1,21 @@
+package monitor
+
+import "testing"
+
+func TestBadEpochReasons(t *testing.T) {
+	justified := true
+	notJustified := false
+
+	if reasons := badEpochReasons(Participation{TargetRate: 10}); reasons != nil {
+		t.Errorf("expected incomplete epochs not to be judged, got %v", reasons)
+	}
+	if reasons := badEpochReasons(Participation{TargetRate: 90, Justified: &justified}); reasons != nil {
+		t.Errorf("expected a healthy epoch, got %v", reasons)
+	}
+	if reasons := badEpochReasons(Participation{TargetRate: 60, Justified: &justified}); len(reasons) != 1 {
+		t.Errorf("expected low target participation to be flagged, got %v", reasons)
+	}
+	if reasons := badEpochReasons(Participation{TargetRate: 60, Justified: &notJustified}); len(reasons) != 2 {
+		t.Errorf("expected both reasons to be flagged, got %v", reasons)
+	}
+}
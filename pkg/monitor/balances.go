@@ -0,0 +1,241 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const validatorsPath = "/eth/v1/beacon/states/head/validators"
+const balanceEpochsCount = 64
+
+const gweiPerEth = 1000000000
+
+// upper bounds in ETH of the effective balance buckets, the last bucket
+// includes everything up to the Electra maximum effective balance
+var effectiveBalanceBuckets = []uint64{31, 32, 64, 128, 256, 512, 1024, 2048}
+
+type balanceBucket struct {
+	// upper bound in ETH, inclusive
+	MaxEth uint64 `json:"max_eth"`
+	Count  int    `json:"count"`
+}
+
+// ConsolidationRequest is an Electra execution layer request to merge validators
+type ConsolidationRequest struct {
+	Slot          int    `json:"slot,string"`
+	SourceAddress string `json:"source_address"`
+	SourcePubkey  string `json:"source_pubkey"`
+	TargetPubkey  string `json:"target_pubkey"`
+}
+
+type balanceEpoch struct {
+	Epoch              int                    `json:"epoch"`
+	ActiveValidators   int                    `json:"active_validators"`
+	TotalEffectiveGwei uint64                 `json:"total_effective_gwei"`
+	Histogram          []balanceBucket        `json:"histogram"`
+	Consolidations     []ConsolidationRequest `json:"consolidations"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+// balanceHistory keeps the latest epochs; the zero value is ready to use.
+type balanceHistory struct {
+	lock   sync.Mutex
+	epochs []balanceEpoch
+}
+
+func (h *balanceHistory) add(epoch balanceEpoch) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.epochs = append(h.epochs, epoch)
+	if len(h.epochs) > balanceEpochsCount {
+		h.epochs = h.epochs[len(h.epochs)-balanceEpochsCount:]
+	}
+}
+
+func (h *balanceHistory) list() []balanceEpoch {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]balanceEpoch{}, h.epochs...)
+}
+
+func newHistogram() []balanceBucket {
+	histogram := make([]balanceBucket, len(effectiveBalanceBuckets))
+	for i, max := range effectiveBalanceBuckets {
+		histogram[i].MaxEth = max
+	}
+	return histogram
+}
+
+func addToHistogram(histogram []balanceBucket, effectiveBalanceGwei uint64) {
+	eth := effectiveBalanceGwei / gweiPerEth
+	for i := range histogram {
+		if eth <= histogram[i].MaxEth || i == len(histogram)-1 {
+			histogram[i].Count++
+			return
+		}
+	}
+}
+
+type validatorEntry struct {
+	Status    string `json:"status"`
+	Validator struct {
+		EffectiveBalance string `json:"effective_balance"`
+	} `json:"validator"`
+}
+
+// summarizeValidators streams the validator list in `r`, which can be huge,
+// into the effective balance histogram of the active validators.
+func summarizeValidators(r io.Reader) (balanceEpoch, error) {
+	summary := balanceEpoch{Histogram: newHistogram()}
+	dec := json.NewDecoder(r)
+	// find the `data` array
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return summary, err
+		}
+		if key, ok := token.(string); ok && key == "data" {
+			break
+		}
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return summary, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return summary, errors.New("validators data is not a list")
+	}
+	for dec.More() {
+		entry := validatorEntry{}
+		err := dec.Decode(&entry)
+		if err != nil {
+			return summary, err
+		}
+		if !strings.HasPrefix(entry.Status, "active") {
+			continue
+		}
+		balance, err := strconv.ParseUint(entry.Validator.EffectiveBalance, 10, 64)
+		if err != nil {
+			return summary, fmt.Errorf("invalid effective balance: %v", err)
+		}
+		summary.ActiveValidators++
+		summary.TotalEffectiveGwei += balance
+		addToHistogram(summary.Histogram, balance)
+	}
+	return summary, nil
+}
+
+func (n *Node) fetchValidatorSummary() (_ balanceEpoch, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + validatorsPath)
+	if err != nil {
+		return balanceEpoch{}, err
+	}
+	defer resp.Body.Close()
+	err = checkStatus(resp)
+	if err != nil {
+		return balanceEpoch{}, err
+	}
+	return summarizeValidators(resp.Body)
+}
+
+type consolidationsBlockResp struct {
+	Data struct {
+		Message struct {
+			Slot int `json:"slot,string"`
+			Body struct {
+				ExecutionRequests *struct {
+					Consolidations []ConsolidationRequest `json:"consolidations"`
+				} `json:"execution_requests"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
+// fetchConsolidations returns the consolidation requests in the block at
+// `slot`, if there is one. Blocks before Electra have none.
+func (n *Node) fetchConsolidations(slot int) (_ []ConsolidationRequest, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + fmt.Sprintf(blockV2PathFmt, strconv.Itoa(slot)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// empty slot
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	data := consolidationsBlockResp{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return nil, err
+	}
+	requests := data.Data.Message.Body.ExecutionRequests
+	if requests == nil {
+		return nil, nil
+	}
+	for i := range requests.Consolidations {
+		requests.Consolidations[i].Slot = data.Data.Message.Slot
+	}
+	return requests.Consolidations, nil
+}
+
+// updateBalances records the effective balances now and the consolidations
+// in the blocks of the previous epoch.
+func (m *Monitor) updateBalances() error {
+	node, err := m.passthroughProvider()
+	if err != nil {
+		return err
+	}
+	m.fetches.acquire(cosmeticFetch)
+	defer m.fetches.release()
+	summary, err := node.fetchValidatorSummary()
+	if err != nil {
+		return err
+	}
+
+	config := m.eth2()
+	epoch := m.getCurrentEpoch()
+	summary.Epoch = epoch
+	summary.Consolidations = []ConsolidationRequest{}
+	if epoch > 0 {
+		start := (epoch - 1) * config.SlotsPerEpoch
+		for slot := start; slot < start+config.SlotsPerEpoch; slot++ {
+			consolidations, err := node.fetchConsolidations(slot)
+			if err != nil {
+				return err
+			}
+			summary.Consolidations = append(summary.Consolidations, consolidations...)
+		}
+	}
+	summary.UpdatedAt = time.Now()
+	m.balances.add(summary)
+	return nil
+}
+
+func (m *Monitor) startBalanceMonitor(beat func() bool) {
+	config := m.eth2()
+	for beat() {
+		err := m.updateBalances()
+		if err != nil {
+			log.Println(err)
+		}
+		waitUntilNextEpoch(config.GenesisTime, config.SecondsPerSlot, config.SlotsPerEpoch)
+	}
+}
+
+type balancesResp struct {
+	Epochs []balanceEpoch `json:"epochs"`
+}
+
+func (m *Monitor) sendBalances(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, &balancesResp{Epochs: m.balances.list()})
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeValidators(t *testing.T) {
+	validators := `{"execution_optimistic": false, "data": [
+		{"index": "0", "status": "active_ongoing", "validator": {"effective_balance": "32000000000"}},
+		{"index": "1", "status": "active_exiting", "validator": {"effective_balance": "31000000000"}},
+		{"index": "2", "status": "pending_queued", "validator": {"effective_balance": "32000000000"}},
+		{"index": "3", "status": "active_ongoing", "validator": {"effective_balance": "2048000000000"}},
+		{"index": "4", "status": "active_ongoing", "validator": {"effective_balance": "100000000000"}}
+	]}`
+
+	summary, err := summarizeValidators(strings.NewReader(validators))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ActiveValidators != 4 || summary.TotalEffectiveGwei != 2211000000000 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	counts := map[uint64]int{}
+	for _, bucket := range summary.Histogram {
+		counts[bucket.MaxEth] = bucket.Count
+	}
+	if counts[31] != 1 || counts[32] != 1 || counts[128] != 1 || counts[2048] != 1 {
+		t.Errorf("unexpected histogram %+v", summary.Histogram)
+	}
+}
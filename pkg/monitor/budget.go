@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// optional subsystems (peering, subnets, balances, the chain and passthrough
+// queries, proto array snapshots) stop using a node once this fraction of its
+// budget is spent, leaving the rest for heads, finality and fork choice
+const optionalBudgetFraction = 0.8
+
+var errBudgetExhausted = errors.New("request budget exhausted")
+
+// requestBudget counts the requests sent to a node over the last minute and
+// enforces an optional limit on them; the zero value counts without a limit.
+type requestBudget struct {
+	lock  sync.Mutex
+	limit int
+	// requests per second of the last minute, indexed by the unix time of
+	// the second modulo 60
+	counts  [60]int
+	seconds [60]int64
+}
+
+func (b *requestBudget) setLimit(limit int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.limit = limit
+}
+
+func (b *requestBudget) usedLocked(now time.Time) int {
+	used := 0
+	for i, second := range b.seconds {
+		if second > now.Unix()-60 {
+			used += b.counts[i]
+		}
+	}
+	return used
+}
+
+// usage returns the number of requests sent in the minute before `now` and
+// the limit on them, zero if there is none.
+func (b *requestBudget) usage(now time.Time) (int, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.usedLocked(now), b.limit
+}
+
+// take records a request at `now` unless it would exceed the budget.
+func (b *requestBudget) take(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.limit > 0 && b.usedLocked(now) >= b.limit {
+		return false
+	}
+	second := now.Unix()
+	i := second % 60
+	if b.seconds[i] != second {
+		b.seconds[i] = second
+		b.counts[i] = 0
+	}
+	b.counts[i]++
+	return true
+}
+
+// allowsOptional is false once optional subsystems should leave the node alone.
+func (b *requestBudget) allowsOptional(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.limit <= 0 || float64(b.usedLocked(now)) < optionalBudgetFraction*float64(b.limit)
+}
+
+// budgetTransport fails requests beyond the budget, or sent while the node
+// asked us to back off, before they reach the node. Every request to the
+// node goes through it, so a rate limited response from any query backs
+// off all of them.
+type budgetTransport struct {
+	next    http.RoundTripper
+	budget  *requestBudget
+	backoff *backoff
+}
+
+func (t *budgetTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	now := time.Now()
+	if t.backoff != nil && t.backoff.active(now) {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, errBackingOff
+	}
+	if !t.budget.take(now) {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, errBudgetExhausted
+	}
+	resp, err := t.next.RoundTrip(request)
+	if err != nil || t.backoff == nil {
+		return resp, err
+	}
+	if isRateLimit(resp.StatusCode) {
+		t.backoff.fail(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), time.Now())
+	} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		t.backoff.reset()
+	}
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestBudget(t *testing.T) {
+	var budget requestBudget
+	start := time.Unix(1000, 0)
+	for i := 0; i < 100; i++ {
+		if !budget.take(start) {
+			t.Fatal("expected requests without a limit to be allowed")
+		}
+	}
+
+	budget.setLimit(10)
+	if budget.take(start) || budget.allowsOptional(start) {
+		t.Error("expected the budget to be spent")
+	}
+	if used, limit := budget.usage(start.Add(30 * time.Second)); used != 100 || limit != 10 {
+		t.Errorf("expected 100 of 10 requests used, got %d of %d", used, limit)
+	}
+
+	// the requests of a minute ago no longer count
+	later := start.Add(time.Minute)
+	for i := 0; i < 8; i++ {
+		if !budget.allowsOptional(later) || !budget.take(later) {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+	if budget.allowsOptional(later) {
+		t.Error("expected optional requests to stop short of the limit")
+	}
+	if !budget.take(later) || !budget.take(later) || budget.take(later) {
+		t.Error("expected the remaining requests to be reserved for essential queries")
+	}
+}
+
+func TestBudgetTransport(t *testing.T) {
+	stub := beaconStub("peer-a")
+	defer stub.Close()
+
+	nodes, _ := discoverNodes([]Endpoint{{Addr: stub.URL, RequestsPerMinute: 5}}, 1000)
+	if len(nodes) != 1 {
+		t.Fatal("expected to find the node")
+	}
+	node := nodes[0]
+	used, limit := node.requests.usage(time.Now())
+	if used == 0 || limit != 5 {
+		t.Fatalf("expected the probe to be counted against the budget, got %d of %d", used, limit)
+	}
+
+	var err error
+	for i := used; i <= limit; i++ {
+		err = node.doFetchSyncStatus()
+	}
+	if err == nil {
+		t.Error("expected requests beyond the budget to fail")
+	}
+}
+
+func TestBudgetExhaustedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	budget := &requestBudget{}
+	budget.setLimit(1)
+	client := &http.Client{Transport: &budgetTransport{next: http.DefaultTransport, budget: budget}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	_, err = client.Get(server.URL)
+	if status := classifyError(err); status != StatusBudgetExhausted {
+		t.Errorf("expected the spent budget not to mark the node unreachable, got %s (%v)", status, err)
+	}
+}
@@ -0,0 +1,184 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const blockPathFmt = "/eth/v1/beacon/blocks/%s"
+const defaultChainLength = 32
+const maxChainLength = 128
+
+// blocks a single chain request may fetch from the node, the rest of the
+// chain is served once later requests have filled the cache
+const maxChainFetchesPerRequest = 16
+
+const zeroRoot = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+// ChainBlock is a block of the canonical chain with a link to its parent.
+type ChainBlock struct {
+	Slot          int    `json:"slot,string"`
+	Root          string `json:"root"`
+	ParentRoot    string `json:"parent_root"`
+	ProposerIndex string `json:"proposer_index"`
+	Graffiti      string `json:"graffiti"`
+}
+
+type blockResp struct {
+	Data struct {
+		Message struct {
+			Slot          int    `json:"slot,string"`
+			ProposerIndex string `json:"proposer_index"`
+			ParentRoot    string `json:"parent_root"`
+			Body          struct {
+				Graffiti string `json:"graffiti"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
+// decodeGraffiti renders the hex encoded graffiti as text if it is
+// printable and leaves it hex encoded otherwise.
+func decodeGraffiti(graffiti string) string {
+	raw, err := hex.DecodeString(strings.TrimPrefix(graffiti, "0x"))
+	if err != nil {
+		return graffiti
+	}
+	raw = bytes.TrimRight(raw, "\x00")
+	if !utf8.Valid(raw) {
+		return graffiti
+	}
+	return string(raw)
+}
+
+func (n *Node) fetchBlock(root string) (_ ChainBlock, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + fmt.Sprintf(blockPathFmt, root))
+	if err != nil {
+		return ChainBlock{}, err
+	}
+	defer resp.Body.Close()
+	data := blockResp{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return ChainBlock{}, err
+	}
+	message := data.Data.Message
+	return ChainBlock{
+		Slot:          message.Slot,
+		Root:          root,
+		ParentRoot:    message.ParentRoot,
+		ProposerIndex: message.ProposerIndex,
+		Graffiti:      decodeGraffiti(message.Body.Graffiti),
+	}, nil
+}
+
+// chainCache holds the blocks of the last canonical chain served so that
+// only new blocks have to be fetched; the zero value is ready to use. Each
+// consumer keeps its own cache so a shorter chain does not evict the blocks
+// of a longer one.
+type chainCache struct {
+	lock   sync.Mutex
+	blocks map[string]ChainBlock
+}
+
+func (c *chainCache) get(root string) (ChainBlock, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	block, ok := c.blocks[root]
+	return block, ok
+}
+
+// canonicalChain follows the parent links from `headRoot` for up to `length`
+// blocks, newest first. At most `maxFetches` blocks missing from the cache
+// are fetched, without a cap if it is not positive; the chain is cut short
+// beyond them and reported as incomplete. Blocks are fetched without holding
+// the lock so slow nodes do not hold up concurrent requests.
+func (c *chainCache) canonicalChain(node *Node, headRoot string, length int, maxFetches int) ([]ChainBlock, bool, error) {
+	chain := []ChainBlock{}
+	complete := true
+	fetches := 0
+	root := headRoot
+	for len(chain) < length && root != "" && root != zeroRoot {
+		block, ok := c.get(root)
+		if !ok {
+			if maxFetches > 0 && fetches == maxFetches {
+				complete = false
+				break
+			}
+			fetches++
+			var err error
+			block, err = node.fetchBlock(root)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		chain = append(chain, block)
+		root = block.ParentRoot
+	}
+
+	// only the latest chain is useful for the next request
+	blocks := make(map[string]ChainBlock, len(chain))
+	for _, block := range chain {
+		blocks[block.Root] = block
+	}
+	c.lock.Lock()
+	c.blocks = blocks
+	c.lock.Unlock()
+	return chain, complete, nil
+}
+
+type chainResp struct {
+	Source string       `json:"source"`
+	Blocks []ChainBlock `json:"blocks"`
+	// set if fewer blocks than asked for are served to spare the node,
+	// asking again serves more of them
+	Partial bool `json:"partial"`
+}
+
+// chainProvider picks a healthy node that follows the majority head and has
+// budget to spare.
+func (m *Monitor) chainProvider() (*Node, string, error) {
+	nodes := m.nodeList()
+	head, _ := majorityHead(nodes)
+	now := time.Now()
+	for _, node := range nodes {
+		if node.isHealthy && !isPrysm(node.version) && node.latestHead.root == head.root && node.requests.allowsOptional(now) {
+			return node, head.root, nil
+		}
+	}
+	return nil, "", errors.New("no healthy node available to serve the chain")
+}
+
+// sendChain serves the last `?length=` blocks of the canonical chain.
+func (m *Monitor) sendChain(w http.ResponseWriter, r *http.Request) {
+	length, err := parsePositiveInt(r.URL.Query(), "length", defaultChainLength)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if length > maxChainLength {
+		length = maxChainLength
+	}
+
+	node, headRoot, err := m.chainProvider()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	m.fetches.acquire(cosmeticFetch)
+	blocks, complete, err := m.chain.canonicalChain(node, headRoot, length, maxChainFetchesPerRequest)
+	m.fetches.release()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, r, &chainResp{Source: node.id, Blocks: blocks, Partial: !complete})
+}
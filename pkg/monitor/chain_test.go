@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeGraffiti(t *testing.T) {
+	if graffiti := decodeGraffiti("0x4c69676874686f7573650000000000000000000000000000000000000000000000"); graffiti != "Lighthouse" {
+		t.Errorf("unexpected graffiti %q", graffiti)
+	}
+	if graffiti := decodeGraffiti("0xff00"); graffiti != "0xff00" {
+		t.Errorf("expected binary graffiti to stay hex encoded, got %q", graffiti)
+	}
+}
+
+func TestCanonicalChain(t *testing.T) {
+	var cache chainCache
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		// concurrent requests can use the cache while blocks are fetched
+		cache.get(zeroRoot)
+		// block `n` is at slot `n` with parent `n-1`, block 0 is genesis
+		slot, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/blocks/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		resp := blockResp{}
+		resp.Data.Message.Slot = slot
+		resp.Data.Message.ParentRoot = strconv.Itoa(slot - 1)
+		if slot == 0 {
+			resp.Data.Message.ParentRoot = zeroRoot
+		}
+		json.NewEncoder(w).Encode(&resp)
+	}))
+	defer server.Close()
+	node := &Node{endpoint: server.URL}
+
+	chain, _, err := cache.canonicalChain(node, "10", 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 4 || chain[0].Root != "10" || chain[3].Root != "7" || chain[3].ParentRoot != "6" {
+		t.Errorf("unexpected chain %+v", chain)
+	}
+
+	fetches = 0
+	chain, _, err = cache.canonicalChain(node, "12", 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 || chain[3].Root != "9" {
+		t.Errorf("expected only the new blocks to be fetched, fetched %d for %+v", fetches, chain)
+	}
+
+	chain, _, err = cache.canonicalChain(node, "2", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 {
+		t.Errorf("expected the chain to end at genesis, got %+v", chain)
+	}
+
+	// a capped request serves what it could fetch and the next one continues
+	fetches = 0
+	chain, complete, err := cache.canonicalChain(node, "30", 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complete || fetches != 4 || len(chain) != 4 || chain[3].Root != "27" {
+		t.Errorf("expected 4 of 10 blocks, fetched %d for %+v", fetches, chain)
+	}
+	chain, complete, err = cache.canonicalChain(node, "30", 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complete || fetches != 8 || len(chain) != 8 {
+		t.Errorf("expected the cached blocks and 4 more, fetched %d for %+v", fetches, chain)
+	}
+}
@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"net/http"
+	"time"
+)
+
+type clockResp struct {
+	ServerTime         time.Time `json:"server_time"`
+	GenesisTime        int       `json:"genesis_time"`
+	SecondsPerSlot     int       `json:"seconds_per_slot"`
+	SlotsPerEpoch      int       `json:"slots_per_epoch"`
+	CurrentSlot        int       `json:"current_slot"`
+	CurrentEpoch       int       `json:"current_epoch"`
+	SecondsIntoSlot    float64   `json:"seconds_into_slot"`
+	SecondsToNextEpoch float64   `json:"seconds_to_next_epoch"`
+}
+
+// computeClock does the slot arithmetic for `now`. Before genesis the clock
+// stays at slot 0 and counts down to genesis.
+func computeClock(config Eth2Config, now time.Time) clockResp {
+	resp := clockResp{
+		ServerTime:     now.UTC(),
+		GenesisTime:    config.GenesisTime,
+		SecondsPerSlot: config.SecondsPerSlot,
+		SlotsPerEpoch:  config.SlotsPerEpoch,
+	}
+
+	genesis := time.Unix(int64(config.GenesisTime), 0)
+	sinceGenesis := now.Sub(genesis)
+	if sinceGenesis < 0 {
+		resp.SecondsToNextEpoch = -sinceGenesis.Seconds()
+		return resp
+	}
+
+	slotDuration := time.Duration(config.SecondsPerSlot) * time.Second
+	epochDuration := slotDuration * time.Duration(config.SlotsPerEpoch)
+	resp.CurrentSlot = int(sinceGenesis / slotDuration)
+	resp.CurrentEpoch = resp.CurrentSlot / config.SlotsPerEpoch
+	resp.SecondsIntoSlot = (sinceGenesis % slotDuration).Seconds()
+	resp.SecondsToNextEpoch = (epochDuration - sinceGenesis%epochDuration).Seconds()
+	return resp
+}
+
+func (m *Monitor) sendClock(w http.ResponseWriter, r *http.Request) {
+	resp := computeClock(m.eth2(), time.Now())
+	writeJSON(w, r, &resp)
+}
@@ -0,0 +1,28 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeClock(t *testing.T) {
+	config := Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}
+	genesis := time.Unix(1606824023, 0)
+
+	// 2.5s into slot 65, i.e. the second slot of epoch 2
+	clock := computeClock(config, genesis.Add(65*12*time.Second+2500*time.Millisecond))
+	if clock.CurrentSlot != 65 || clock.CurrentEpoch != 2 {
+		t.Errorf("expected slot 65 in epoch 2, got slot %d in epoch %d", clock.CurrentSlot, clock.CurrentEpoch)
+	}
+	if clock.SecondsIntoSlot != 2.5 {
+		t.Errorf("expected to be 2.5s into the slot, got %f", clock.SecondsIntoSlot)
+	}
+	if clock.SecondsToNextEpoch != 31*12-2.5 {
+		t.Errorf("unexpected time to next epoch %f", clock.SecondsToNextEpoch)
+	}
+
+	clock = computeClock(config, genesis.Add(-time.Minute))
+	if clock.CurrentSlot != 0 || clock.SecondsToNextEpoch != 60 {
+		t.Errorf("expected to count down to genesis, got %+v", clock)
+	}
+}
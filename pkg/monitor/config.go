@@ -1,22 +1,158 @@
 package monitor
 
+import "time"
+
 type Eth2Config struct {
-	SecondsPerSlot int    `json:"seconds_per_slot" yaml:"seconds_per_slot"`
-	GenesisTime    int    `json:"genesis_time" yaml:"genesis_time"`
-	SlotsPerEpoch  int    `json:"slots_per_epoch" yaml:"slots_per_epoch"`
-	Network        string `json:"network" yaml:"network"`
+	SecondsPerSlot         int    `json:"seconds_per_slot" yaml:"seconds_per_slot"`
+	GenesisTime            int    `json:"genesis_time" yaml:"genesis_time"`
+	SlotsPerEpoch          int    `json:"slots_per_epoch" yaml:"slots_per_epoch"`
+	Network                string `json:"network" yaml:"network"`
+	DepositContractAddress string `json:"deposit_contract_address" yaml:"deposit_contract_address"`
+	// execution chain the deposit contract is deployed on
+	DepositChainID int `json:"deposit_chain_id" yaml:"deposit_chain_id"`
+	// fork name (e.g. "altair") to activation epoch
+	ForkEpochs map[string]int `json:"fork_epochs,omitempty" yaml:"fork_epochs"`
+	// first block to look for deposit logs in
+	DepositContractDeployBlock int `json:"deposit_contract_deploy_block" yaml:"deposit_contract_deploy_block"`
+	// length of a sync committee period, part of the preset
+	EpochsPerSyncCommitteePeriod int `json:"epochs_per_sync_committee_period" yaml:"epochs_per_sync_committee_period"`
+	// optional path to a consensus client style `config.yaml`
+	SpecFile string `json:"-" yaml:"spec_file"`
+}
+
+// EndpointTLS configures how connections to an https:// endpoint are
+// verified and authenticated.
+type EndpointTLS struct {
+	// PEM bundle of CAs to trust in addition to the system roots
+	CAFile string `yaml:"ca_file"`
+	// PEM client certificate and key, for nodes requiring mutual TLS
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// accept any certificate, only for testing
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
 }
 
 type Endpoint struct {
-	Addr string `json:"addr" yaml:"addr"`
-	Eth1 string `json:"eth1" yaml:"eth1"`
+	Addr  string `json:"addr" yaml:"addr"`
+	Eth1  string `json:"eth1" yaml:"eth1"`
+	Label string `json:"label" yaml:"label"`
+	// file to read `addr` from if it embeds credentials
+	AddrFile string `json:"-" yaml:"addr_file"`
+
+	// Roles this node may take on. If unset, Lighthouse nodes are
+	// assumed to serve fork choice and participation data.
+	ForkChoice    *bool `json:"fork_choice" yaml:"fork_choice"`
+	Participation *bool `json:"participation" yaml:"participation"`
+	// among the nodes for a role, the one with the lowest priority is used
+	Priority int `json:"priority" yaml:"priority"`
+
+	// optional Prometheus metrics URL to sample subnet peer counts from
+	Metrics string `json:"metrics" yaml:"metrics"`
+
+	// optional http://, https:// or socks5:// proxy to reach the node
+	// through, or "direct" to ignore HTTP(S)_PROXY for this node
+	Proxy string `json:"-" yaml:"proxy"`
+	// optional IPs to connect to instead of resolving the host of `addr`,
+	// tried in order
+	PinnedIPs []string `json:"-" yaml:"pinned_ips"`
+	// optional DNS server to resolve the node's host names with, e.g. for
+	// split-horizon setups
+	Resolver string `json:"-" yaml:"resolver"`
+
+	TLS EndpointTLS `json:"-" yaml:"tls"`
+
+	// optional cap on the requests sent to the node per minute; optional
+	// data like peering, subnets and balances is skipped first
+	RequestsPerMinute int `json:"-" yaml:"requests_per_minute"`
+}
+
+// APIKey grants a single tenant access to the API. Routes and Networks
+// restrict what the key may read; an empty list places no restriction.
+// A route ending in "*" matches any path with the preceding prefix.
+type APIKey struct {
+	Name     string   `yaml:"name"`
+	Key      string   `yaml:"key"`
+	KeyFile  string   `yaml:"key_file"`
+	Routes   []string `yaml:"routes"`
+	Networks []string `yaml:"networks"`
+}
+
+// RetentionConfig bounds how long each kind of data is kept. A zero value
+// selects the default retention and a negative value keeps data forever.
+type RetentionConfig struct {
+	HeadObservations time.Duration `yaml:"head_observations"`
+	Participation    time.Duration `yaml:"participation"`
+	Reorgs           time.Duration `yaml:"reorgs"`
+
+	ProtoArraySnapshots time.Duration `yaml:"proto_array_snapshots"`
 }
 
 type Config struct {
 	Endpoints           []Endpoint
 	Eth2                Eth2Config
 	OutputDir           string
-	EtherscanAPIKey     string `yaml:"etherscan_api_key"`
-	MillisecondsTimeout int    `yaml:"http_timeout_milliseconds"`
-	WSProviderEndpoint  string `yaml:"weak_subjectivity_provider_endpoint"`
+	EtherscanAPIKey     string   `yaml:"etherscan_api_key"`
+	EtherscanAPIKeyFile string   `yaml:"etherscan_api_key_file"`
+	MillisecondsTimeout int      `yaml:"http_timeout_milliseconds"`
+	WSProviderEndpoint  string   `yaml:"weak_subjectivity_provider_endpoint"`
+	APIKeys             []APIKey `yaml:"api_keys"`
+	AdminAllowedCIDRs   []string `yaml:"admin_allowed_cidrs"`
+	// serve no admin routes and accept only reads, whatever the auth settings
+	ReadOnly bool `yaml:"read_only"`
+
+	TimeseriesResolutionSeconds int             `yaml:"timeseries_resolution_seconds"`
+	Retention                   RetentionConfig `yaml:"retention"`
+	DataDir                     string          `yaml:"data_dir"`
+
+	ForkChoiceDivergenceSlots int     `yaml:"fork_choice_divergence_slots"`
+	ForkChoiceWeightTolerance float64 `yaml:"fork_choice_weight_tolerance"`
+
+	// weight of a competing branch relative to the canonical one that counts
+	// as a high reorg risk and for how many slots it may last before alerting
+	ReorgRiskThreshold  float64 `yaml:"reorg_risk_threshold"`
+	ReorgRiskAlertSlots int     `yaml:"reorg_risk_alert_slots"`
+
+	WSExpiryWarningEpochs int `yaml:"ws_expiry_warning_epochs"`
+
+	// follow changes in genesis, always enabled for Ephemery
+	WatchGenesis bool `yaml:"watch_genesis"`
+
+	// a node is stale if its head has not changed for this many slots
+	StaleAfterSlots int `yaml:"stale_after_slots"`
+
+	// spread heavy queries over all capable nodes rather than the preferred one
+	BalanceQueries bool `yaml:"balance_queries"`
+
+	Meta MetaConfig `yaml:"meta"`
+
+	// optional ed25519 key (PKCS #8 PEM) to sign the summary and ws data with
+	SigningKeyFile string `yaml:"signing_key_file"`
+
+	// optional execution client JSON-RPC endpoint to ingest deposit logs from
+	ExecutionEndpoint     string `yaml:"execution_endpoint"`
+	ExecutionEndpointFile string `yaml:"execution_endpoint_file"`
+
+	// fetch all validators every epoch for the effective balance histograms
+	TrackBalances bool `yaml:"track_balances"`
+
+	// store the raw proto array at every epoch boundary under `data_dir`
+	ProtoArraySnapshots bool `yaml:"proto_array_snapshots"`
+
+	// serve full peer IDs, ENRs and addresses in the node detail endpoint,
+	// only for trusted deployments
+	RevealIdentity bool `yaml:"reveal_identity"`
+
+	// metric with the peer count per attestation subnet of each node
+	SubnetMetric SubnetMetricConfig `yaml:"subnet_metric"`
+
+	// validator indices to report the votes of that a reorg exposes to
+	// surround votes
+	WatchedValidators []int `yaml:"watched_validators"`
+
+	// bound on the requests in flight to all nodes together, head fetches
+	// are served first when it is reached
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
+
+	// where the settings given explicitly came from, by dotted path
+	Sources map[string]string `yaml:"-"`
 }
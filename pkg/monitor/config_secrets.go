@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// matches `$$`, `${VAR}` and `${VAR:-default}`
+var envReference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv replaces `${VAR}` in the values of the configuration with the
+// value of the environment variable VAR, or with `default` for
+// `${VAR:-default}` if VAR is unset or empty. `$$` stands for a literal `$`.
+// Referencing a variable that is unset and has no default is an error so
+// secrets are not silently empty. Only scalar values are expanded, after the
+// document is parsed, so comments are left alone and a value cannot change
+// the structure of the document. Expanded values are always strings, so a
+// secret like `0123` or `yes` is kept as is rather than read as a number or
+// a boolean, and only string settings can reference variables.
+func ExpandEnv(data []byte) ([]byte, error) {
+	var document yaml.MapSlice
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	expanded := expandValue(document, &missing)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("config references unset environment variables %s", strings.Join(missing, ", "))
+	}
+	return yaml.Marshal(expanded)
+}
+
+func expandValue(value interface{}, missing *[]string) interface{} {
+	switch value := value.(type) {
+	case yaml.MapSlice:
+		for i := range value {
+			value[i].Value = expandValue(value[i].Value, missing)
+		}
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = expandValue(value[i], missing)
+		}
+		return value
+	case string:
+		if !envReference.MatchString(value) {
+			return value
+		}
+		return expandString(value, missing)
+	}
+	return value
+}
+
+func expandString(value string, missing *[]string) string {
+	return envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		if reference == "$$" {
+			return "$"
+		}
+		groups := envReference.FindStringSubmatch(reference)
+		name := groups[1]
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		*missing = append(*missing, name)
+		return ""
+	})
+}
+
+// readSecretFile sets `value`, the setting at the dotted `path`, from the
+// contents of `file`, e.g. a mounted Kubernetes or Docker secret, dropping
+// the trailing newline.
+func (c *Config) readSecretFile(value *string, file string, path string) error {
+	if file == "" {
+		return nil
+	}
+	name := path[strings.LastIndex(path, ".")+1:]
+	if *value != "" {
+		return fmt.Errorf("set only one of `%s` and `%s_file`", name, name)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	*value = strings.TrimRight(string(data), "\r\n")
+	c.SetSource(path, SettingFromFile)
+	return nil
+}
+
+// LoadSecretFiles fills in the settings given by a `*_file` variant.
+func (c *Config) LoadSecretFiles() error {
+	err := c.readSecretFile(&c.EtherscanAPIKey, c.EtherscanAPIKeyFile, "etherscan_api_key")
+	if err != nil {
+		return err
+	}
+	err = c.readSecretFile(&c.ExecutionEndpoint, c.ExecutionEndpointFile, "execution_endpoint")
+	if err != nil {
+		return err
+	}
+	for i := range c.Endpoints {
+		err = c.readSecretFile(&c.Endpoints[i].Addr, c.Endpoints[i].AddrFile, fmt.Sprintf("endpoints.%d.addr", i))
+		if err != nil {
+			return err
+		}
+	}
+	for i := range c.APIKeys {
+		err = c.readSecretFile(&c.APIKeys[i].Key, c.APIKeys[i].KeyFile, fmt.Sprintf("api_keys.%d.key", i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
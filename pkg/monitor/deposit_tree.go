@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const depositContractTreeDepth = 32
+
+var zeroHashes = computeZeroHashes()
+
+func computeZeroHashes() [depositContractTreeDepth][32]byte {
+	var hashes [depositContractTreeDepth][32]byte
+	for i := 1; i < depositContractTreeDepth; i++ {
+		hashes[i] = hashPair(hashes[i-1], hashes[i-1])
+	}
+	return hashes
+}
+
+func hashPair(a [32]byte, b [32]byte) [32]byte {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+// chunks splits `data` into zero padded 32 byte chunks
+func chunks(data []byte) [][32]byte {
+	var result [][32]byte
+	for i := 0; i < len(data); i += 32 {
+		var chunk [32]byte
+		copy(chunk[:], data[i:])
+		result = append(result, chunk)
+	}
+	return result
+}
+
+// merkleize computes the root of `leaves` padded with zero chunks to the next power of two
+func merkleize(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+	for level := 0; len(leaves) > 1; level++ {
+		if len(leaves)%2 == 1 {
+			leaves = append(leaves, zeroHashes[level])
+		}
+		next := make([][32]byte, 0, len(leaves)/2)
+		for i := 0; i < len(leaves); i += 2 {
+			next = append(next, hashPair(leaves[i], leaves[i+1]))
+		}
+		leaves = next
+	}
+	return leaves[0]
+}
+
+// depositDataRoot is the SSZ hash tree root of the `DepositData` container
+func depositDataRoot(deposit DepositEvent) [32]byte {
+	var amount [32]byte
+	binary.LittleEndian.PutUint64(amount[:], deposit.AmountGwei)
+	var credentials [32]byte
+	copy(credentials[:], deposit.WithdrawalCredentials)
+	return merkleize([][32]byte{
+		merkleize(chunks(deposit.Pubkey)),
+		credentials,
+		amount,
+		merkleize(chunks(deposit.Signature)),
+	})
+}
+
+// depositTree mirrors the incremental merkle tree of the deposit contract.
+type depositTree struct {
+	Branch [depositContractTreeDepth][32]byte `json:"branch"`
+	Count  uint64                             `json:"count"`
+}
+
+func (t *depositTree) insert(leaf [32]byte) {
+	t.Count++
+	size := t.Count
+	node := leaf
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			t.Branch[height] = node
+			return
+		}
+		node = hashPair(t.Branch[height], node)
+		size /= 2
+	}
+}
+
+// root is what the contract returns from `get_deposit_root`
+func (t *depositTree) root() [32]byte {
+	var node [32]byte
+	size := t.Count
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			node = hashPair(t.Branch[height], node)
+		} else {
+			node = hashPair(node, zeroHashes[height])
+		}
+		size /= 2
+	}
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:], t.Count)
+	return hashPair(node, count)
+}
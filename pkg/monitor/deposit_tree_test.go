@@ -0,0 +1,34 @@
+package monitor
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEmptyDepositRoot(t *testing.T) {
+	var tree depositTree
+	root := tree.root()
+	if hex.EncodeToString(root[:]) != "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e" {
+		t.Errorf("unexpected root of the empty deposit tree %x", root)
+	}
+}
+
+func TestDepositTreeMatchesMerkleize(t *testing.T) {
+	var tree depositTree
+	var leaves [][32]byte
+	for i := 0; i < 5; i++ {
+		leaf := [32]byte{byte(i + 1)}
+		leaves = append(leaves, leaf)
+		tree.insert(leaf)
+	}
+
+	// the root of the full depth tree over the leaves, mixed in with the count
+	node := merkleize(leaves)
+	for height := 3; height < depositContractTreeDepth; height++ {
+		node = hashPair(node, zeroHashes[height])
+	}
+	expected := hashPair(node, [32]byte{5})
+	if tree.root() != expected {
+		t.Errorf("incremental root %x does not match %x", tree.root(), expected)
+	}
+}
@@ -0,0 +1,380 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keccak256("DepositEvent(bytes,bytes,bytes,bytes,bytes)")
+const depositEventTopic = "0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5"
+
+// stay behind the execution head so that we do not ingest logs that are reorged out
+const depositLogConfirmations = 32
+const depositLogBlockRange = 10000
+const depositIngestionInterval = 5 * time.Minute
+
+const recentDepositEventsCount = 1000
+const depositRootHistoryCount = 1000
+const defaultDepositEventsLimit = 100
+
+const minDepositAmountGwei = 1000000000
+
+// hexBytes is encoded as a 0x prefixed hex string in JSON
+type hexBytes []byte
+
+func (b hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(b))
+}
+
+func (b *hexBytes) UnmarshalJSON(data []byte) error {
+	var encoded string
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+type DepositEvent struct {
+	BlockNumber           uint64   `json:"block_number"`
+	TransactionHash       string   `json:"transaction_hash"`
+	Index                 uint64   `json:"index"`
+	Pubkey                hexBytes `json:"pubkey"`
+	WithdrawalCredentials hexBytes `json:"withdrawal_credentials"`
+	AmountGwei            uint64   `json:"amount_gwei"`
+	Signature             hexBytes `json:"signature"`
+	// why the deposit can not be credited, if it can't
+	Problems []string `json:"problems,omitempty"`
+}
+
+type depositRoot struct {
+	BlockNumber  uint64 `json:"block_number"`
+	DepositCount uint64 `json:"deposit_count"`
+	Root         string `json:"root"`
+}
+
+// depositState is everything ingested from the deposit contract logs so far.
+type depositState struct {
+	LastBlock uint64         `json:"last_block"`
+	Tree      depositTree    `json:"tree"`
+	Recent    []DepositEvent `json:"recent"`
+	Invalid   []DepositEvent `json:"invalid"`
+	Roots     []depositRoot  `json:"roots"`
+}
+
+// depositLog guards the deposit state; the zero value is ready to use.
+type depositLog struct {
+	lock  sync.Mutex
+	state depositState
+}
+
+type rpcLog struct {
+	BlockNumber     string `json:"blockNumber"`
+	TransactionHash string `json:"transactionHash"`
+	LogIndex        string `json:"logIndex"`
+	Data            string `json:"data"`
+}
+
+func parseHexUint(value string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+}
+
+// abiBytesArgs decodes `count` ABI encoded dynamic `bytes` arguments
+func abiBytesArgs(data []byte, count int) ([][]byte, error) {
+	args := make([][]byte, count)
+	word := func(at uint64) (uint64, error) {
+		if at+32 > uint64(len(data)) {
+			return 0, errors.New("log data too short")
+		}
+		return binary.BigEndian.Uint64(data[at+24 : at+32]), nil
+	}
+	for i := range args {
+		offset, err := word(uint64(i * 32))
+		if err != nil {
+			return nil, err
+		}
+		length, err := word(offset)
+		if err != nil {
+			return nil, err
+		}
+		start := offset + 32
+		if start+length > uint64(len(data)) {
+			return nil, errors.New("log data too short")
+		}
+		args[i] = data[start : start+length]
+	}
+	return args, nil
+}
+
+func decodeDepositLog(entry rpcLog) (DepositEvent, error) {
+	event := DepositEvent{TransactionHash: entry.TransactionHash}
+	blockNumber, err := parseHexUint(entry.BlockNumber)
+	if err != nil {
+		return event, fmt.Errorf("invalid block number in deposit log: %v", err)
+	}
+	event.BlockNumber = blockNumber
+
+	data, err := hex.DecodeString(strings.TrimPrefix(entry.Data, "0x"))
+	if err != nil {
+		return event, fmt.Errorf("invalid deposit log data: %v", err)
+	}
+	args, err := abiBytesArgs(data, 5)
+	if err != nil {
+		return event, err
+	}
+	if len(args[2]) != 8 || len(args[4]) != 8 {
+		return event, errors.New("malformed amount or index in deposit log")
+	}
+	event.Pubkey = args[0]
+	event.WithdrawalCredentials = args[1]
+	event.AmountGwei = binary.LittleEndian.Uint64(args[2])
+	event.Signature = args[3]
+	event.Index = binary.LittleEndian.Uint64(args[4])
+	event.Problems = depositProblems(event)
+	return event, nil
+}
+
+// depositProblems finds deposits that will not be credited. The BLS signature
+// is not verified here so deposits with a wrong signature are not detected
+// unless the signature is empty.
+func depositProblems(event DepositEvent) []string {
+	var problems []string
+	if len(event.Pubkey) != 48 {
+		problems = append(problems, "pubkey is not 48 bytes")
+	} else if bytes.Equal(event.Pubkey, make([]byte, 48)) {
+		problems = append(problems, "pubkey is empty")
+	}
+	if len(event.WithdrawalCredentials) != 32 {
+		problems = append(problems, "withdrawal credentials are not 32 bytes")
+	} else if prefix := event.WithdrawalCredentials[0]; prefix > 0x02 {
+		problems = append(problems, fmt.Sprintf("unknown withdrawal credentials prefix 0x%02x", prefix))
+	}
+	if len(event.Signature) != 96 {
+		problems = append(problems, "signature is not 96 bytes")
+	} else if bytes.Equal(event.Signature, make([]byte, 96)) {
+		problems = append(problems, "signature is empty")
+	}
+	if event.AmountGwei < minDepositAmountGwei {
+		problems = append(problems, "amount is below the minimum deposit")
+	}
+	return problems
+}
+
+// apply adds the deposits in `events`, which must be in log order, to the
+// tree and marks everything up to `toBlock` as ingested. The last ingested
+// block never moves back.
+func (l *depositLog) apply(events []DepositEvent, toBlock uint64) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	state := &l.state
+	for i, event := range events {
+		// already ingested, e.g. when retrying after an error
+		if event.Index < state.Tree.Count {
+			continue
+		}
+		if event.Index > state.Tree.Count {
+			return fmt.Errorf("expected deposit %d but got deposit %d in block %d", state.Tree.Count, event.Index, event.BlockNumber)
+		}
+		state.Tree.insert(depositDataRoot(event))
+
+		state.Recent = append(state.Recent, event)
+		if len(state.Recent) > recentDepositEventsCount {
+			state.Recent = state.Recent[len(state.Recent)-recentDepositEventsCount:]
+		}
+		if len(event.Problems) > 0 {
+			state.Invalid = append(state.Invalid, event)
+		}
+
+		// record the root once all deposits of a block are in
+		if i == len(events)-1 || events[i+1].BlockNumber != event.BlockNumber {
+			root := state.Tree.root()
+			state.Roots = append(state.Roots, depositRoot{
+				BlockNumber:  event.BlockNumber,
+				DepositCount: state.Tree.Count,
+				Root:         "0x" + hex.EncodeToString(root[:]),
+			})
+			if len(state.Roots) > depositRootHistoryCount {
+				state.Roots = state.Roots[len(state.Roots)-depositRootHistoryCount:]
+			}
+		}
+	}
+	if toBlock > state.LastBlock {
+		state.LastBlock = toBlock
+	}
+	return nil
+}
+
+func (l *depositLog) snapshot() depositState {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	state := l.state
+	state.Recent = append([]DepositEvent{}, state.Recent...)
+	state.Invalid = append([]DepositEvent{}, state.Invalid...)
+	state.Roots = append([]depositRoot{}, state.Roots...)
+	return state
+}
+
+func (l *depositLog) restore(state depositState) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.state = state
+}
+
+func (l *depositLog) lastBlock() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.state.LastBlock
+}
+
+func (m *Monitor) executionCall(method string, params []interface{}, result interface{}) error {
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      randomRPCID(),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: time.Duration(m.config.MillisecondsTimeout) * time.Millisecond}
+	resp, err := client.Post(m.config.ExecutionEndpoint, "application/json", bytes.NewBuffer(request))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data := struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return err
+	}
+	if data.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, data.Error.Message)
+	}
+	return json.Unmarshal(data.Result, result)
+}
+
+// ingestDepositLogs catches up with the deposit contract logs up to the
+// confirmed execution head. Catching up can take many requests, so `beat`
+// is called before each one and ingestion stops once it returns false.
+func (m *Monitor) ingestDepositLogs(beat func() bool) error {
+	var head string
+	err := m.executionCall("eth_blockNumber", []interface{}{}, &head)
+	if err != nil {
+		return err
+	}
+	headNumber, err := parseHexUint(head)
+	if err != nil {
+		return err
+	}
+	if headNumber < depositLogConfirmations {
+		return nil
+	}
+	target := headNumber - depositLogConfirmations
+
+	from := uint64(m.config.Eth2.DepositContractDeployBlock)
+	if last := m.deposits.lastBlock(); last > 0 {
+		from = last + 1
+	}
+	for from <= target && beat() {
+		to := from + depositLogBlockRange - 1
+		if to > target {
+			to = target
+		}
+		filter := map[string]interface{}{
+			"address":   m.config.Eth2.DepositContractAddress,
+			"topics":    []string{depositEventTopic},
+			"fromBlock": fmt.Sprintf("0x%x", from),
+			"toBlock":   fmt.Sprintf("0x%x", to),
+		}
+		var entries []rpcLog
+		err := m.executionCall("eth_getLogs", []interface{}{filter}, &entries)
+		if err != nil {
+			return err
+		}
+		events := make([]DepositEvent, 0, len(entries))
+		for _, entry := range entries {
+			event, err := decodeDepositLog(entry)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Index < events[j].Index })
+		err = m.deposits.apply(events, to)
+		if err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+func (m *Monitor) startDepositLogIngestion(beat func() bool) {
+	for beat() {
+		err := m.ingestDepositLogs(beat)
+		if err != nil {
+			log.Println(err)
+		}
+		time.Sleep(depositIngestionInterval)
+	}
+}
+
+type depositEventsResp struct {
+	LastBlock    uint64         `json:"last_block"`
+	DepositCount uint64         `json:"deposit_count"`
+	InvalidCount int            `json:"invalid_count"`
+	DepositRoot  string         `json:"deposit_root"`
+	Events       []DepositEvent `json:"events"`
+	Invalid      []DepositEvent `json:"invalid"`
+	Roots        []depositRoot  `json:"roots"`
+}
+
+// sendDepositEvents serves the latest `?limit=` deposits along with all invalid ones.
+func (m *Monitor) sendDepositEvents(w http.ResponseWriter, r *http.Request) {
+	limit, err := parsePositiveInt(r.URL.Query(), "limit", defaultDepositEventsLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	state := m.deposits.snapshot()
+	root := state.Tree.root()
+	events := state.Recent
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	roots := state.Roots
+	if len(roots) > limit {
+		roots = roots[len(roots)-limit:]
+	}
+	resp := depositEventsResp{
+		LastBlock:    state.LastBlock,
+		DepositCount: state.Tree.Count,
+		InvalidCount: len(state.Invalid),
+		DepositRoot:  "0x" + hex.EncodeToString(root[:]),
+		Events:       events,
+		Invalid:      state.Invalid,
+		Roots:        roots,
+	}
+	writeJSON(w, r, &resp)
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// encodeDepositLogData ABI encodes the arguments of a `DepositEvent`
+func encodeDepositLogData(args ...[]byte) string {
+	var head, tail []byte
+	offset := uint64(32 * len(args))
+	for _, arg := range args {
+		word := make([]byte, 32)
+		binary.BigEndian.PutUint64(word[24:], offset)
+		head = append(head, word...)
+
+		length := make([]byte, 32)
+		binary.BigEndian.PutUint64(length[24:], uint64(len(arg)))
+		padded := make([]byte, (len(arg)+31)/32*32)
+		copy(padded, arg)
+		tail = append(tail, length...)
+		tail = append(tail, padded...)
+		offset += uint64(32 + len(padded))
+	}
+	return "0x" + hex.EncodeToString(append(head, tail...))
+}
+
+func depositLogEntry(block string, index uint64, amount uint64, signature []byte) rpcLog {
+	pubkey := make([]byte, 48)
+	pubkey[0] = 0xaa
+	credentials := make([]byte, 32)
+	credentials[0] = 0x01
+	encodedAmount := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encodedAmount, amount)
+	encodedIndex := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encodedIndex, index)
+	return rpcLog{
+		BlockNumber: block,
+		Data:        encodeDepositLogData(pubkey, credentials, encodedAmount, signature, encodedIndex),
+	}
+}
+
+func TestDecodeDepositLog(t *testing.T) {
+	signature := make([]byte, 96)
+	signature[0] = 0xbb
+	event, err := decodeDepositLog(depositLogEntry("0x10", 7, 32000000000, signature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.BlockNumber != 16 || event.Index != 7 || event.AmountGwei != 32000000000 || len(event.Pubkey) != 48 || event.Signature[0] != 0xbb {
+		t.Errorf("unexpected deposit %+v", event)
+	}
+	if len(event.Problems) != 0 {
+		t.Errorf("expected a valid deposit, got %v", event.Problems)
+	}
+
+	event, err = decodeDepositLog(depositLogEntry("0x10", 7, 100, make([]byte, 96)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(event.Problems) != 2 {
+		t.Errorf("expected a small amount and an empty signature to be flagged, got %v", event.Problems)
+	}
+}
+
+func TestDepositLogApply(t *testing.T) {
+	var deposits depositLog
+	events := []DepositEvent{
+		{BlockNumber: 10, Index: 0, AmountGwei: 32000000000},
+		{BlockNumber: 10, Index: 1, AmountGwei: 32000000000},
+		{BlockNumber: 12, Index: 2, AmountGwei: 1, Problems: []string{"amount is below the minimum deposit"}},
+	}
+	err := deposits.apply(events, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := deposits.snapshot()
+	if state.Tree.Count != 3 || state.LastBlock != 20 || len(state.Invalid) != 1 || len(state.Roots) != 2 {
+		t.Errorf("unexpected deposit state %+v", state)
+	}
+
+	// replaying deposits is harmless but gaps are not
+	err = deposits.apply(events[2:], 20)
+	if err != nil {
+		t.Error(err)
+	}
+	err = deposits.apply([]DepositEvent{{BlockNumber: 21, Index: 5}}, 30)
+	if err == nil {
+		t.Error("expected an error for missing deposits")
+	}
+
+	// an abandoned ingestion finishing late does not move the last block back
+	err = deposits.apply(nil, 15)
+	if err != nil {
+		t.Error(err)
+	}
+	if last := deposits.lastBlock(); last != 20 {
+		t.Errorf("expected the last block to stay at 20 but got %d", last)
+	}
+}
+
+func TestDepositLogIngestionBeats(t *testing.T) {
+	getLogsCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Method string `json:"method"`
+		}{}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			t.Error(err)
+		}
+		result := "[]"
+		if request.Method == "eth_blockNumber" {
+			result = fmt.Sprintf(`"0x%x"`, 4*depositLogBlockRange+depositLogConfirmations)
+		} else {
+			getLogsCalls++
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+	defer server.Close()
+	m := &Monitor{config: &Config{ExecutionEndpoint: server.URL}}
+
+	// each chunk of a long catch up sends a heartbeat, and a replaced
+	// instance stops at the next one
+	beats := 0
+	beat := func() bool {
+		beats++
+		return beats <= 2
+	}
+	err := m.ingestDepositLogs(beat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getLogsCalls != 2 || beats != 3 {
+		t.Errorf("expected 2 chunks and 3 heartbeats but got %d and %d", getLogsCalls, beats)
+	}
+	if last := m.deposits.lastBlock(); last != 2*depositLogBlockRange-1 {
+		t.Errorf("expected ingestion to stop at block %d but got %d", 2*depositLogBlockRange-1, last)
+	}
+}
@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bound on each probe of an endpoint if no `http_timeout_milliseconds` is set,
+// so a single hanging endpoint does not hold up startup
+const defaultProbeTimeoutMilliseconds = 10000
+
+// endpoints that could not be reached at startup are retried this often
+const discoveryRetryInterval = 30 * time.Second
+
+// probeEndpoint sets up a node for `endpoint`, failing if it does not serve
+// its version, identity and head.
+func probeEndpoint(endpoint Endpoint, millisecondsTimeout int) (*Node, error) {
+	probeTimeout := millisecondsTimeout
+	if probeTimeout <= 0 {
+		probeTimeout = defaultProbeTimeoutMilliseconds
+	}
+	transport, err := newTransport(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	addr, user := splitCredentials(endpoint.Addr)
+	var roundTripper http.RoundTripper = transport
+	if user != nil {
+		roundTripper = &basicAuthTransport{next: transport, user: user}
+	}
+	node, err := nodeAtEndpoint(addr, endpoint.Eth1, roundTripper, time.Duration(probeTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	err = node.doFetchLatestHead()
+	if err != nil {
+		return nil, err
+	}
+	node.client.Timeout = time.Duration(millisecondsTimeout) * time.Millisecond
+	node.requests.setLimit(endpoint.RequestsPerMinute)
+	node.isHealthy = true
+	node.status = StatusOK
+	node.label = endpoint.Label
+	node.config = endpoint
+	return node, nil
+}
+
+// discoverNodes probes all `endpoints` concurrently. The nodes found keep the
+// order of the configuration, the endpoints that failed are returned to retry.
+// Misconfigured endpoints are dropped as retrying them cannot succeed.
+func discoverNodes(endpoints []Endpoint, millisecondsTimeout int) ([]*Node, []Endpoint) {
+	found := make([]*Node, len(endpoints))
+	valid := make([]bool, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		endpoint, err := checkEndpoint(endpoint)
+		if err != nil {
+			log.Printf("warn: ignoring endpoint: %v", err)
+			continue
+		}
+		valid[i] = true
+		wg.Add(1)
+		go func(i int, endpoint Endpoint) {
+			defer wg.Done()
+			node, err := probeEndpoint(endpoint, millisecondsTimeout)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			found[i] = node
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	var nodes []*Node
+	var pending []Endpoint
+	for i, node := range found {
+		if node == nil {
+			if valid[i] {
+				pending = append(pending, endpoints[i])
+			}
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, pending
+}
+
+// nodeList returns the nodes discovered so far.
+func (m *Monitor) nodeList() []*Node {
+	m.nodesLock.Lock()
+	defer m.nodesLock.Unlock()
+	return append([]*Node{}, m.nodes...)
+}
+
+// addNodes makes late discovered nodes available to every role they can take on.
+// Pollers that were waiting for a provider start once one is found.
+func (m *Monitor) addNodes(nodes []*Node) {
+	m.nodesLock.Lock()
+	m.nodes = append(m.nodes, nodes...)
+	m.forkChoiceProviders = selectProviders(m.nodes, func(e Endpoint) *bool { return e.ForkChoice })
+	m.participationProviders = selectProviders(m.nodes, func(e Endpoint) *bool { return e.Participation })
+	m.nodesLock.Unlock()
+	m.startProviderPollers()
+}
+
+// startDiscovery keeps probing the endpoints that were down at startup
+// until all of them have been found.
+func (m *Monitor) startDiscovery(pending []Endpoint) {
+	for len(pending) > 0 {
+		time.Sleep(discoveryRetryInterval)
+
+		var nodes []*Node
+		nodes, pending = discoverNodes(pending, m.config.MillisecondsTimeout)
+		for _, node := range nodes {
+			log.Printf("discovered node %s at %s", node.id, node.addr)
+		}
+		if len(nodes) > 0 {
+			m.addNodes(nodes)
+		}
+	}
+}
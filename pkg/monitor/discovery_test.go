@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func beaconStub(peerID string) *httptest.Server {
+	return httptest.NewServer(beaconHandler(peerID))
+}
+
+func beaconHandler(peerID string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case clientVersionPath:
+			w.Write([]byte(`{"data":{"version":"Lighthouse/v5.0.0"}}`))
+		case nodeIdentityPath:
+			fmt.Fprintf(w, `{"data":{"peer_id":%q}}`, peerID)
+		case nodeSyncingPath:
+			w.Write([]byte(`{"data":{"is_syncing":false}}`))
+		case headHeaderPath:
+			w.Write([]byte(`{"data":{"root":"0xabcd","header":{"message":{"slot":"10"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestDiscoverNodes(t *testing.T) {
+	a := beaconStub("peer-a")
+	defer a.Close()
+	b := beaconStub("peer-b")
+	defer b.Close()
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	}))
+	defer hanging.Close()
+
+	endpoints := []Endpoint{{Addr: hanging.URL}, {Addr: b.URL, Label: "b"}, {Addr: a.URL, Label: "a"}}
+	start := time.Now()
+	nodes, pending := discoverNodes(endpoints, 200)
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("probes did not time out individually, took %s", elapsed)
+	}
+
+	if len(nodes) != 2 || nodes[0].label != "b" || nodes[1].label != "a" {
+		t.Fatalf("expected nodes in configuration order, got %v", nodes)
+	}
+	if nodes[1].id != idHashOf("peer-a") || !nodes[1].isHealthy || nodes[1].latestHead.slot != 10 {
+		t.Errorf("unexpected node %+v", nodes[1])
+	}
+	if len(pending) != 1 || pending[0].Addr != hanging.URL {
+		t.Errorf("expected the hanging endpoint to be retried, got %v", pending)
+	}
+
+	m := &Monitor{config: &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}}}
+	m.startProviderPollers()
+	if len(m.pollers.status()) != 0 {
+		t.Fatal("expected no provider pollers without a provider")
+	}
+	m.addNodes(nodes[:1])
+	m.addNodes(nodes[1:])
+	if len(m.nodeList()) != 2 || len(m.candidatesFor(forkChoiceQuery)) != 2 {
+		t.Error("expected late nodes to become fork choice providers")
+	}
+	var names []string
+	for _, status := range m.pollers.status() {
+		names = append(names, status.Name)
+	}
+	expected := []string{"fork_choice_sanity", "participation"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the provider pollers to start once, got %v", names)
+	}
+}
@@ -0,0 +1,237 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// where the value of a setting came from
+const (
+	SettingFromDefault = "default"
+	SettingFromFile    = "file"
+	SettingFromEnv     = "env"
+	SettingFromFlag    = "flag"
+)
+
+// settings holding a credential outright and those holding a URL that may
+// embed one, by the last element of their path
+var secretSettings = map[string]bool{"etherscan_api_key": true, "key": true}
+var urlSettings = map[string]bool{
+	"addr":                                true,
+	"metrics":                             true,
+	"proxy":                               true,
+	"execution_endpoint":                  true,
+	"weak_subjectivity_provider_endpoint": true,
+}
+
+func joinSettingPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// settingName is the name of a field in the configuration file, following
+// the rules of the YAML decoder; the empty string if it is not read from it.
+func settingName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// flattenSettings collects the settings in `value` by their dotted path, e.g.
+// `endpoints.0.addr`. Lists of plain values are kept whole.
+func flattenSettings(value reflect.Value, prefix string, settings map[string]interface{}) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			settings[prefix] = nil
+			return
+		}
+		flattenSettings(value.Elem(), prefix, settings)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			name := settingName(value.Type().Field(i))
+			if name != "" {
+				flattenSettings(value.Field(i), joinSettingPath(prefix, name), settings)
+			}
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.Struct {
+			settings[prefix] = value.Interface()
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			flattenSettings(value.Index(i), joinSettingPath(prefix, strconv.Itoa(i)), settings)
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			flattenSettings(value.MapIndex(key), joinSettingPath(prefix, fmt.Sprint(key.Interface())), settings)
+		}
+	default:
+		if duration, ok := value.Interface().(time.Duration); ok {
+			settings[prefix] = duration.String()
+			return
+		}
+		settings[prefix] = value.Interface()
+	}
+}
+
+// flattenDocument does what flattenSettings does for a decoded YAML document.
+func flattenDocument(document interface{}, prefix string, settings map[string]interface{}) {
+	switch document := document.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range document {
+			flattenDocument(value, joinSettingPath(prefix, fmt.Sprint(key)), settings)
+		}
+	case []interface{}:
+		for _, element := range document {
+			if _, ok := element.(map[interface{}]interface{}); !ok {
+				settings[prefix] = document
+				return
+			}
+		}
+		for i, element := range document {
+			flattenDocument(element, joinSettingPath(prefix, strconv.Itoa(i)), settings)
+		}
+	default:
+		settings[prefix] = document
+	}
+}
+
+func referencesEnv(value interface{}) bool {
+	switch value := value.(type) {
+	case string:
+		for _, match := range envReference.FindAllStringSubmatch(value, -1) {
+			if match[1] != "" {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, element := range value {
+			if referencesEnv(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetSource records where the setting at the dotted `path` came from.
+func (c *Config) SetSource(path string, source string) {
+	if c.Sources == nil {
+		c.Sources = make(map[string]string)
+	}
+	c.Sources[path] = source
+}
+
+// RecordSources notes the settings given in `raw`, the configuration file
+// before environment variables are expanded, as coming from the file or, if
+// they reference a variable, from the environment.
+func (c *Config) RecordSources(raw []byte) error {
+	var document interface{}
+	err := yaml.Unmarshal(raw, &document)
+	if err != nil {
+		return err
+	}
+	settings := make(map[string]interface{})
+	flattenDocument(document, "", settings)
+	for path, value := range settings {
+		source := SettingFromFile
+		if referencesEnv(value) {
+			source = SettingFromEnv
+		}
+		c.SetSource(path, source)
+	}
+	return nil
+}
+
+// redactSetting masks the credentials in the value of the setting at `path`.
+func redactSetting(path string, value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return value
+	}
+	name := path[strings.LastIndex(path, ".")+1:]
+	switch {
+	case secretSettings[name]:
+		return redacted
+	case urlSettings[name] && text != proxyDirect:
+		return redactURL(text)
+	}
+	return secrets.redact(text)
+}
+
+// effectiveDefaults returns the values in use for settings whose default is
+// applied where they are read rather than filled into the configuration.
+func (m *Monitor) effectiveDefaults() map[string]interface{} {
+	meta := m.meta()
+	metric := m.subnetMetric()
+	staleAfterSlots := m.config.StaleAfterSlots
+	if staleAfterSlots <= 0 {
+		staleAfterSlots = defaultStaleAfterSlots
+	}
+	return map[string]interface{}{
+		"timeseries_resolution_seconds":   int(m.timeseriesResolution() / time.Second),
+		"retention.head_observations":     m.headObservationRetention().String(),
+		"retention.participation":         m.participationRetention().String(),
+		"retention.reorgs":                m.reorgRetention().String(),
+		"retention.proto_array_snapshots": m.protoArraySnapshotRetention().String(),
+		"fork_choice_divergence_slots":    m.forkChoiceDivergenceSlots(),
+		"fork_choice_weight_tolerance":    m.forkChoiceWeightTolerance(),
+		"reorg_risk_threshold":            m.reorgRiskThreshold(),
+		"reorg_risk_alert_slots":          m.reorgRiskAlertSlots(),
+		"stale_after_slots":               staleAfterSlots,
+		"max_concurrent_requests":         m.maxConcurrentRequests(),
+		"meta.display_name":               meta.DisplayName,
+		"meta.network_label":              meta.NetworkLabel,
+		"subnet_metric.name":              metric.Name,
+		"subnet_metric.label":             metric.Label,
+	}
+}
+
+type configSetting struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+type configResp struct {
+	Settings map[string]configSetting `json:"settings"`
+}
+
+// sendConfig serves the configuration the monitor runs with, defaults
+// applied and credentials masked, with where each setting came from.
+func (m *Monitor) sendConfig(w http.ResponseWriter, r *http.Request) {
+	values := make(map[string]interface{})
+	flattenSettings(reflect.ValueOf(*m.config), "", values)
+
+	settings := make(map[string]configSetting, len(values))
+	for path, value := range values {
+		source, ok := m.config.Sources[path]
+		if !ok {
+			source = SettingFromDefault
+		}
+		settings[path] = configSetting{Value: redactSetting(path, value), Source: source}
+	}
+	for path, value := range m.effectiveDefaults() {
+		if settings[path].Value != value {
+			settings[path] = configSetting{Value: value, Source: SettingFromDefault}
+		}
+	}
+
+	writeJSON(w, r, &configResp{Settings: settings})
+}
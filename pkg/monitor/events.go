@@ -0,0 +1,188 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type EventKind string
+
+const (
+	HeadUpdatedEvent        EventKind = "head_updated"
+	CheckpointAdvancedEvent EventKind = "checkpoint_advanced"
+	NodeHealthChangedEvent  EventKind = "node_health_changed"
+	ReorgDetectedEvent      EventKind = "reorg_detected"
+	EpochCompletedEvent     EventKind = "epoch_completed"
+)
+
+// Event is something the pollers observed that other parts of the monitor
+// may want to act on.
+type Event interface {
+	Kind() EventKind
+}
+
+// HeadUpdated is published when a node moves to a new head.
+type HeadUpdated struct {
+	Node string
+	Head HeadObservation
+}
+
+// CheckpointAdvanced is published when the justified or finalized
+// checkpoint moves to a later epoch.
+type CheckpointAdvanced struct {
+	Justified Checkpoint
+	Finalized Checkpoint
+	// whether the finalized checkpoint is among the ones that moved
+	FinalizedAdvanced bool
+	Source            string
+	At                time.Time
+}
+
+// NodeHealthChanged is published when the status of a node changes,
+// including the first time it is known.
+type NodeHealthChanged struct {
+	Node     string
+	Addr     string
+	Previous NodeStatus
+	Current  NodeStatus
+}
+
+// ReorgDetected is published for every reorg of the canonical head.
+type ReorgDetected struct {
+	Reorg Reorg
+	// the fork choice the reorg was detected in
+	ProtoArray []ProtoArrayNode
+}
+
+// EpochCompleted is published once the wall clock passes the end of an epoch.
+type EpochCompleted struct {
+	Epoch int
+}
+
+func (HeadUpdated) Kind() EventKind        { return HeadUpdatedEvent }
+func (CheckpointAdvanced) Kind() EventKind { return CheckpointAdvancedEvent }
+func (NodeHealthChanged) Kind() EventKind  { return NodeHealthChangedEvent }
+func (ReorgDetected) Kind() EventKind      { return ReorgDetectedEvent }
+func (EpochCompleted) Kind() EventKind     { return EpochCompletedEvent }
+
+// eventBus hands the events published by the pollers to the subscribers of
+// their kind; the zero value is ready to use. Handlers run on the goroutine
+// of the publisher in the order they subscribed, so they must not block.
+type eventBus struct {
+	lock     sync.RWMutex
+	handlers map[EventKind][]func(Event)
+}
+
+func (b *eventBus) subscribe(kind EventKind, handler func(Event)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.handlers == nil {
+		b.handlers = make(map[EventKind][]func(Event))
+	}
+	b.handlers[kind] = append(b.handlers[kind], handler)
+}
+
+func (b *eventBus) publish(event Event) {
+	b.lock.RLock()
+	handlers := b.handlers[event.Kind()]
+	b.lock.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// subscribeConsumers connects storage, alerting and persistence to the
+// events published by the pollers.
+func (m *Monitor) subscribeConsumers() {
+	m.events.subscribe(HeadUpdatedEvent, func(event Event) {
+		head := event.(HeadUpdated)
+		err := m.store().AppendHeadObservation(head.Node, head.Head)
+		if err != nil {
+			log.Println(err)
+		}
+	})
+
+	m.events.subscribe(ReorgDetectedEvent, func(event Event) {
+		reorg := event.(ReorgDetected).Reorg
+		log.Printf("reorg of depth %d at slot %d from %s to %s", reorg.Depth, reorg.NewHead.Slot, reorg.OldHead.Root, reorg.NewHead.Root)
+		err := m.store().SaveReorg(reorg)
+		if err != nil {
+			log.Println(err)
+		}
+	})
+
+	m.events.subscribe(ReorgDetectedEvent, func(event Event) {
+		m.assessSurroundRisk(event.(ReorgDetected))
+	})
+
+	m.events.subscribe(CheckpointAdvancedEvent, func(event Event) {
+		advanced := event.(CheckpointAdvanced)
+		if advanced.FinalizedAdvanced {
+			m.finalityLatencies.observe(m.eth2(), advanced.Finalized.Epoch, advanced.At)
+		}
+		if m.surroundRisks.justified(advanced.Justified.Epoch) {
+			m.alerts.resolve(surroundRiskAlert)
+		}
+	})
+
+	m.events.subscribe(NodeHealthChangedEvent, func(event Event) {
+		changed := event.(NodeHealthChanged)
+		name := nodeStatusAlertPrefix + changed.Node
+		message := fmt.Sprintf("node %s at %s is %s", changed.Node, changed.Addr, changed.Current)
+		switch changed.Current {
+		case StatusOK:
+			m.alerts.resolve(name)
+		case StatusUnreachable, StatusMisconfigured:
+			m.alerts.raise(name, SeverityCritical, message)
+		default:
+			m.alerts.raise(name, SeverityWarning, message)
+		}
+	})
+
+	// only wakes up the persistence poller, which writes the state
+	m.events.subscribe(EpochCompletedEvent, func(event Event) {
+		select {
+		case m.saveRequests <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// publishHeads publishes the heads the nodes moved to since the last poll.
+func (m *Monitor) publishHeads(nodes []*Node) {
+	for _, node := range nodes {
+		if !node.headUpdatedAt.After(node.publishedHeadAt) {
+			continue
+		}
+		observation := HeadObservation{Slot: node.latestHead.slot, Root: node.latestHead.root, ObservedAt: node.headUpdatedAt}
+		m.events.publish(HeadUpdated{Node: node.id, Head: observation})
+		node.publishedHeadAt = node.headUpdatedAt
+	}
+}
+
+// publishEpochsCompleted publishes the epochs that ended since the last call.
+// The first call only notes the current epoch.
+func (m *Monitor) publishEpochsCompleted() {
+	config := m.eth2()
+	if config.SecondsPerSlot == 0 || config.SlotsPerEpoch == 0 {
+		return
+	}
+	epoch := m.getCurrentEpoch()
+	m.polledEpochLock.Lock()
+	if m.polledEpoch == nil {
+		m.polledEpoch = &epoch
+		m.polledEpochLock.Unlock()
+		return
+	}
+	first := *m.polledEpoch
+	if epoch > first {
+		m.polledEpoch = &epoch
+	}
+	m.polledEpochLock.Unlock()
+
+	for completed := first; completed < epoch; completed++ {
+		m.events.publish(EpochCompleted{Epoch: completed})
+	}
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	var bus eventBus
+	var received []string
+	bus.subscribe(ReorgDetectedEvent, func(event Event) {
+		received = append(received, "first "+event.(ReorgDetected).Reorg.Source)
+	})
+	bus.subscribe(ReorgDetectedEvent, func(event Event) {
+		received = append(received, "second "+event.(ReorgDetected).Reorg.Source)
+	})
+
+	bus.publish(EpochCompleted{Epoch: 1})
+	bus.publish(ReorgDetected{Reorg: Reorg{Source: "a"}})
+	if !reflect.DeepEqual(received, []string{"first a", "second a"}) {
+		t.Errorf("expected the subscribers of the kind in order, got %v", received)
+	}
+}
+
+func TestPublishHealthChanges(t *testing.T) {
+	node := &Node{id: "a", addr: "http://a", status: StatusUnreachable}
+	m := &Monitor{config: &Config{}, alerts: newAlertSet()}
+	m.subscribeConsumers()
+	var changes []NodeHealthChanged
+	m.events.subscribe(NodeHealthChangedEvent, func(event Event) {
+		changes = append(changes, event.(NodeHealthChanged))
+	})
+
+	m.publishHealthChanges([]*Node{node})
+	m.publishHealthChanges([]*Node{node})
+	if len(changes) != 1 || changes[0].Current != StatusUnreachable {
+		t.Fatalf("expected a single change, got %v", changes)
+	}
+	if alerts := m.alerts.list(); len(alerts) != 1 || alerts[0].Severity != SeverityCritical {
+		t.Errorf("expected a critical alert for the node, got %v", alerts)
+	}
+
+	node.status = StatusOK
+	m.publishHealthChanges([]*Node{node})
+	if len(changes) != 2 || changes[1].Previous != StatusUnreachable || len(m.alerts.list()) != 0 {
+		t.Errorf("expected the recovery to resolve the alert, got %v", changes)
+	}
+}
+
+func TestPublishEpochsCompleted(t *testing.T) {
+	m := &Monitor{config: &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}}}
+	var completed []int
+	m.events.subscribe(EpochCompletedEvent, func(event Event) {
+		completed = append(completed, event.(EpochCompleted).Epoch)
+	})
+
+	m.publishEpochsCompleted()
+	if len(completed) != 0 {
+		t.Fatalf("expected the first poll to only note the epoch, got %v", completed)
+	}
+	current := *m.polledEpoch
+	earlier := current - 2
+	m.polledEpoch = &earlier
+	m.publishEpochsCompleted()
+	if !reflect.DeepEqual(completed, []int{current - 2, current - 1}) {
+		t.Errorf("expected the epochs since the last poll, got %v", completed)
+	}
+}
+
+func TestEpochCompletedRequestsSave(t *testing.T) {
+	m := &Monitor{config: &Config{}, saveRequests: make(chan struct{}, 1)}
+	m.subscribeConsumers()
+	m.events.publish(EpochCompleted{Epoch: 1})
+	m.events.publish(EpochCompleted{Epoch: 2})
+	select {
+	case <-m.saveRequests:
+	default:
+		t.Fatal("expected a completed epoch to request a save")
+	}
+	select {
+	case <-m.saveRequests:
+		t.Error("expected pending requests to be coalesced")
+	default:
+	}
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// number of finalized epochs to keep the latency of
+const finalityLatencyHistory = 1024
+
+// FinalityLatency records when the finalization of an epoch was observed
+// relative to the earliest it could have happened: at the end of the next
+// epoch, once both are justified.
+type FinalityLatency struct {
+	Epoch          int       `json:"epoch"`
+	ObservedAt     time.Time `json:"observed_at"`
+	LatencySeconds float64   `json:"latency_seconds"`
+}
+
+// earliestFinalization is the start of epoch `epoch + 2`, when the epoch
+// processing at the end of `epoch + 1` can finalize `epoch`.
+func earliestFinalization(config Eth2Config, epoch int) time.Time {
+	secondsPerEpoch := config.SecondsPerSlot * config.SlotsPerEpoch
+	return time.Unix(int64(config.GenesisTime+(epoch+2)*secondsPerEpoch), 0)
+}
+
+// finalityLatencyLog follows the finalized epoch and records the latency of
+// every epoch it passes; the zero value is ready to use.
+type finalityLatencyLog struct {
+	lock sync.Mutex
+	// last finalized epoch seen, if any
+	lastEpoch *int
+	latencies []FinalityLatency
+}
+
+// observe notes that `epoch` is finalized as of `now`. An advance over
+// several epochs finalizes all of them at once. The first observation only
+// sets the baseline as it is unknown when that epoch was finalized.
+func (l *finalityLatencyLog) observe(config Eth2Config, epoch int, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.lastEpoch == nil {
+		l.lastEpoch = &epoch
+		return
+	}
+	for finalized := *l.lastEpoch + 1; finalized <= epoch; finalized++ {
+		latency := now.Sub(earliestFinalization(config, finalized)).Seconds()
+		l.latencies = append(l.latencies, FinalityLatency{Epoch: finalized, ObservedAt: now, LatencySeconds: latency})
+	}
+	if epoch > *l.lastEpoch {
+		l.lastEpoch = &epoch
+	}
+	if len(l.latencies) > finalityLatencyHistory {
+		l.latencies = append([]FinalityLatency{}, l.latencies[len(l.latencies)-finalityLatencyHistory:]...)
+	}
+}
+
+func (l *finalityLatencyLog) list() []FinalityLatency {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]FinalityLatency{}, l.latencies...)
+}
+
+func (l *finalityLatencyLog) clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lastEpoch = nil
+	l.latencies = nil
+}
+
+type latencyDistribution struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// percentile picks the nearest rank value of `sorted` for `p` in (0, 1].
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func summarizeLatencies(latencies []FinalityLatency) latencyDistribution {
+	if len(latencies) == 0 {
+		return latencyDistribution{}
+	}
+	values := make([]float64, len(latencies))
+	total := 0.0
+	for i, latency := range latencies {
+		values[i] = latency.LatencySeconds
+		total += latency.LatencySeconds
+	}
+	sort.Float64s(values)
+	return latencyDistribution{
+		Count: len(values),
+		Mean:  total / float64(len(values)),
+		P50:   percentile(values, 0.5),
+		P90:   percentile(values, 0.9),
+		P99:   percentile(values, 0.99),
+		Max:   values[len(values)-1],
+	}
+}
+
+type finalityLatencyResp struct {
+	Distribution latencyDistribution `json:"distribution"`
+	Epochs       []FinalityLatency   `json:"epochs"`
+}
+
+// sendFinalityLatency serves the distribution of the finality latency over
+// the last `?epochs=` finalized epochs along with the epochs themselves.
+func (m *Monitor) sendFinalityLatency(w http.ResponseWriter, r *http.Request) {
+	epochs, err := parsePositiveInt(r.URL.Query(), "epochs", finalityLatencyHistory)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	latencies := m.finalityLatencies.list()
+	if len(latencies) > epochs {
+		latencies = latencies[len(latencies)-epochs:]
+	}
+	writeJSON(w, r, &finalityLatencyResp{Distribution: summarizeLatencies(latencies), Epochs: latencies})
+}
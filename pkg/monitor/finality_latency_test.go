@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFinalityLatencyLog(t *testing.T) {
+	config := Eth2Config{GenesisTime: 1000, SecondsPerSlot: 12, SlotsPerEpoch: 32}
+	epoch := func(e int) time.Time { return time.Unix(int64(1000+e*384), 0) }
+
+	var log finalityLatencyLog
+	log.observe(config, 10, epoch(13))
+	if len(log.list()) != 0 {
+		t.Fatal("expected the first observation to only set the baseline")
+	}
+
+	// epoch 11 finalizes on time at the start of epoch 13
+	log.observe(config, 11, epoch(13).Add(10*time.Second))
+	// nothing new
+	log.observe(config, 11, epoch(14))
+	// a stall: 12 and 13 finalize together well into epoch 17
+	log.observe(config, 13, epoch(17).Add(5*time.Second))
+
+	latencies := log.list()
+	if len(latencies) != 3 {
+		t.Fatalf("expected 3 finalized epochs, got %v", latencies)
+	}
+	expected := []float64{10, 3*384 + 5, 2*384 + 5}
+	for i, latency := range latencies {
+		if latency.Epoch != 11+i || latency.LatencySeconds != expected[i] {
+			t.Errorf("unexpected latency %+v", latency)
+		}
+	}
+
+	distribution := summarizeLatencies(latencies)
+	if distribution.Count != 3 || distribution.P50 != 773 || distribution.Max != 1157 {
+		t.Errorf("unexpected distribution %+v", distribution)
+	}
+
+	log.clear()
+	log.observe(config, 20, epoch(22))
+	if len(log.list()) != 0 {
+		t.Error("expected a cleared log to start over")
+	}
+}
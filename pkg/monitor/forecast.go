@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const participationForecastAlert = "participation_forecast"
+
+// the share of active stake that must attest to the target to justify
+const justificationThreshold = 100 * 2.0 / 3.0
+
+// only warn once enough of the epoch has passed for the projection to be meaningful
+const forecastAlertMinElapsed = 0.5
+
+// ParticipationForecast projects the target participation of the current,
+// incomplete epoch from the votes fork choice has seen so far. The
+// validator inclusion data only covers epochs that are over, so the votes
+// are read from the weights in the proto array of the provider instead.
+type ParticipationForecast struct {
+	Epoch int `json:"epoch"`
+	// share of the epoch the votes were observed over
+	ElapsedFraction float64 `json:"elapsed_fraction"`
+	// share of active stake whose latest vote builds on the first canonical
+	// block of the epoch
+	JustificationRate          float64   `json:"justification_rate"`
+	ProjectedJustificationRate float64   `json:"projected_justification_rate"`
+	OnTrack                    bool      `json:"on_track"`
+	UpdatedAt                  time.Time `json:"updated_at"`
+}
+
+// forecastParticipation extrapolates the votes for the current epoch to the
+// full epoch. A validator votes once per epoch, for the head at its slot, so
+// the weight of the first canonical block of the epoch is the stake that
+// voted in the slots since; fork choice applies the votes of a slot once it
+// is over. Votes before that block went to its parent, which is the target
+// as well, so the rate observed since the block is projected over the whole
+// epoch. The weight includes any proposer boost.
+func forecastParticipation(protoArray []ProtoArrayNode, activeGwei uint64, currentSlot int, slotsPerEpoch int) *ParticipationForecast {
+	if len(protoArray) == 0 || activeGwei == 0 || slotsPerEpoch <= 0 {
+		return nil
+	}
+	epoch := currentSlot / slotsPerEpoch
+	head := protoArrayHead(protoArray)
+	first := -1
+	for _, i := range ancestorIndices(protoArray, protoArrayIndex(protoArray, head.Root)) {
+		if protoArray[i].Slot < epoch*slotsPerEpoch {
+			break
+		}
+		first = i
+	}
+	if first < 0 || currentSlot <= protoArray[first].Slot {
+		return nil
+	}
+	elapsed := float64(currentSlot-protoArray[first].Slot) / float64(slotsPerEpoch)
+	rate := protoArray[first].Weight / float64(activeGwei) * 100
+	projected := math.Min(rate/elapsed, 100)
+	return &ParticipationForecast{
+		Epoch:                      epoch,
+		ElapsedFraction:            elapsed,
+		JustificationRate:          rate,
+		ProjectedJustificationRate: projected,
+		OnTrack:                    projected >= justificationThreshold,
+	}
+}
+
+// latestActiveGwei is the active stake of the most recent epoch with
+// participation data, which stands in for the stake of the current one.
+func (m *Monitor) latestActiveGwei() uint64 {
+	history := m.participationHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Gwei != nil && history[i].Gwei.Active > 0 {
+			return history[i].Gwei.Active
+		}
+	}
+	return 0
+}
+
+// updateParticipationForecast projects the current epoch from `protoArray`,
+// the latest fork choice of the provider.
+func (m *Monitor) updateParticipationForecast(protoArray []ProtoArrayNode) {
+	config := m.eth2()
+	currentSlot := computeCurrentSlot(config.GenesisTime, config.SecondsPerSlot)
+	forecast := forecastParticipation(protoArray, m.latestActiveGwei(), currentSlot, config.SlotsPerEpoch)
+	if forecast != nil {
+		forecast.UpdatedAt = time.Now()
+	}
+
+	m.participationLock.Lock()
+	m.participationForecast = forecast
+	m.participationLock.Unlock()
+
+	if forecast != nil && !forecast.OnTrack && forecast.ElapsedFraction >= forecastAlertMinElapsed {
+		message := fmt.Sprintf("epoch %d is projected to reach only %.1f%% target participation", forecast.Epoch, forecast.ProjectedJustificationRate)
+		m.alerts.raise(participationForecastAlert, SeverityWarning, message)
+	} else if forecast == nil || forecast.OnTrack {
+		m.alerts.resolve(participationForecastAlert)
+	}
+}
@@ -0,0 +1,34 @@
+package monitor
+
+import "testing"
+
+func TestForecastParticipation(t *testing.T) {
+	// with 4 slots per epoch, epoch 2 starts with the block at slot 9:
+	// 0 <- 7 <- 9 <- 10
+	protoArray := protoArrayWithHead(3, [][2]int{{0, -1}, {7, 0}, {9, 1}, {10, 2}}...)
+	protoArray[2].Weight = 200
+
+	if forecastParticipation(protoArray, 1000, 9, 4) != nil {
+		t.Error("expected no forecast before any votes for the epoch are counted")
+	}
+
+	forecast := forecastParticipation(protoArray, 1000, 10, 4)
+	if forecast.Epoch != 2 || forecast.ElapsedFraction != 0.25 || forecast.JustificationRate != 20 || forecast.ProjectedJustificationRate != 80 || !forecast.OnTrack {
+		t.Errorf("expected epoch to be on track, got %+v", forecast)
+	}
+
+	forecast = forecastParticipation(protoArray, 2000, 11, 4)
+	if forecast.ProjectedJustificationRate != 20 || forecast.OnTrack {
+		t.Errorf("expected epoch to be behind, got %+v", forecast)
+	}
+
+	protoArray[2].Weight = 900
+	forecast = forecastParticipation(protoArray, 1000, 10, 4)
+	if forecast.ProjectedJustificationRate != 100 {
+		t.Errorf("expected projection to be capped, got %+v", forecast)
+	}
+
+	if forecastParticipation(protoArray, 1000, 13, 4) != nil {
+		t.Error("expected no forecast without a block in the current epoch")
+	}
+}
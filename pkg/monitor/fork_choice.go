@@ -1,64 +1,130 @@
 package monitor
 
-func buildTree(node ForkChoiceNode, nodes map[string]ForkChoiceNode, childrenIndex map[string][]string) ForkChoiceNode {
-	children := childrenIndex[node.Root]
+import "sort"
 
-	for _, childRoot := range children {
-		emptyChild := nodes[childRoot]
-		child := buildTree(emptyChild, nodes, childrenIndex)
-		node.Children = append(node.Children, child)
+// forkChoiceTree is the block tree of a proto array. Nodes refer to each other
+// by their index into the proto array so the tree shares its data and costs a
+// few integers per block. Nested `ForkChoiceNode`s are only built on request.
+type forkChoiceTree struct {
+	protoArray []ProtoArrayNode
+	canonical  []bool
+	// the children of node `i` are children[childStart[i]:childStart[i+1]]
+	childStart []int
+	children   []int
+	// the canonical chain from the root and the slot of each of its blocks
+	canonicalChain []int
+	canonicalSlots []int
+}
+
+func buildForkChoiceTree(protoArrayData []ProtoArrayNode, canonicalHeadIndex float64) *forkChoiceTree {
+	count := len(protoArrayData)
+	tree := &forkChoiceTree{
+		protoArray: protoArrayData,
+		canonical:  make([]bool, count),
+		childStart: make([]int, count+1),
+	}
+
+	parents := make([]int, count)
+	for i, protoNode := range protoArrayData {
+		tree.canonical[i] = protoNode.BestDescendant == canonicalHeadIndex
+		parents[i] = -1
+		if protoNode.ParentIndex != nil {
+			parent := int(*protoNode.ParentIndex)
+			if parent >= 0 && parent < count {
+				parents[i] = parent
+				tree.childStart[parent+1]++
+			}
+		}
+	}
+	for i := 0; i < count; i++ {
+		tree.childStart[i+1] += tree.childStart[i]
+	}
+	tree.children = make([]int, tree.childStart[count])
+	next := append([]int{}, tree.childStart[:count]...)
+	for i, parent := range parents {
+		if parent >= 0 {
+			tree.children[next[parent]] = i
+			next[parent]++
+		}
 	}
 
 	// NOTE: If there is a single-child extension (recursively) from the
 	// "best descendant" in the protoarray, then it is not marked as such
 	// and implictly inferred from the fact that there are no forks...
 	// Check for this condition here so we capture the full canonical chain
-	if len(node.Children) == 1 {
-		child := &node.Children[0]
-		if node.IsCanonical && !child.IsCanonical {
-			child.IsCanonical = true
+	onlyChildOfCanonical := make([]bool, count)
+	for i := 0; i < count; i++ {
+		if children := tree.childrenOf(i); len(children) == 1 && tree.canonical[i] {
+			onlyChildOfCanonical[children[0]] = true
+		}
+	}
+	for i, canonical := range onlyChildOfCanonical {
+		if canonical {
+			tree.canonical[i] = true
 		}
 	}
 
-	return node
+	if count > 0 {
+		tree.followCanonicalChain()
+	}
+	return tree
 }
 
-// Turn the flat proto_array data into a nested block tree
-func rollProtoArray(protoArrayData []ProtoArrayNode, canonicalHeadIndex float64) ForkChoiceNode {
-	// root to children
-	childrenIndex := make(map[string][]string)
-	nodes := make(map[string]ForkChoiceNode)
-	for _, protoNode := range protoArrayData {
-		root := protoNode.Root
-
-		isCanonical := protoNode.BestDescendant == canonicalHeadIndex
-		node := ForkChoiceNode{
-			Slot:        protoNode.Slot,
-			Root:        root,
-			Weight:      protoNode.Weight,
-			IsCanonical: isCanonical,
-		}
+// followCanonicalChain records the chain of canonical blocks from the root so
+// that lookups by slot need not walk the tree.
+func (t *forkChoiceTree) followCanonicalChain() {
+	t.canonicalChain = make([]int, 0, len(t.protoArray))
+	t.canonicalSlots = make([]int, 0, len(t.protoArray))
+	index := 0
+	for index >= 0 {
+		t.canonicalChain = append(t.canonicalChain, index)
+		t.canonicalSlots = append(t.canonicalSlots, t.protoArray[index].Slot)
 
-		if protoNode.ParentIndex != nil {
-			parentIndex := int(*protoNode.ParentIndex)
-			parentRoot := protoArrayData[parentIndex].Root
-			children, ok := childrenIndex[parentRoot]
-			if !ok {
-				children = []string{}
+		next := -1
+		for _, child := range t.childrenOf(index) {
+			if t.canonical[child] {
+				next = child
+				break
 			}
-			children = append(children, root)
-			childrenIndex[parentRoot] = children
 		}
+		index = next
+	}
+}
+
+func (t *forkChoiceTree) childrenOf(index int) []int {
+	return t.children[t.childStart[index]:t.childStart[index+1]]
+}
 
-		nodes[root] = node
+// materialize builds the nested block tree rooted at `index`.
+func (t *forkChoiceTree) materialize(index int) ForkChoiceNode {
+	protoNode := t.protoArray[index]
+	node := ForkChoiceNode{
+		Slot:        protoNode.Slot,
+		Root:        protoNode.Root,
+		Weight:      protoNode.Weight,
+		IsCanonical: t.canonical[index],
 	}
+	for _, child := range t.childrenOf(index) {
+		node.Children = append(node.Children, t.materialize(child))
+	}
+	return node
+}
 
-	rootNode := nodes[protoArrayData[0].Root]
+// canonicalIndexAt follows the canonical chain from the root of the tree to
+// the first block at or after `targetSlot`.
+func (t *forkChoiceTree) canonicalIndexAt(targetSlot int) int {
+	position := sort.SearchInts(t.canonicalSlots, targetSlot)
+	if position == len(t.canonicalChain) {
+		position--
+	}
+	return t.canonicalChain[position]
+}
 
-	return buildTree(rootNode, nodes, childrenIndex)
+// Turn the flat proto_array data into a nested block tree
+func rollProtoArray(protoArrayData []ProtoArrayNode, canonicalHeadIndex float64) ForkChoiceNode {
+	return buildForkChoiceTree(protoArrayData, canonicalHeadIndex).materialize(0)
 }
 
-func computeSummary(protoArrayData []ProtoArrayNode, canonicalHeadIndex float64) ForkChoiceNode {
-	blockTree := rollProtoArray(protoArrayData, canonicalHeadIndex)
-	return blockTree
+func computeSummary(protoArrayData []ProtoArrayNode, canonicalHeadIndex float64) *forkChoiceTree {
+	return buildForkChoiceTree(protoArrayData, canonicalHeadIndex)
 }
@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// a mainnet proto array during a long period of non-finality
+const benchmarkProtoArraySize = 50000
+
+// syntheticProtoArray builds a canonical chain of `size` blocks with a short
+// losing fork every few slots, as seen on mainnet.
+func syntheticProtoArray(size int) []ProtoArrayNode {
+	protoArray := make([]ProtoArrayNode, 0, size)
+	canonical := -1
+	for slot := 0; len(protoArray) < size; slot++ {
+		node := ProtoArrayNode{Slot: slot, Root: hash(strconv.Itoa(slot)), Weight: float64(32000000000 * (size - slot))}
+		if canonical >= 0 {
+			parent := float64(canonical)
+			node.ParentIndex = &parent
+		}
+		protoArray = append(protoArray, node)
+		canonical = len(protoArray) - 1
+
+		if slot%7 == 3 && len(protoArray) < size {
+			parent := *node.ParentIndex
+			protoArray = append(protoArray, ProtoArrayNode{Slot: node.Slot, Root: hash(strconv.Itoa(slot) + "'"), ParentIndex: &parent, Weight: 32000000000})
+		}
+	}
+	head := float64(canonical)
+	for i := range protoArray {
+		protoArray[i].BestDescendant = head
+	}
+	return protoArray
+}
+
+func benchmarkTree(b *testing.B) (*forkChoiceTree, int) {
+	protoArray := syntheticProtoArray(benchmarkProtoArraySize)
+	tree := buildForkChoiceTree(protoArray, protoArray[0].BestDescendant)
+	lastSlot := protoArray[len(protoArray)-1].Slot
+	return tree, lastSlot
+}
+
+func BenchmarkBuildForkChoiceTree(b *testing.B) {
+	protoArray := syntheticProtoArray(benchmarkProtoArraySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buildForkChoiceTree(protoArray, protoArray[0].BestDescendant)
+	}
+}
+
+func BenchmarkRollProtoArray(b *testing.B) {
+	protoArray := syntheticProtoArray(benchmarkProtoArraySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rollProtoArray(protoArray, protoArray[0].BestDescendant)
+	}
+}
+
+func BenchmarkPruneForBrowser(b *testing.B) {
+	tree, lastSlot := benchmarkTree(b)
+	secondsPerSlot := 12
+	genesisTime := int(time.Now().Unix()) - lastSlot*secondsPerSlot
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pruneForBrowser(tree, genesisTime, 32, secondsPerSlot)
+	}
+}
+
+func BenchmarkEncodeTree(b *testing.B) {
+	tree, _ := benchmarkTree(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.encodeJSON(ioutil.Discard, 0)
+	}
+}
+
+// Performance budget for a proto array of `benchmarkProtoArraySize` blocks.
+// Allocations are independent of the size of the proto array and enforced
+// exactly. Timings allow ample headroom over a typical server but depend on
+// the machine, so they are only checked with FORK_MON_TIMING_BUDGET=1 set.
+const timingBudgetEnv = "FORK_MON_TIMING_BUDGET"
+
+const (
+	buildTreeAllocs = 10
+	pruneAllocs     = 0
+	encodeAllocs    = 40
+
+	buildTreeBudget = 25 * time.Millisecond
+	pruneBudget     = 100 * time.Microsecond
+	encodeBudget    = 100 * time.Millisecond
+)
+
+// fastestOf returns the best of a few timed runs of `f`.
+func fastestOf(f func()) time.Duration {
+	var fastest time.Duration
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		f()
+		elapsed := time.Since(start)
+		if i == 0 || elapsed < fastest {
+			fastest = elapsed
+		}
+	}
+	return fastest
+}
+
+func TestForkChoicePerformanceBudget(t *testing.T) {
+	protoArray := syntheticProtoArray(benchmarkProtoArraySize)
+	head := protoArray[0].BestDescendant
+	var tree *forkChoiceTree
+	build := func() { tree = buildForkChoiceTree(protoArray, head) }
+	build()
+
+	lastSlot := protoArray[len(protoArray)-1].Slot
+	genesisTime := int(time.Now().Unix()) - lastSlot*12
+	prune := func() { pruneForBrowser(tree, genesisTime, 32, 12) }
+	encode := func() { tree.encodeJSON(ioutil.Discard, 0) }
+
+	budgets := []struct {
+		name   string
+		f      func()
+		allocs float64
+		time   time.Duration
+	}{
+		{"build tree", build, buildTreeAllocs, buildTreeBudget},
+		{"prune for browser", prune, pruneAllocs, pruneBudget},
+		{"encode tree", encode, encodeAllocs, encodeBudget},
+	}
+	for _, budget := range budgets {
+		if allocs := testing.AllocsPerRun(3, budget.f); allocs > budget.allocs {
+			t.Errorf("%s: %.0f allocations exceed the budget of %.0f", budget.name, allocs, budget.allocs)
+		}
+		if os.Getenv(timingBudgetEnv) != "1" {
+			continue
+		}
+		if elapsed := fastestOf(budget.f); elapsed > budget.time {
+			t.Errorf("%s: took %s, over the budget of %s", budget.name, elapsed, budget.time)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const forkChoiceDivergenceAlert = "fork_choice_divergence"
+const headMismatchAlertPrefix = "head_mismatch:"
+const defaultForkChoiceDivergenceSlots = 3
+const defaultForkChoiceWeightTolerance = 0.05
+
+// protoArrayHead returns the head of `protoArray`, the zero value if it is
+// empty.
+func protoArrayHead(protoArray []ProtoArrayNode) ProtoArrayNode {
+	if len(protoArray) == 0 {
+		return ProtoArrayNode{}
+	}
+	headIndex := int(protoArray[0].BestDescendant)
+	if headIndex < 0 || headIndex >= len(protoArray) {
+		return protoArray[0]
+	}
+	return protoArray[headIndex]
+}
+
+// compareForkChoice returns a description of how the fork choice of two nodes
+// differs, or the empty string if they agree. Weights are only compared if both
+// proto arrays are anchored at the same root as they are otherwise not
+// comparable.
+func compareForkChoice(a []ProtoArrayNode, b []ProtoArrayNode, weightTolerance float64) string {
+	if len(a) == 0 || len(b) == 0 {
+		return "missing fork choice data"
+	}
+
+	headA := protoArrayHead(a)
+	headB := protoArrayHead(b)
+	if headA.Root != headB.Root {
+		return fmt.Sprintf("canonical heads differ: %s at slot %d vs. %s at slot %d", headA.Root, headA.Slot, headB.Root, headB.Slot)
+	}
+
+	if a[0].Root == b[0].Root {
+		weightA := a[0].Weight
+		weightB := b[0].Weight
+		largest := math.Max(weightA, weightB)
+		if largest > 0 && math.Abs(weightA-weightB)/largest > weightTolerance {
+			return fmt.Sprintf("total weights differ: %.0f vs. %.0f", weightA, weightB)
+		}
+	}
+	return ""
+}
+
+func (m *Monitor) forkChoiceDivergenceSlots() int {
+	if m.config.ForkChoiceDivergenceSlots > 0 {
+		return m.config.ForkChoiceDivergenceSlots
+	}
+	return defaultForkChoiceDivergenceSlots
+}
+
+func (m *Monitor) forkChoiceWeightTolerance() float64 {
+	if m.config.ForkChoiceWeightTolerance > 0 {
+		return m.config.ForkChoiceWeightTolerance
+	}
+	return defaultForkChoiceWeightTolerance
+}
+
+// checkForkChoiceProviders compares the first two healthy fork choice
+// providers and alerts if they disagree for several slots in a row,
+// as then the fork choice view we serve may be unreliable.
+func (m *Monitor) checkForkChoiceProviders() error {
+	var providers []*Node
+	for _, node := range m.candidatesFor(forkChoiceQuery) {
+		if node.isHealthy && !node.isSyncing {
+			providers = append(providers, node)
+		}
+	}
+	if len(providers) < 2 {
+		m.divergentSlots = 0
+		m.alerts.resolve(forkChoiceDivergenceAlert)
+		return nil
+	}
+
+	a, err := m.protoArrayOf(providers[0])
+	if err != nil {
+		return err
+	}
+	b, err := m.protoArrayOf(providers[1])
+	if err != nil {
+		return err
+	}
+
+	difference := compareForkChoice(a, b, m.forkChoiceWeightTolerance())
+	if difference == "" {
+		m.divergentSlots = 0
+		m.alerts.resolve(forkChoiceDivergenceAlert)
+		return nil
+	}
+
+	m.divergentSlots++
+	if m.divergentSlots >= m.forkChoiceDivergenceSlots() {
+		message := fmt.Sprintf("nodes %s and %s disagree for %d slots, %s", providers[0].id, providers[1].id, m.divergentSlots, difference)
+		m.alerts.raise(forkChoiceDivergenceAlert, SeverityCritical, message)
+	}
+	return nil
+}
+
+// compareHeads returns a description of how the head implied by a node's fork
+// choice differs from the head it reports over the headers API, or the empty
+// string if they agree.
+func compareHeads(protoArray []ProtoArrayNode, head HeadRef) string {
+	if len(protoArray) == 0 || head.root == "" {
+		return ""
+	}
+	forkChoiceHead := protoArrayHead(protoArray)
+	if forkChoiceHead.Root == head.root {
+		return ""
+	}
+	return fmt.Sprintf("fork choice head is %s at slot %d but headers head is %s at slot %d", forkChoiceHead.Root, forkChoiceHead.Slot, head.root, head.slot)
+}
+
+// checkHeadConsistency verifies that every healthy fork choice provider agrees
+// with itself about its head. A mismatch that outlives normal propagation races
+// points to an inconsistency inside the client worth reporting upstream.
+func (m *Monitor) checkHeadConsistency() {
+	for _, node := range m.candidatesFor(forkChoiceQuery) {
+		name := headMismatchAlertPrefix + node.id
+		if !node.isHealthy || node.isSyncing {
+			node.headMismatchSlots = 0
+			m.alerts.resolve(name)
+			continue
+		}
+
+		protoArray, err := m.protoArrayOf(node)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		difference := compareHeads(protoArray, node.latestHead)
+		if difference == "" {
+			node.headMismatchSlots = 0
+			m.alerts.resolve(name)
+			continue
+		}
+
+		node.headMismatchSlots++
+		if node.headMismatchSlots >= m.forkChoiceDivergenceSlots() {
+			message := fmt.Sprintf("node %s is inconsistent for %d slots, %s", node.id, node.headMismatchSlots, difference)
+			m.alerts.raise(name, SeverityWarning, message)
+		}
+	}
+}
+
+func (m *Monitor) startForkChoiceSanityCheck(beat func() bool) {
+	config := m.eth2()
+	for beat() {
+		waitUntilNextSlot(config.GenesisTime, config.SecondsPerSlot)
+		// give the block for this slot a chance to propagate
+		time.Sleep(time.Duration(config.SecondsPerSlot) * time.Second / 3)
+
+		err := m.checkForkChoiceProviders()
+		if err != nil {
+			log.Println(err)
+		}
+		m.checkHeadConsistency()
+	}
+}
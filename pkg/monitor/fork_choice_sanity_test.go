@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func protoArrayServer(t *testing.T, nodes *[]ProtoArrayNode) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := ProtoArrayResp{}
+		resp.Data.Nodes = *nodes
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestForkChoiceDivergenceAlert(t *testing.T) {
+	zero := float64(0)
+	agreed := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	forked := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1'"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	lighter := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 50, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 50, BestDescendant: 1},
+	}
+
+	if compareForkChoice(agreed, agreed, 0.05) != "" {
+		t.Error("identical fork choice should agree")
+	}
+	if compareForkChoice(agreed, forked, 0.05) == "" {
+		t.Error("expected different heads to diverge")
+	}
+	if compareForkChoice(agreed, lighter, 0.05) == "" {
+		t.Error("expected different weights to diverge")
+	}
+
+	first := agreed
+	second := agreed
+	a := protoArrayServer(t, &first)
+	defer a.Close()
+	b := protoArrayServer(t, &second)
+	defer b.Close()
+
+	m := &Monitor{
+		config: &Config{ForkChoiceDivergenceSlots: 2, Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		forkChoiceProviders: []*Node{
+			{id: "a", endpoint: a.URL, isHealthy: true},
+			{id: "b", endpoint: b.URL, isHealthy: true},
+		},
+		alerts: newAlertSet(),
+	}
+
+	second = forked
+	for slot := 0; slot < 2; slot++ {
+		if len(m.alerts.list()) != 0 {
+			t.Fatal("alerted before the divergence threshold")
+		}
+		err := m.checkForkChoiceProviders()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the proto arrays are fetched again in the next slot
+		m.protoArrays.clear()
+	}
+	alerts := m.alerts.list()
+	if len(alerts) != 1 || alerts[0].Name != forkChoiceDivergenceAlert || alerts[0].Severity != SeverityCritical {
+		t.Fatalf("expected a critical divergence alert, have %v", alerts)
+	}
+
+	second = agreed
+	err := m.checkForkChoiceProviders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.alerts.list()) != 0 {
+		t.Error("expected alert to resolve once providers agree")
+	}
+}
+
+func TestHeadConsistencyAlert(t *testing.T) {
+	zero := float64(0)
+	protoArray := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	server := protoArrayServer(t, &protoArray)
+	defer server.Close()
+
+	node := &Node{id: "a", endpoint: server.URL, isHealthy: true, latestHead: HeadRef{1, hash("1")}}
+	m := &Monitor{
+		config:              &Config{ForkChoiceDivergenceSlots: 2, Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		forkChoiceProviders: []*Node{node},
+		alerts:              newAlertSet(),
+	}
+
+	m.checkHeadConsistency()
+	if len(m.alerts.list()) != 0 {
+		t.Fatal("expected consistent heads not to alert")
+	}
+
+	node.latestHead = HeadRef{1, hash("1'")}
+	for slot := 0; slot < 2; slot++ {
+		if len(m.alerts.list()) != 0 {
+			t.Fatal("alerted before the mismatch threshold")
+		}
+		m.checkHeadConsistency()
+		m.protoArrays.clear()
+	}
+	alerts := m.alerts.list()
+	if len(alerts) != 1 || alerts[0].Name != headMismatchAlertPrefix+"a" {
+		t.Fatalf("expected a head mismatch alert, have %v", alerts)
+	}
+
+	node.latestHead = HeadRef{1, hash("1")}
+	m.checkHeadConsistency()
+	if len(m.alerts.list()) != 0 {
+		t.Error("expected alert to resolve once the heads agree")
+	}
+}
@@ -18,10 +18,10 @@ func TestCanBuildTree(t *testing.T) {
 	firstIndex := float64(0)
 	secondIndex := float64(1)
 	protoArrayData := []ProtoArrayNode{
-		{Slot: "0", Root: hash("0")},
-		{Slot: "1", Root: hash("1"), ParentIndex: &firstIndex},
-		{Slot: "2", Root: hash("2"), ParentIndex: &secondIndex},
-		{Slot: "3", Root: hash("3"), ParentIndex: &firstIndex},
+		{Slot: 0, Root: hash("0")},
+		{Slot: 1, Root: hash("1"), ParentIndex: &firstIndex},
+		{Slot: 2, Root: hash("2"), ParentIndex: &secondIndex},
+		{Slot: 3, Root: hash("3"), ParentIndex: &firstIndex},
 	}
 
 	tree := rollProtoArray(protoArrayData, 3)
@@ -30,17 +30,17 @@ func TestCanBuildTree(t *testing.T) {
 		Children: []ForkChoiceNode{
 			{
 				Children: []ForkChoiceNode{
-					{Slot: "2", Root: hash("2")},
+					{Slot: 2, Root: hash("2")},
 				},
-				Slot: "1",
+				Slot: 1,
 				Root: hash("1"),
 			},
 			{
-				Slot: "3",
+				Slot: 3,
 				Root: hash("3"),
 			},
 		},
-		Slot: "0",
+		Slot: 0,
 		Root: hash("0"),
 	}
 
@@ -49,3 +49,35 @@ func TestCanBuildTree(t *testing.T) {
 		t.Error("did not compute the expected tree")
 	}
 }
+
+func TestForkChoiceTreeCanonicalChain(t *testing.T) {
+	zero := float64(0)
+	one := float64(1)
+	two := float64(2)
+	protoArrayData := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), BestDescendant: 3},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, BestDescendant: 3},
+		{Slot: 2, Root: hash("2"), ParentIndex: &one, BestDescendant: 3},
+		{Slot: 3, Root: hash("3"), ParentIndex: &two},
+		{Slot: 2, Root: hash("2'"), ParentIndex: &one, BestDescendant: 4},
+	}
+
+	tree := buildForkChoiceTree(protoArrayData, 3)
+	expected := []bool{true, true, true, true, false}
+	if !reflect.DeepEqual(tree.canonical, expected) {
+		t.Errorf("unexpected canonical chain %v", tree.canonical)
+	}
+	if children := tree.childrenOf(1); !reflect.DeepEqual(children, []int{2, 4}) {
+		t.Errorf("expected children in proto array order, got %v", children)
+	}
+
+	if index := tree.canonicalIndexAt(2); index != 2 {
+		t.Errorf("expected canonical block at slot 2, got index %d", index)
+	}
+	if index := tree.canonicalIndexAt(10); index != 3 {
+		t.Errorf("expected to stop at the head, got index %d", index)
+	}
+	if node := tree.materialize(2); len(node.Children) != 1 || node.Children[0].Root != hash("3") {
+		t.Errorf("unexpected subtree %+v", node)
+	}
+}
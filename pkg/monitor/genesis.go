@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const genesisCheckInterval = 1 * time.Minute
+
+// GenesisReset records a (periodic) restart of the network from a new
+// genesis, as happens on e.g. the Ephemery testnet.
+type GenesisReset struct {
+	DetectedAt          time.Time `json:"detected_at"`
+	PreviousGenesisTime int       `json:"previous_genesis_time"`
+	GenesisTime         int       `json:"genesis_time"`
+}
+
+func (c *Config) watchesGenesis() bool {
+	return c.WatchGenesis || strings.EqualFold(c.Eth2.Network, "ephemery")
+}
+
+// genesisClock holds the genesis time once the network restarted from a new
+// genesis, the configured one applies until then; the zero value is ready
+// to use.
+type genesisClock struct {
+	lock        sync.Mutex
+	genesisTime int
+}
+
+// genesisTime is the genesis time of the chain the monitor follows.
+func (m *Monitor) genesisTime() int {
+	m.genesis.lock.Lock()
+	defer m.genesis.lock.Unlock()
+	if m.genesis.genesisTime != 0 {
+		return m.genesis.genesisTime
+	}
+	return m.config.Eth2.GenesisTime
+}
+
+func (m *Monitor) latestGenesisReset() *GenesisReset {
+	m.genesis.lock.Lock()
+	defer m.genesis.lock.Unlock()
+	return m.lastGenesisReset
+}
+
+// eth2 returns the chain configuration with the current genesis time.
+func (m *Monitor) eth2() Eth2Config {
+	config := m.config.Eth2
+	config.GenesisTime = m.genesisTime()
+	return config
+}
+
+// observedGenesisTime returns the genesis time reported by the most nodes.
+func (m *Monitor) observedGenesisTime() (int, bool) {
+	counts := make(map[int]int)
+	for _, node := range m.nodeList() {
+		if !node.isHealthy {
+			continue
+		}
+		genesisTime, err := node.fetchGenesisTime()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		counts[genesisTime]++
+	}
+
+	observed := 0
+	observedCount := 0
+	for genesisTime, count := range counts {
+		if count > observedCount {
+			observed = genesisTime
+			observedCount = count
+		}
+	}
+	return observed, observedCount > 0
+}
+
+// resetGenesis moves the monitor over to the new chain, dropping everything
+// we know about the previous one.
+func (m *Monitor) resetGenesis(genesisTime int) {
+	reset := GenesisReset{
+		DetectedAt:          time.Now(),
+		PreviousGenesisTime: m.genesisTime(),
+		GenesisTime:         genesisTime,
+	}
+	log.Printf("genesis changed from %d to %d, resetting monitor state", reset.PreviousGenesisTime, reset.GenesisTime)
+
+	m.genesis.lock.Lock()
+	m.genesis.genesisTime = genesisTime
+	m.lastGenesisReset = &reset
+	m.genesis.lock.Unlock()
+
+	m.forkchoiceLock.Lock()
+	m.forkChoiceSummary = nil
+	m.forkChoiceHead = nil
+	m.safeHead = nil
+	m.forkchoiceLock.Unlock()
+
+	m.weakSubjectivityLock.Lock()
+	m.weakSubjectivityData = WeakSubjectivityData{}
+	m.weakSubjectivityLock.Unlock()
+
+	m.justifiedCheckpoint = Checkpoint{}
+	m.finalizedCheckpoint = Checkpoint{}
+	m.polledEpochLock.Lock()
+	m.polledEpoch = nil
+	m.polledEpochLock.Unlock()
+	m.finalityLatencies.clear()
+	m.headStability.clear()
+	m.protoArrays.clear()
+	m.surroundRisks.clear()
+	m.samples.pruneBefore(reset.DetectedAt)
+	m.reorgs.reset()
+	err := m.store().Reset()
+	if err != nil {
+		log.Println(err)
+	}
+	m.deposits.restore(depositState{})
+	// the head poll writes the heads, so reset them between its rounds
+	m.headsLock.Lock()
+	for _, node := range m.nodeList() {
+		node.latestHead = HeadRef{}
+	}
+	m.headsLock.Unlock()
+}
+
+func (m *Monitor) checkGenesis() {
+	genesisTime, ok := m.observedGenesisTime()
+	if ok && genesisTime != m.genesisTime() {
+		m.resetGenesis(genesisTime)
+	}
+}
+
+func (m *Monitor) startGenesisMonitor(beat func() bool) {
+	for beat() {
+		m.checkGenesis()
+		time.Sleep(genesisCheckInterval)
+	}
+}
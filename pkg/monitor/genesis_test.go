@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestGenesisReset(t *testing.T) {
+	genesisTime := 1000
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": {"genesis_time": "%d"}}`, genesisTime)
+	}))
+	defer server.Close()
+
+	m := &Monitor{
+		config:  &Config{Eth2: Eth2Config{Network: "ephemery", GenesisTime: 1000}},
+		nodes:   []*Node{{endpoint: server.URL, isHealthy: true, latestHead: HeadRef{10, "0xaa"}}},
+		samples: newSampleStore(),
+	}
+	m.store().SaveParticipation(Participation{Epoch: 1})
+	m.finalizedCheckpoint = Checkpoint{Epoch: 1, Root: "0x01"}
+
+	m.checkGenesis()
+	if m.lastGenesisReset != nil || len(m.participationHistory()) != 1 {
+		t.Fatal("reset without a change in genesis")
+	}
+
+	genesisTime = 2000
+	m.checkGenesis()
+	if m.genesisTime() != 2000 {
+		t.Errorf("genesis time not updated: %d", m.genesisTime())
+	}
+	if m.lastGenesisReset == nil || m.lastGenesisReset.PreviousGenesisTime != 1000 {
+		t.Errorf("reset not recorded: %v", m.lastGenesisReset)
+	}
+	if len(m.participationHistory()) != 0 || m.finalizedCheckpoint != (Checkpoint{}) || m.nodes[0].latestHead != (HeadRef{}) {
+		t.Error("state from the previous chain was kept")
+	}
+}
+
+func TestGenesisResetWhilePolling(t *testing.T) {
+	genesisTime := 1000
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": {"genesis_time": "%d"}}`, genesisTime)
+	}))
+	defer server.Close()
+
+	m := &Monitor{
+		config:  &Config{Eth2: Eth2Config{Network: "ephemery", GenesisTime: 1000, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		nodes:   []*Node{{endpoint: server.URL, isHealthy: true}},
+		samples: newSampleStore(),
+	}
+	genesisTime = 2000
+
+	// the reset races with readers of the clock, run with -race
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			m.getCurrentEpoch()
+		}
+	}()
+	m.checkGenesis()
+	wg.Wait()
+	if m.eth2().GenesisTime != 2000 || m.config.Eth2.GenesisTime != 1000 {
+		t.Errorf("expected the new genesis time to be tracked apart from the config, got %d", m.eth2().GenesisTime)
+	}
+}
@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// slots behind the head whose canonical block is watched for changes
+const headStabilityTrackedSlots = 64
+
+// number of epochs the switch counts are kept for
+const headStabilityHistoryEpochs = 256
+
+// HeadStabilityEpoch counts how often the canonical block of a slot in the
+// epoch changed after it was first observed, including a block being
+// orphaned or a late block taking over an empty slot.
+type HeadStabilityEpoch struct {
+	Epoch    int `json:"epoch"`
+	Switches int `json:"switches"`
+}
+
+// canonicalRoots maps each slot in [fromSlot, head slot] to the root of its
+// block on the canonical chain of `protoArray`, the empty string if the slot
+// has no block.
+func canonicalRoots(protoArray []ProtoArrayNode, fromSlot int) map[int]string {
+	head := protoArrayHead(protoArray)
+	roots := make(map[int]string)
+	for slot := fromSlot; slot <= head.Slot; slot++ {
+		roots[slot] = ""
+	}
+	for _, i := range ancestorIndices(protoArray, protoArrayIndex(protoArray, head.Root)) {
+		node := protoArray[i]
+		if node.Slot < fromSlot {
+			break
+		}
+		roots[node.Slot] = node.Root
+	}
+	return roots
+}
+
+// headStability follows the canonical block of recent slots across fork
+// choice updates; the zero value is ready to use.
+type headStability struct {
+	lock sync.Mutex
+	// the node the roots were observed on
+	source string
+	roots  map[int]string
+	epochs []HeadStabilityEpoch
+}
+
+// epochEntry returns the counts of `epoch`, keeping the series by ascending
+// epoch even if an earlier epoch shows up late, e.g. from a node that is
+// behind.
+func (h *headStability) epochEntry(epoch int) *HeadStabilityEpoch {
+	i := sort.Search(len(h.epochs), func(i int) bool { return h.epochs[i].Epoch >= epoch })
+	if i < len(h.epochs) && h.epochs[i].Epoch == epoch {
+		return &h.epochs[i]
+	}
+	h.epochs = append(h.epochs, HeadStabilityEpoch{})
+	copy(h.epochs[i+1:], h.epochs[i:])
+	h.epochs[i] = HeadStabilityEpoch{Epoch: epoch}
+	return &h.epochs[i]
+}
+
+// observe compares the canonical chain of `protoArray`, served by `source`,
+// with the one seen on earlier calls and counts the slots whose block
+// changed. The view of another node is not compared with the previous one,
+// so a change of provider starts over from its chain.
+func (h *headStability) observe(protoArray []ProtoArrayNode, source string, slotsPerEpoch int) {
+	if len(protoArray) == 0 {
+		return
+	}
+	head := protoArrayHead(protoArray)
+	fromSlot := head.Slot - headStabilityTrackedSlots + 1
+	// earlier blocks have been pruned from the proto array
+	if fromSlot < protoArray[0].Slot {
+		fromSlot = protoArray[0].Slot
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.roots == nil || source != h.source {
+		h.roots = make(map[int]string)
+		h.source = source
+	}
+	roots := canonicalRoots(protoArray, fromSlot)
+	for slot := fromSlot; slot <= head.Slot; slot++ {
+		root := roots[slot]
+		entry := h.epochEntry(slot / slotsPerEpoch)
+		if previous, ok := h.roots[slot]; ok && previous != root {
+			entry.Switches++
+		}
+		h.roots[slot] = root
+	}
+	for slot := range h.roots {
+		if slot < fromSlot {
+			delete(h.roots, slot)
+		}
+	}
+	if len(h.epochs) > headStabilityHistoryEpochs {
+		h.epochs = append([]HeadStabilityEpoch{}, h.epochs[len(h.epochs)-headStabilityHistoryEpochs:]...)
+	}
+}
+
+func (h *headStability) list() []HeadStabilityEpoch {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]HeadStabilityEpoch{}, h.epochs...)
+}
+
+func (h *headStability) clear() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.source = ""
+	h.roots = nil
+	h.epochs = nil
+}
+
+type headStabilityResp struct {
+	Epochs []HeadStabilityEpoch `json:"epochs"`
+}
+
+// sendHeadStability serves the head switches of the last `?epochs=` epochs.
+func (m *Monitor) sendHeadStability(w http.ResponseWriter, r *http.Request) {
+	epochs, err := parsePositiveInt(r.URL.Query(), "epochs", headStabilityHistoryEpochs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	series := m.headStability.list()
+	if len(series) > epochs {
+		series = series[len(series)-epochs:]
+	}
+	writeJSON(w, r, &headStabilityResp{Epochs: series})
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import "testing"
+
+func TestHeadStability(t *testing.T) {
+	// 0 <- 1 <- 2
+	//   \- 3 <- 4
+	//             \- 5 at slot 33
+	blocks := [][2]int{{0, -1}, {1, 0}, {2, 1}, {2, 0}, {3, 3}, {33, 4}}
+	var stability headStability
+
+	stability.observe(protoArrayWithHead(2, blocks...), "a", 32)
+	stability.observe(protoArrayWithHead(2, blocks...), "a", 32)
+	if epochs := stability.list(); len(epochs) != 1 || epochs[0].Switches != 0 {
+		t.Fatalf("expected no switches while the head is unchanged, got %+v", epochs)
+	}
+
+	// block 1 is orphaned and slot 2 switches to block 3
+	stability.observe(protoArrayWithHead(4, blocks...), "a", 32)
+	if epochs := stability.list(); len(epochs) != 1 || epochs[0].Switches != 2 {
+		t.Fatalf("expected 2 switches, got %+v", epochs)
+	}
+
+	stability.observe(protoArrayWithHead(5, blocks...), "a", 32)
+	epochs := stability.list()
+	if len(epochs) != 2 || epochs[0].Switches != 2 || epochs[1] != (HeadStabilityEpoch{Epoch: 1}) {
+		t.Fatalf("unexpected series %+v", epochs)
+	}
+
+	// another provider reporting the orphaned branch does not count as a
+	// switch and an epoch seen late keeps the series ascending
+	stability.observe(protoArrayWithHead(2, blocks...), "b", 32)
+	epochs = stability.list()
+	if len(epochs) != 2 || epochs[0].Switches != 2 || epochs[1] != (HeadStabilityEpoch{Epoch: 1}) {
+		t.Fatalf("expected no switches after the provider changed, got %+v", epochs)
+	}
+	var late headStability
+	late.observe(protoArrayWithHead(5, blocks...), "a", 32)
+	// only epoch 1 is known when a node behind reports epoch 0
+	late.epochs = late.epochs[1:]
+	late.observe(protoArrayWithHead(2, blocks...), "a", 32)
+	if epochs := late.list(); len(epochs) != 2 || epochs[0].Epoch != 0 || epochs[1].Epoch != 1 {
+		t.Fatalf("expected the series by ascending epoch, got %+v", epochs)
+	}
+
+	stability.clear()
+	if len(stability.list()) != 0 {
+		t.Error("expected an empty series after clearing")
+	}
+}
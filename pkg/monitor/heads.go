@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// how many of the most recent heads are kept per node
+const headHistoryLength = 64
+
+type HeadObservation struct {
+	Slot       int       `json:"slot,string"`
+	Root       string    `json:"root"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// headHistory is a ring buffer of the latest heads of a node; the zero value is ready to use.
+type headHistory struct {
+	lock    sync.Mutex
+	entries []HeadObservation
+	next    int
+}
+
+func (h *headHistory) add(observation HeadObservation) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.entries) < headHistoryLength {
+		h.entries = append(h.entries, observation)
+		return
+	}
+	h.entries[h.next] = observation
+	h.next = (h.next + 1) % headHistoryLength
+}
+
+// list returns the observations from oldest to newest
+func (h *headHistory) list() []HeadObservation {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	observations := make([]HeadObservation, 0, len(h.entries))
+	observations = append(observations, h.entries[h.next:]...)
+	return append(observations, h.entries[:h.next]...)
+}
+
+func (h *headHistory) clear() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.entries = nil
+	h.next = 0
+}
+
+// branchSwitches counts the heads that did not move past the slot of the one
+// before, i.e. the node switched to another branch.
+func branchSwitches(observations []HeadObservation) int {
+	switches := 0
+	for i := 1; i < len(observations); i++ {
+		if observations[i].Slot <= observations[i-1].Slot {
+			switches++
+		}
+	}
+	return switches
+}
+
+type nodeHeadsResp struct {
+	ID             string            `json:"id"`
+	Heads          []HeadObservation `json:"heads"`
+	BranchSwitches int               `json:"branch_switches"`
+}
+
+func (m *Monitor) sendNodeHeads(w http.ResponseWriter, r *http.Request, node *Node) {
+	heads := m.headObservations(node.id)
+	resp := nodeHeadsResp{
+		ID:             node.id,
+		Heads:          heads,
+		BranchSwitches: branchSwitches(heads),
+	}
+	writeJSON(w, r, &resp)
+}
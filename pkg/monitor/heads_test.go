@@ -0,0 +1,39 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeadHistory(t *testing.T) {
+	var history headHistory
+	for i := 0; i < headHistoryLength+3; i++ {
+		history.add(HeadObservation{Slot: i, ObservedAt: time.Unix(int64(i), 0)})
+	}
+
+	heads := history.list()
+	if len(heads) != headHistoryLength {
+		t.Fatalf("expected %d heads, got %d", headHistoryLength, len(heads))
+	}
+	if heads[0].Slot != 3 || heads[len(heads)-1].Slot != headHistoryLength+2 {
+		t.Errorf("unexpected order of heads, from %d to %d", heads[0].Slot, heads[len(heads)-1].Slot)
+	}
+
+	history.clear()
+	if len(history.list()) != 0 {
+		t.Error("expected history to be empty")
+	}
+}
+
+func TestBranchSwitches(t *testing.T) {
+	heads := []HeadObservation{
+		{Slot: 10, Root: "a"},
+		{Slot: 11, Root: "b"},
+		{Slot: 11, Root: "c"},
+		{Slot: 12, Root: "d"},
+		{Slot: 11, Root: "b"},
+	}
+	if switches := branchSwitches(heads); switches != 2 {
+		t.Errorf("expected 2 branch switches, got %d", switches)
+	}
+}
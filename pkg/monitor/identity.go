@@ -0,0 +1,39 @@
+package monitor
+
+import "fmt"
+
+// NodeIdentity is the p2p identity of a node. It is only served when
+// `reveal_identity` is set as it allows anyone to find and target the node.
+type NodeIdentity struct {
+	PeerID             string   `json:"peer_id"`
+	ENR                string   `json:"enr"`
+	P2PAddresses       []string `json:"p2p_addresses"`
+	DiscoveryAddresses []string `json:"discovery_addresses"`
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// parseNodeIdentity reads the `data` of a `/eth/v1/node/identity` response.
+// Only the peer ID is required, clients differ in which addresses they report.
+func parseNodeIdentity(data map[string]interface{}) (NodeIdentity, error) {
+	peerID, ok := data["peer_id"].(string)
+	if !ok {
+		return NodeIdentity{}, fmt.Errorf("peer id not a string")
+	}
+	enr, _ := data["enr"].(string)
+	return NodeIdentity{
+		PeerID:             peerID,
+		ENR:                enr,
+		P2PAddresses:       stringList(data["p2p_addresses"]),
+		DiscoveryAddresses: stringList(data["discovery_addresses"]),
+	}, nil
+}
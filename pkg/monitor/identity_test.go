@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRevealIdentity(t *testing.T) {
+	identity, err := parseNodeIdentity(map[string]interface{}{
+		"peer_id":       "16Uiu2HAmPeer",
+		"enr":           "enr:-abc",
+		"p2p_addresses": []interface{}{"/ip4/10.0.0.1/tcp/9000/p2p/16Uiu2HAmPeer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseNodeIdentity(map[string]interface{}{}); err == nil {
+		t.Error("expected an identity without a peer id to be rejected")
+	}
+
+	node := &Node{id: idHashOf(identity.PeerID), identity: identity}
+	m := &Monitor{config: &Config{Eth2: Eth2Config{SecondsPerSlot: 12, SlotsPerEpoch: 32}}, nodes: []*Node{node}}
+	detail := func() nodeDetailResp {
+		w := httptest.NewRecorder()
+		m.sendNodeAPI(w, httptest.NewRequest(http.MethodGet, "/api/v1/nodes/"+node.id, nil))
+		resp := nodeDetailResp{}
+		err := json.NewDecoder(w.Body).Decode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := detail(); resp.Identity != nil {
+		t.Errorf("identity exposed without reveal_identity: %+v", resp.Identity)
+	}
+
+	m.config.RevealIdentity = true
+	resp := detail()
+	if resp.Identity == nil || resp.Identity.PeerID != "16Uiu2HAmPeer" || resp.Identity.ENR != "enr:-abc" || len(resp.Identity.P2PAddresses) != 1 {
+		t.Errorf("unexpected identity %+v", resp.Identity)
+	}
+}
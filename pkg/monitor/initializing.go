@@ -0,0 +1,56 @@
+package monitor
+
+import "log"
+
+// expectedSections lists the sections this monitor fills in given its
+// configuration and the nodes found so far.
+func (m *Monitor) expectedSections() []string {
+	var sections []string
+	if len(m.candidatesFor(forkChoiceQuery)) > 0 {
+		sections = append(sections, forkChoiceSource, finalitySource)
+	}
+	if len(m.candidatesFor(participationQuery)) > 0 {
+		sections = append(sections, participationSource)
+	}
+	if m.config.EtherscanAPIKey != "" {
+		sections = append(sections, depositContractSource)
+	}
+	if m.config.WSProviderEndpoint != "" {
+		sections = append(sections, weakSubjectivitySource)
+	}
+	return sections
+}
+
+// initializingSections returns the expected sections that have not been
+// fetched since startup, their data is missing or restored from disk.
+func (m *Monitor) initializingSections() []string {
+	sections := []string{}
+	for _, section := range m.expectedSections() {
+		if m.isInitializing(section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+func (m *Monitor) isInitializing(section string) bool {
+	return m.sources.get(section) == nil
+}
+
+// initialize does the first fetch of the fork choice and finality so these
+// are available before the head monitor aligns to the next slot.
+func (m *Monitor) initialize() {
+	provider := m.forkChoiceProvider()
+	if provider == nil {
+		return
+	}
+
+	err := m.buildLatestForkChoiceSummary()
+	if err != nil {
+		log.Println(err)
+	}
+	err = m.updateFinality(provider)
+	if err != nil {
+		log.Println(err)
+	}
+}
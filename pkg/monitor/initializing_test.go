@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestInitializingSections(t *testing.T) {
+	zero := float64(0)
+	protoArray := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	server := protoArrayServer(t, &protoArray)
+	defer server.Close()
+
+	node := &Node{id: "a", endpoint: server.URL, version: "Lighthouse/v5.0.0", isHealthy: true}
+	m := &Monitor{
+		config:                    &Config{Eth2: Eth2Config{SecondsPerSlot: 12, SlotsPerEpoch: 32}, EtherscanAPIKey: "key"},
+		nodes:                     []*Node{node},
+		forkChoiceProviders:       []*Node{node},
+		currentForkChoiceProvider: node,
+		alerts:                    newAlertSet(),
+	}
+
+	summary := func() summaryResp {
+		w := httptest.NewRecorder()
+		m.sendSummary(w, httptest.NewRequest(http.MethodGet, "/api/v1/summary", nil))
+		resp := summaryResp{}
+		err := json.NewDecoder(w.Body).Decode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	expected := []string{forkChoiceSource, finalitySource, depositContractSource}
+	if resp := summary(); !reflect.DeepEqual(resp.Initializing, expected) {
+		t.Errorf("expected all sections to be initializing, got %v", resp.Initializing)
+	}
+
+	// the stub only serves the proto array so finality stays missing
+	m.initialize()
+	expected = []string{finalitySource, depositContractSource}
+	if resp := summary(); !reflect.DeepEqual(resp.Initializing, expected) {
+		t.Errorf("expected fork choice to be filled in, got %v", resp.Initializing)
+	}
+}
@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultLeaderboardWindow = 24 * time.Hour
+const defaultLeaderboardSort = "canonical"
+
+type leaderboardEntry struct {
+	Rank    int    `json:"rank"`
+	ID      string `json:"id"`
+	Label   string `json:"label,omitempty"`
+	Client  string `json:"client"`
+	Samples int    `json:"samples"`
+	// share of samples on the majority head, responding at all and the
+	// average distance of the head to the current slot
+	CanonicalPercent    float64 `json:"canonical_percent"`
+	AvailabilityPercent float64 `json:"availability_percent"`
+	AverageLag          float64 `json:"average_lag"`
+}
+
+type leaderboardResp struct {
+	WindowSeconds int                `json:"window_seconds"`
+	Sort          string             `json:"sort"`
+	Entries       []leaderboardEntry `json:"entries"`
+}
+
+// each comparator reports whether entry `a` ranks above entry `b`
+var leaderboardSorts = map[string]func(a, b *leaderboardEntry) bool{
+	"canonical":    func(a, b *leaderboardEntry) bool { return a.CanonicalPercent > b.CanonicalPercent },
+	"availability": func(a, b *leaderboardEntry) bool { return a.AvailabilityPercent > b.AvailabilityPercent },
+	"lag":          func(a, b *leaderboardEntry) bool { return a.AverageLag < b.AverageLag },
+	"id":           func(a, b *leaderboardEntry) bool { return a.ID < b.ID },
+}
+
+// scoreSamples aggregates the samples of a node, which must not be empty.
+func scoreSamples(samples []nodeSample) (canonical float64, availability float64, lag float64) {
+	canonicalCount, healthyCount, totalLag := 0, 0, 0
+	for _, sample := range samples {
+		if sample.Canonical {
+			canonicalCount++
+		}
+		if sample.Healthy {
+			healthyCount++
+		}
+		totalLag += sample.Lag
+	}
+	count := float64(len(samples))
+	return 100 * float64(canonicalCount) / count, 100 * float64(healthyCount) / count, float64(totalLag) / count
+}
+
+// rankLeaderboard orders `entries` by `sortKey`, which may be prefixed with
+// "-" to reverse the order, and numbers them.
+func rankLeaderboard(entries []leaderboardEntry, sortKey string) error {
+	descending := strings.HasPrefix(sortKey, "-")
+	less, ok := leaderboardSorts[strings.TrimPrefix(sortKey, "-")]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q", sortKey)
+	}
+	// ties keep the order of the node IDs
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	sort.SliceStable(entries, func(i, j int) bool {
+		if descending {
+			return less(&entries[j], &entries[i])
+		}
+		return less(&entries[i], &entries[j])
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return nil
+}
+
+// leaderboardWindow reads the window to rank over from `?epochs=` or a
+// duration like `?window=24h`.
+func (m *Monitor) leaderboardWindow(r *http.Request) (time.Duration, error) {
+	query := r.URL.Query()
+	if query.Get("epochs") != "" {
+		epochs, err := parsePositiveInt(query, "epochs", 1)
+		if err != nil {
+			return 0, err
+		}
+		config := m.eth2()
+		return time.Duration(epochs*config.SlotsPerEpoch*config.SecondsPerSlot) * time.Second, nil
+	}
+	raw := query.Get("window")
+	if raw == "" {
+		return defaultLeaderboardWindow, nil
+	}
+	window, err := time.ParseDuration(raw)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("window must be a positive duration like `24h`")
+	}
+	return window, nil
+}
+
+// sendLeaderboard ranks the nodes by how reliably they followed the chain
+// over the window, from the sampled node timeseries.
+func (m *Monitor) sendLeaderboard(w http.ResponseWriter, r *http.Request) {
+	window, err := m.leaderboardWindow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sortKey := r.URL.Query().Get("sort")
+	if sortKey == "" {
+		sortKey = defaultLeaderboardSort
+	}
+
+	start := time.Now().Add(-window)
+	entries := []leaderboardEntry{}
+	for _, node := range m.nodeList() {
+		samples := m.samples.since(node.id, start)
+		if len(samples) == 0 {
+			continue
+		}
+		entry := leaderboardEntry{
+			ID:      node.id,
+			Label:   node.label,
+			Client:  clientFamily(node.version),
+			Samples: len(samples),
+		}
+		entry.CanonicalPercent, entry.AvailabilityPercent, entry.AverageLag = scoreSamples(samples)
+		entries = append(entries, entry)
+	}
+	err = rankLeaderboard(entries, sortKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, r, &leaderboardResp{WindowSeconds: int(window / time.Second), Sort: sortKey, Entries: entries})
+}
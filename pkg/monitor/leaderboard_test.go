@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScoreSamples(t *testing.T) {
+	samples := []nodeSample{
+		{Lag: 0, Healthy: true, Canonical: true},
+		{Lag: 2, Healthy: true, Canonical: false},
+		{Lag: 4, Healthy: false},
+		{Lag: 2, Healthy: true, Canonical: true},
+	}
+	canonical, availability, lag := scoreSamples(samples)
+	if canonical != 50 || availability != 75 || lag != 2 {
+		t.Errorf("unexpected scores %f, %f, %f", canonical, availability, lag)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	now := time.Now()
+	m := &Monitor{
+		config:  &Config{Eth2: Eth2Config{SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		nodes:   []*Node{{id: "a", version: "teku/v1"}, {id: "b", version: "Lighthouse/v5"}, {id: "c"}},
+		samples: newSampleStore(),
+	}
+	m.samples.append("a", nodeSample{Time: now.Add(-time.Minute), Lag: 1, Healthy: true})
+	m.samples.append("a", nodeSample{Time: now, Lag: 3, Healthy: true, Canonical: true})
+	m.samples.append("b", nodeSample{Time: now.Add(-48 * time.Hour), Lag: 0, Healthy: false})
+	m.samples.append("b", nodeSample{Time: now, Lag: 0, Healthy: true, Canonical: true})
+
+	leaderboard := func(query string) leaderboardResp {
+		w := httptest.NewRecorder()
+		m.sendLeaderboard(w, httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", query, w.Code)
+		}
+		var resp leaderboardResp
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	resp := leaderboard("")
+	if len(resp.Entries) != 2 || resp.Entries[0].ID != "b" || resp.Entries[0].Rank != 1 || resp.Entries[1].CanonicalPercent != 50 {
+		t.Errorf("unexpected leaderboard %+v", resp.Entries)
+	}
+	if resp.Entries[1].Client != "teku" || resp.Entries[1].AverageLag != 2 {
+		t.Errorf("unexpected entry %+v", resp.Entries[1])
+	}
+
+	resp = leaderboard("?sort=-lag&window=72h")
+	if resp.Entries[0].ID != "a" || resp.Entries[1].AvailabilityPercent != 50 {
+		t.Errorf("unexpected leaderboard %+v", resp.Entries)
+	}
+
+	resp = leaderboard("?epochs=1&sort=availability")
+	if resp.WindowSeconds != 384 || resp.Entries[0].ID != "a" {
+		t.Errorf("expected ties to be ranked by id, got %+v", resp)
+	}
+
+	w := httptest.NewRecorder()
+	m.sendLeaderboard(w, httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard?sort=nonsense", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown sort key to be rejected, got %d", w.Code)
+	}
+}
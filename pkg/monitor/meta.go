@@ -0,0 +1,30 @@
+package monitor
+
+import "net/http"
+
+const defaultDisplayName = "eth2-fork-mon"
+
+// MetaConfig describes this instance so that several of them can be told
+// apart in shared dashboards and alerts.
+type MetaConfig struct {
+	DisplayName     string `json:"display_name" yaml:"display_name"`
+	NetworkLabel    string `json:"network_label" yaml:"network_label"`
+	OperatorContact string `json:"operator_contact" yaml:"operator_contact"`
+}
+
+// meta fills in the display name and network label if they are not configured.
+func (m *Monitor) meta() MetaConfig {
+	meta := m.config.Meta
+	if meta.DisplayName == "" {
+		meta.DisplayName = defaultDisplayName
+	}
+	if meta.NetworkLabel == "" {
+		meta.NetworkLabel = m.config.Eth2.Network
+	}
+	return meta
+}
+
+func (m *Monitor) sendMeta(w http.ResponseWriter, r *http.Request) {
+	meta := m.meta()
+	writeJSON(w, r, &meta)
+}
@@ -0,0 +1,16 @@
+package monitor
+
+import "testing"
+
+func TestMetaDefaults(t *testing.T) {
+	m := &Monitor{config: &Config{Eth2: Eth2Config{Network: "holesky"}}}
+	meta := m.meta()
+	if meta.DisplayName != defaultDisplayName || meta.NetworkLabel != "holesky" {
+		t.Errorf("unexpected defaults %+v", meta)
+	}
+
+	m.config.Meta = MetaConfig{DisplayName: "team monitor", NetworkLabel: "Holešky", OperatorContact: "ops@example.com"}
+	if meta := m.meta(); meta != m.config.Meta {
+		t.Errorf("expected configured values to win, got %+v", meta)
+	}
+}
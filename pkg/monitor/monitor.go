@@ -1,16 +1,19 @@
 package monitor
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"regexp"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -18,6 +21,7 @@ import (
 const headHeaderPath = "/eth/v1/beacon/headers/head"
 const protoArrayPath = "/lighthouse/proto_array"
 const pollingDuration = 1 * time.Second
+const depositContractPollInterval = 30 * time.Minute
 const participationEntriesCount = 20
 
 type WeakSubjectivityData struct {
@@ -28,17 +32,41 @@ type WeakSubjectivityData struct {
 }
 
 type Monitor struct {
-	config *Config
-	nodes  []*Node
+	config    *Config
+	nodes     []*Node
+	nodesLock sync.Mutex
 
-	forkChoiceSummary         *ForkChoiceNode
+	// endpoints not reachable at startup, see `startDiscovery`
+	pendingEndpoints []Endpoint
+
+	forkChoiceSummary         *forkChoiceTree
 	currentForkChoiceProvider *Node
+	forkChoiceProviders       []*Node
 	forkchoiceLock            sync.Mutex
+	divergentSlots            int
+	forkChoiceHead            *BlockRef
+	safeHead                  *BlockRef
+	reorgRisk                 *reorgRisk
+	forkChoiceCache           responseCache
+	// bumped whenever the summary changes to invalidate the cache
+	forkChoiceVersion int
+	reorgRiskHigh     bool
+	reorgRiskSince    int
 
-	participation                []Participation
 	currentParticipationProvider *Node
+	participationProviders       []*Node
+	participationForecast        *ParticipationForecast
 	participationLock            sync.Mutex
 
+	stickyProviders map[queryKind]stickyProvider
+	providerLock    sync.Mutex
+
+	// whether the pollers of each kind of provider run, see
+	// `startProviderPollers`
+	forkChoicePollersStarted    bool
+	participationPollersStarted bool
+	providerPollersLock         sync.Mutex
+
 	justifiedCheckpoint Checkpoint
 	finalizedCheckpoint Checkpoint
 
@@ -47,27 +75,133 @@ type Monitor struct {
 	weakSubjectivityData WeakSubjectivityData
 	weakSubjectivityLock sync.Mutex
 
+	adminNets []*net.IPNet
+
+	samples *sampleStore
+	alerts  *alertSet
+
+	// the genesis time after a restart of the network and the last restart,
+	// guarded by the lock of `genesis`
+	genesis          genesisClock
+	lastGenesisReset *GenesisReset
+
+	signingKey ed25519.PrivateKey
+
+	reorgs reorgLog
+	chain  chainCache
+	// the slot ledger walks further back than the chain endpoint
+	slotChain chainCache
+
+	deposits depositLog
+	balances balanceHistory
+
+	arrivals  arrivalLog
+	proposers proposerDuties
+
+	peering peeringState
+	subnets subnetSamples
+
+	sources sourceSet
+
+	finalityLatencies finalityLatencyLog
+	headStability     headStability
+	surroundRisks     surroundRiskLog
+
+	// the proto arrays fetched in the current slot
+	protoArrays protoArrayCache
+	// how well each node serves each kind of heavy query
+	providerScores providerScores
+
+	// history of heads, participation and reorgs, kept in `memory` unless
+	// another backend is set
+	storage Storage
+	memory  memoryStorage
+
+	// carries what the pollers observe to the rest of the monitor
+	events eventBus
+	// held by the head poll while it updates the heads of the nodes
+	headsLock sync.Mutex
+	// the epoch the head poll last saw, see `publishEpochsCompleted`
+	polledEpoch     *int
+	polledEpochLock sync.Mutex
+	// serializes writes of the saved state
+	stateLock sync.Mutex
+	// asks the persistence poller to save the state early
+	saveRequests chan struct{}
+
+	// orders and caps the requests to all nodes
+	fetches fetchQueue
+	// restarts background loops that stop making progress
+	pollers watchdog
+
 	errc chan error
 }
 
+// participationProvider is the node participation was last read from.
+func (m *Monitor) participationProvider() *Node {
+	m.participationLock.Lock()
+	defer m.participationLock.Unlock()
+	return m.currentParticipationProvider
+}
+
+func (m *Monitor) setParticipationProvider(provider *Node) {
+	m.participationLock.Lock()
+	defer m.participationLock.Unlock()
+	m.currentParticipationProvider = provider
+}
+
+// forkChoiceProvider is the node the fork choice is currently read from.
+func (m *Monitor) forkChoiceProvider() *Node {
+	m.forkchoiceLock.Lock()
+	defer m.forkchoiceLock.Unlock()
+	return m.currentForkChoiceProvider
+}
+
+// setForkChoiceProvider switches the fork choice to `provider` and returns
+// whether it differs from the previous one.
+func (m *Monitor) setForkChoiceProvider(provider *Node) bool {
+	m.forkchoiceLock.Lock()
+	defer m.forkchoiceLock.Unlock()
+	changed := provider != m.currentForkChoiceProvider
+	m.currentForkChoiceProvider = provider
+	return changed
+}
+
 func (m *Monitor) fetchHeads() error {
+	m.headsLock.Lock()
 	var wg sync.WaitGroup
+	provider := m.providerFor(forkChoiceQuery)
+	providerChanged := m.setForkChoiceProvider(provider)
 	lastBlockTreeHead := HeadRef{}
-	for _, node := range m.nodes {
-		wg.Add(1)
-		if node == m.currentForkChoiceProvider {
-			lastBlockTreeHead = node.latestHead
-		}
-		if node.isSyncing {
-			go node.doFetchSyncStatus()
+	if provider != nil {
+		lastBlockTreeHead = provider.latestHead
+	}
+	nodes := m.nodeList()
+	now := time.Now()
+	for _, node := range nodes {
+		// leave rate limited nodes alone until they are ready for us again
+		if node.backoff.active(now) {
+			continue
 		}
-		go node.fetchLatestHead(&wg)
+		wg.Add(1)
+		go func(node *Node) {
+			if node.isSyncing {
+				go m.fetches.run(headFetch, func() { node.doFetchSyncStatus() })
+			}
+			m.fetches.run(headFetch, func() { node.fetchLatestHead(&wg) })
+		}(node)
 	}
 
 	wg.Wait()
+	m.headsLock.Unlock()
+
+	m.publishHeads(nodes)
+	m.arrivals.observe(nodes, time.Now())
+	m.publishHealthChanges(nodes)
+	m.publishEpochsCompleted()
 
-	if m.currentForkChoiceProvider != nil {
-		if m.currentForkChoiceProvider.latestHead != lastBlockTreeHead {
+	if provider != nil {
+		if providerChanged || provider.latestHead != lastBlockTreeHead {
 			go func() {
 				err := m.buildLatestForkChoiceSummary()
 				if err != nil {
@@ -75,14 +209,10 @@ func (m *Monitor) fetchHeads() error {
 				}
 			}()
 			go func() {
-				justified, finalized, err := m.currentForkChoiceProvider.fetchFinalityCheckpoints()
+				err := m.updateFinality(m.providerFor(finalityQuery))
 				if err != nil {
 					log.Println(err)
-					return
 				}
-
-				m.justifiedCheckpoint = justified
-				m.finalizedCheckpoint = finalized
 			}()
 		}
 	}
@@ -90,41 +220,79 @@ func (m *Monitor) fetchHeads() error {
 	return nil
 }
 
-func (m *Monitor) startHeadMonitor() {
-	err := m.fetchHeads()
-	if err != nil {
-		m.errc <- err
-		return
-	}
-
-	for {
-		time.Sleep(pollingDuration)
-
+func (m *Monitor) startHeadMonitor(beat func() bool) {
+	for beat() {
 		err := m.fetchHeads()
 		if err != nil {
 			m.errc <- err
 			return
 		}
+
+		time.Sleep(pollingDuration)
 	}
 }
 
-func (m *Monitor) buildLatestForkChoiceSummary() error {
-	if m.currentForkChoiceProvider.isSyncing {
-		return m.currentForkChoiceProvider.doFetchSyncStatus()
+// updateFinality fetches the latest checkpoints from `provider`.
+func (m *Monitor) updateFinality(provider *Node) error {
+	m.fetches.acquire(finalityFetch)
+	start := time.Now()
+	justified, finalized, err := provider.fetchFinalityCheckpoints()
+	now := time.Now()
+	m.fetches.release()
+	m.providerScores.record(finalityQuery, provider.id, now.Sub(start), err, now)
+	if err != nil {
+		return err
+	}
+
+	advanced := CheckpointAdvanced{
+		Justified:         justified,
+		Finalized:         finalized,
+		FinalizedAdvanced: finalized.Epoch > m.finalizedCheckpoint.Epoch || m.finalizedCheckpoint.Root == "",
+		Source:            provider.id,
+		At:                now,
 	}
+	justifiedAdvanced := justified.Epoch > m.justifiedCheckpoint.Epoch || m.justifiedCheckpoint.Root == ""
+	m.justifiedCheckpoint = justified
+	m.finalizedCheckpoint = finalized
+	m.sources.record(finalitySource, provider.id, now)
+	if advanced.FinalizedAdvanced || justifiedAdvanced {
+		m.events.publish(advanced)
+	}
+	return nil
+}
 
-	protoArray, err := m.currentForkChoiceProvider.fetchProtoArray()
+func (m *Monitor) buildLatestForkChoiceSummary() error {
+	provider := m.forkChoiceProvider()
+	if provider.isSyncing {
+		m.fetches.acquire(forkChoiceFetch)
+		err := provider.doFetchSyncStatus()
+		m.fetches.release()
+		return err
+	}
+	protoArray, err := m.protoArrayOf(provider)
 	if err != nil {
 		return err
 	}
+	now := time.Now()
 
 	root := protoArray[0]
 	headIndex := root.BestDescendant
 	summary := computeSummary(protoArray, headIndex)
+	head := protoArrayHead(protoArray)
+	safeHead := computeSafeHead(protoArray, m.justifiedCheckpoint.Root)
 
 	m.forkchoiceLock.Lock()
-	defer m.forkchoiceLock.Unlock()
-	m.forkChoiceSummary = &summary
+	m.forkChoiceSummary = summary
+	m.forkChoiceVersion++
+	m.forkChoiceHead = &BlockRef{Slot: head.Slot, Root: head.Root}
+	m.safeHead = safeHead
+	m.forkchoiceLock.Unlock()
+
+	m.recordReorg(protoArray, provider.id)
+	m.headStability.observe(protoArray, provider.id, m.config.Eth2.SlotsPerEpoch)
+	m.updateReorgRisk(protoArray)
+	m.updateParticipationForecast(protoArray)
+	m.sources.record(forkChoiceSource, provider.id, now)
 
 	return nil
 }
@@ -132,34 +300,36 @@ func (m *Monitor) buildLatestForkChoiceSummary() error {
 // fetchLatestParticipation gets the participation data for the current complete epoch
 // NOTE: it is expensive to ask for historical data so we keep a cache of entries for the frontend
 func (m *Monitor) fetchLatestParticipation() error {
-	currentSlot := computeCurrentSlot(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot)
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
 	currentEpoch := int(currentSlot / m.config.Eth2.SlotsPerEpoch)
 	// provider only has data for the `targetEpoch` at the latest
 	targetEpoch := currentEpoch - 1
-	provider := m.currentParticipationProvider
+	provider := m.providerFor(participationQuery)
+	if provider == nil {
+		return errors.New("no participation provider available")
+	}
+	m.setParticipationProvider(provider)
+	m.fetches.acquire(participationFetch)
+	start := time.Now()
 	currentParticipation, previousParticipation, err := provider.doFetchParticipation(targetEpoch)
+	now := time.Now()
+	m.fetches.release()
+	m.providerScores.record(participationQuery, provider.id, now.Sub(start), err, now)
 	if err != nil {
 		return err
 	}
-	m.participationLock.Lock()
-	data := m.participation
-	if len(data) != 0 {
-		if data[len(data)-1].Epoch == previousParticipation.Epoch {
-			data[len(data)-1] = previousParticipation
-		} else {
-			data = append(data, previousParticipation)
-		}
-	} else {
-		data = append(data, previousParticipation)
+	// by now epoch processing has run for the previous epoch
+	if m.justifiedCheckpoint.Root != "" {
+		justified := m.justifiedCheckpoint.Epoch >= previousParticipation.Epoch
+		previousParticipation.Justified = &justified
 	}
-	data = append(data, currentParticipation)
 
-	if len(data) > participationEntriesCount {
-		data = data[1:]
+	// replaces any earlier (possibly incomplete or restored) entries for these epochs
+	err = m.store().SaveParticipation(previousParticipation, currentParticipation)
+	if err != nil {
+		return err
 	}
-
-	m.participation = data
-	m.participationLock.Unlock()
+	m.sources.record(participationSource, provider.id, now)
 	return nil
 }
 
@@ -168,7 +338,7 @@ func (m *Monitor) sendSpec(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	enc := json.NewEncoder(w)
-	err := enc.Encode(m.config.Eth2)
+	err := enc.Encode(m.eth2())
 	if err != nil {
 		log.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -177,63 +347,99 @@ func (m *Monitor) sendSpec(w http.ResponseWriter, r *http.Request) {
 }
 
 type nodeResp struct {
-	ID      string `json:"id"`
-	Eth1    string `json:"eth1"`
-	Version string `json:"version"`
-	Slot    string `json:"slot"`
-	Root    string `json:"root"`
-	Healthy bool   `json:"healthy"`
-	Syncing *bool  `json:"syncing"`
+	ID      string     `json:"id"`
+	Eth1    string     `json:"eth1"`
+	Version string     `json:"version"`
+	Client  string     `json:"client"`
+	Label   string     `json:"label,omitempty"`
+	Slot    int        `json:"slot,string"`
+	Root    string     `json:"root"`
+	Lag     int        `json:"lag"`
+	Healthy bool       `json:"healthy"`
+	Status  NodeStatus `json:"status"`
+	Syncing *bool      `json:"syncing"`
+
+	SyncProgress *syncProgress `json:"sync_progress,omitempty"`
+
+	// requests sent to the node over the last minute and its budget, if any
+	RequestsPerMinute int `json:"requests_per_minute"`
+	RequestBudget     int `json:"request_budget,omitempty"`
 }
 
 type monitorResp struct {
-	Nodes     []nodeResp `json:"nodes"`
-	Justified Checkpoint `json:"justified_checkpoint"`
-	Finalized Checkpoint `json:"finalized_checkpoint"`
+	Nodes      []nodeResp `json:"nodes"`
+	Pagination *pageInfo  `json:"pagination,omitempty"`
+	Head       *BlockRef  `json:"head,omitempty"`
+	SafeHead   *BlockRef  `json:"safe_head,omitempty"`
+	Justified  Checkpoint `json:"justified_checkpoint"`
+	Finalized  Checkpoint `json:"finalized_checkpoint"`
+	// sections still waiting for their first fetch
+	Initializing []string `json:"initializing"`
+}
+
+func (m *Monitor) nodeResponse(node *Node, currentSlot int) nodeResp {
+	response := nodeResp{
+		ID:      node.id,
+		Eth1:    node.eth1,
+		Version: node.version,
+		Client:  clientFamily(node.version),
+		Label:   node.label,
+		Slot:    node.latestHead.slot,
+		Root:    node.latestHead.root,
+		Healthy: node.isHealthy,
+		Status:  m.nodeStatus(node),
+		Syncing: &node.isSyncing,
+	}
+	if response.Root != "" {
+		response.Lag = currentSlot - response.Slot
+	}
+	response.RequestsPerMinute, response.RequestBudget = node.requests.usage(time.Now())
+	if node.isSyncing {
+		response.SyncProgress = node.sync.progress()
+	}
+	if isPrysm(response.Version) {
+		response.Syncing = nil
+	}
+	if isNimbus(response.Version) {
+		response.Syncing = nil
+	}
+	return response
 }
 
 func (m *Monitor) sendMonitorState(w http.ResponseWriter, r *http.Request) {
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
 	var nodes []nodeResp
-	for _, node := range m.nodes {
-		response := nodeResp{
-			ID:      node.id,
-			Eth1:    node.eth1,
-			Version: node.version,
-			Slot:    node.latestHead.slot,
-			Root:    node.latestHead.root,
-			Healthy: node.isHealthy,
-			Syncing: &node.isSyncing,
-		}
-		if isPrysm(response.Version) {
-			response.Syncing = nil
-		}
-		if isNimbus(response.Version) {
-			response.Syncing = nil
-		}
-		nodes = append(nodes, response)
+	for _, node := range m.nodeList() {
+		nodes = append(nodes, m.nodeResponse(node, currentSlot))
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	nodes, pagination, err := applyNodeQuery(nodes, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.forkchoiceLock.Lock()
+	head, safeHead := m.forkChoiceHead, m.safeHead
+	m.forkchoiceLock.Unlock()
 
 	resp := monitorResp{
-		Nodes:     nodes,
-		Justified: m.justifiedCheckpoint,
-		Finalized: m.finalizedCheckpoint,
-	}
+		Nodes:      nodes,
+		Pagination: pagination,
+		Head:       head,
+		SafeHead:   safeHead,
+		Justified:  m.justifiedCheckpoint,
+		Finalized:  m.finalizedCheckpoint,
 
-	enc := json.NewEncoder(w)
-	err := enc.Encode(&resp)
-	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		Initializing: m.initializingSections(),
 	}
+
+	writeJSON(w, r, &resp)
 }
 
 type ForkChoiceNode struct {
 	Children    []ForkChoiceNode `json:"children"`
-	Slot        string           `json:"slot"`
+	Slot        int              `json:"slot,string"`
 	Root        string           `json:"root"`
 	Weight      float64          `json:"weight"`
 	IsCanonical bool             `json:"is_canonical"`
@@ -247,7 +453,9 @@ func computeCurrentSlot(genesisTime int, secondsPerSlot int) int {
 	return int(secondsSinceGenesis / int64(secondsPerSlot))
 }
 
-func pruneForBrowser(node ForkChoiceNode, genesisTime int, slotsPerEpoch int, secondsPerSlot int) ForkChoiceNode {
+// pruneForBrowser returns the index of the canonical block the tree sent to
+// the browser starts at, dropping all but the last few epochs.
+func pruneForBrowser(tree *forkChoiceTree, genesisTime int, slotsPerEpoch int, secondsPerSlot int) int {
 	currentSlot := computeCurrentSlot(genesisTime, secondsPerSlot)
 	currentEpoch := int(currentSlot / slotsPerEpoch)
 	targetEpoch := currentEpoch - epochsToSend
@@ -256,33 +464,28 @@ func pruneForBrowser(node ForkChoiceNode, genesisTime int, slotsPerEpoch int, se
 	}
 
 	targetSlot := targetEpoch * slotsPerEpoch
-	slot, err := strconv.Atoi(node.Slot)
-	if err != nil {
-		log.Println(err)
-		return node
-	}
-	for slot < targetSlot {
-		if len(node.Children) == 0 {
-			return node
-		}
-		for _, child := range node.Children {
-			if child.IsCanonical {
-				node = child
-				slot, err = strconv.Atoi(node.Slot)
-				if err != nil {
-					log.Println(err)
-					return node
-				}
-				break
-			}
-		}
-	}
-
-	return node
+	return tree.canonicalIndexAt(targetSlot)
 }
 
+// forkChoiceResponse is sent after the `block_tree`, which is streamed
+// separately as it can be large.
 type forkChoiceResponse struct {
-	BlockTree ForkChoiceNode `json:"block_tree"`
+	ReorgRisk    *reorgRisk  `json:"reorg_risk"`
+	Source       *Provenance `json:"source"`
+	Initializing bool        `json:"initializing"`
+}
+
+// blockTreeJSON returns the encoded block tree for the browser, shared by
+// all requests until the tree or the pruning point changes.
+func (m *Monitor) blockTreeJSON(tree *forkChoiceTree, version int) ([]byte, error) {
+	if tree == nil {
+		return json.Marshal(ForkChoiceNode{})
+	}
+	index := pruneForBrowser(tree, m.genesisTime(), m.config.Eth2.SlotsPerEpoch, m.config.Eth2.SecondsPerSlot)
+	key := fmt.Sprintf("%d/%d", version, index)
+	return m.forkChoiceCache.get(key, func(w io.Writer) error {
+		return tree.encodeJSON(w, index)
+	})
 }
 
 func (m *Monitor) sendForkChoice(w http.ResponseWriter, r *http.Request) {
@@ -291,47 +494,70 @@ func (m *Monitor) sendForkChoice(w http.ResponseWriter, r *http.Request) {
 
 	m.forkchoiceLock.Lock()
 	forkChoiceSummary := m.forkChoiceSummary
+	version := m.forkChoiceVersion
+	risk := m.reorgRisk
 	m.forkchoiceLock.Unlock()
 
-	resp := forkChoiceResponse{}
-	if forkChoiceSummary != nil {
-		forkChoiceForBrowser := pruneForBrowser(*forkChoiceSummary, m.config.Eth2.GenesisTime, m.config.Eth2.SlotsPerEpoch, m.config.Eth2.SecondsPerSlot)
-		resp.BlockTree = forkChoiceForBrowser
+	blockTree, err := m.blockTreeJSON(forkChoiceSummary, version)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
-
-	enc := json.NewEncoder(w)
-	err := enc.Encode(resp)
+	resp := forkChoiceResponse{ReorgRisk: risk, Source: m.sources.get(forkChoiceSource), Initializing: m.isInitializing(forkChoiceSource)}
+	rest, err := json.Marshal(resp)
 	if err != nil {
 		log.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-}
 
-type Participation struct {
-	Epoch             int      `json:"epoch"`
-	ParticipationRate float64  `json:"participation_rate"`
-	JustificationRate float64  `json:"justification_rate"`
-	HeadRate          *float64 `json:"head_rate"`
+	// splice the block tree in as the first field of the response
+	w.Write([]byte(`{"block_tree":`))
+	err = writeChunked(w, blockTree)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	rest[0] = ','
+	w.Write(append(rest, '\n'))
 }
 
 type participationResponse struct {
 	Data []Participation `json:"data"`
+	// projection for the current, incomplete epoch
+	Forecast     *ParticipationForecast `json:"forecast"`
+	Source       *Provenance            `json:"source"`
+	Initializing bool                   `json:"initializing"`
 }
 
 func (m *Monitor) sendParticipationData(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	data := m.participationHistory()
 	m.participationLock.Lock()
-	data := make([]Participation, len(m.participation))
-	copy(data, m.participation)
+	forecast := m.participationForecast
 	m.participationLock.Unlock()
 
+	withGwei := r.URL.Query().Get("gwei") == "true"
+	for i := range data {
+		if !withGwei {
+			data[i].Gwei = nil
+		}
+		data[i].BadEpoch = len(badEpochReasons(data[i])) > 0
+	}
+
 	sort.Slice(data, func(i, j int) bool { return data[i].Epoch > data[j].Epoch })
+	if len(data) > participationEntriesCount {
+		data = data[:participationEntriesCount]
+	}
 
 	resp := participationResponse{
-		Data: data,
+		Data:         data,
+		Forecast:     forecast,
+		Source:       m.sources.get(participationSource),
+		Initializing: m.isInitializing(participationSource),
 	}
 
 	enc := json.NewEncoder(w)
@@ -343,13 +569,21 @@ func (m *Monitor) sendParticipationData(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+type depositContractResponse struct {
+	Balance      int         `json:"balance"`
+	Source       *Provenance `json:"source"`
+	Initializing bool        `json:"initializing"`
+}
+
 func (m *Monitor) sendDepositContractData(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	resp := make(map[string]int)
-
-	resp["balance"] = m.depositContractBalance
+	resp := depositContractResponse{
+		Balance:      m.depositContractBalance,
+		Source:       m.sources.get(depositContractSource),
+		Initializing: m.isInitializing(depositContractSource),
+	}
 
 	enc := json.NewEncoder(w)
 	err := enc.Encode(resp)
@@ -360,37 +594,89 @@ func (m *Monitor) sendDepositContractData(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (m *Monitor) sendWSData(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+var cssFile = regexp.MustCompile(".css$")
 
-	m.weakSubjectivityLock.Lock()
-	data := m.weakSubjectivityData
-	m.weakSubjectivityLock.Unlock()
+func (m *Monitor) serveAPI() {
+	mux := http.NewServeMux()
 
-	enc := json.NewEncoder(w)
-	err := enc.Encode(&data)
-	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-}
+	mux.HandleFunc("/spec", m.withAuth(m.sendSpec))
 
-var cssFile = regexp.MustCompile(".css$")
+	mux.HandleFunc("/meta", m.withAuth(m.sendMeta))
 
-func (m *Monitor) serveAPI() {
-	http.HandleFunc("/spec", m.sendSpec)
+	mux.HandleFunc("/api/v1/signing-key", m.withAuth(m.sendSigningKey))
+
+	mux.HandleFunc("/chain-monitor", m.withAuth(m.sendMonitorState))
+
+	mux.HandleFunc("/fork-choice", m.withAuth(m.sendForkChoice))
+
+	mux.HandleFunc("/participation", m.withAuth(m.sendParticipationData))
+
+	mux.HandleFunc("/deposit-contract", m.withAuth(m.sendDepositContractData))
+
+	mux.HandleFunc("/api/v1/deposits/events", m.withAuth(m.sendDepositEvents))
+
+	mux.HandleFunc("/api/v1/balances", m.withAuth(m.sendBalances))
+
+	mux.HandleFunc("/api/v1/peering", m.withAuth(m.sendPeering))
+
+	mux.HandleFunc("/api/v1/subnets", m.withAuth(m.sendSubnets))
 
-	http.HandleFunc("/chain-monitor", m.sendMonitorState)
+	mux.HandleFunc("/ws-data", m.withAuth(m.sendWSData))
 
-	http.HandleFunc("/fork-choice", m.sendForkChoice)
+	mux.HandleFunc("/api/v1/summary", m.withAuth(m.sendSummary))
 
-	http.HandleFunc("/participation", m.sendParticipationData)
+	mux.HandleFunc("/api/v1/clients", m.withAuth(m.sendClients))
 
-	http.HandleFunc("/deposit-contract", m.sendDepositContractData)
+	mux.HandleFunc("/api/v1/leaderboard", m.withAuth(m.sendLeaderboard))
 
-	http.HandleFunc("/ws-data", m.sendWSData)
+	mux.HandleFunc("/api/v1/providers", m.withAuth(m.sendProviderScores))
+
+	mux.HandleFunc("/api/v1/nodes/", m.withAuth(m.sendNodeAPI))
+
+	mux.HandleFunc("/api/v1/store/stats", m.withAuth(m.sendStoreStats))
+
+	mux.HandleFunc("/api/v1/alerts", m.withAuth(m.sendAlerts))
+
+	mux.HandleFunc("/api/v1/pollers", m.withAuth(m.sendPollers))
+
+	mux.HandleFunc("/api/v1/config", m.withAuth(m.sendConfig))
+
+	mux.HandleFunc("/api/v1/clock", m.withAuth(m.sendClock))
+
+	mux.HandleFunc("/api/v1/upcoming", m.withAuth(m.sendUpcoming))
+
+	mux.HandleFunc("/api/v1/reorgs", m.withAuth(m.sendReorgs))
+
+	mux.HandleFunc("/api/v1/finality/latency", m.withAuth(m.sendFinalityLatency))
+
+	mux.HandleFunc("/api/v1/head-stability", m.withAuth(m.sendHeadStability))
+
+	mux.HandleFunc("/api/v1/surround-risk", m.withAuth(m.sendSurroundRisk))
+
+	mux.HandleFunc("/api/v1/proto-array/snapshots", m.withAuth(m.sendProtoArraySnapshots))
+	mux.HandleFunc("/api/v1/proto-array/snapshots/", m.withAuth(m.sendProtoArraySnapshots))
+
+	mux.HandleFunc("/api/v1/timeline", m.withAuth(m.sendTimeline))
+
+	mux.HandleFunc("/api/v1/chain", m.withAuth(m.sendChain))
+
+	mux.HandleFunc("/api/v1/slots", m.withAuth(m.sendSlots))
+
+	mux.HandleFunc("/api/v1/block/", m.withAuth(m.sendBlock))
+
+	mux.HandleFunc("/api/v1/checkpoint/", m.withAuth(m.sendCheckpoint))
+
+	mux.HandleFunc("/widget/", m.withAuth(m.sendWidget))
+
+	mux.HandleFunc("/api/v1/bad-epochs", m.withAuth(m.sendBadEpochs))
+
+	mux.HandleFunc("/api/v1/epochs/", m.withAuth(m.sendEpochReport))
+
+	mux.HandleFunc("/debug/pprof/", m.withAdmin(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", m.withAdmin(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", m.withAdmin(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", m.withAdmin(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", m.withAdmin(pprof.Trace))
 
 	clientServer := http.FileServer(http.Dir(m.config.OutputDir))
 	clientServerWithMimeType := func(w http.ResponseWriter, r *http.Request) {
@@ -399,10 +685,10 @@ func (m *Monitor) serveAPI() {
 		}
 		clientServer.ServeHTTP(w, r)
 	}
-	http.HandleFunc("/", clientServerWithMimeType)
+	mux.HandleFunc("/", clientServerWithMimeType)
 
 	log.Println("listening on port 8080...")
-	m.errc <- http.ListenAndServe(":8080", nil)
+	m.errc <- http.ListenAndServe(":8080", m.withReadOnly(mux))
 }
 
 func waitUntilNextSlot(genesisTime int, secondsPerSlot int) {
@@ -428,38 +714,33 @@ func waitUntilNextEpoch(genesisTime int, secondsPerSlot int, slotsPerEpoch int)
 	time.Sleep(time.Duration(duration) * time.Second)
 }
 
-func (m *Monitor) startParticipationPoll() {
-	err := m.fetchLatestParticipation()
-	if err != nil {
-		m.errc <- err
-		return
-	}
-
-	config := m.config.Eth2
+func (m *Monitor) startParticipationPoll(beat func() bool) {
+	config := m.eth2()
 	secondsPerEpoch := config.SecondsPerSlot * config.SlotsPerEpoch
-	for {
-		time.Sleep(time.Duration(secondsPerEpoch) * time.Second)
-
+	for beat() {
 		err := m.fetchLatestParticipation()
 		if err != nil {
 			m.errc <- err
 			return
 		}
+
+		time.Sleep(time.Duration(secondsPerEpoch) * time.Second)
 	}
 }
 
-const depositContractBalanceURLFmt = "https://api.etherscan.io/api?module=account&action=balance&address=0x00000000219ab540356cBB839Cbe05303d7705Fa&tag=latest&apikey=%s"
+// takes the chain id, the deposit contract address and the API key
+const depositContractBalanceURLFmt = "https://api.etherscan.io/v2/api?chainid=%d&module=account&action=balance&address=%s&tag=latest&apikey=%s"
 
 func (m *Monitor) updateDepositContractBalance() {
-	url := fmt.Sprintf(depositContractBalanceURLFmt, m.config.EtherscanAPIKey)
+	config := m.eth2()
+	url := fmt.Sprintf(depositContractBalanceURLFmt, config.DepositChainID, config.DepositContractAddress, m.config.EtherscanAPIKey)
 	resp, err := http.Get(url)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 	data := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponse(resp, &data)
 	if err != nil {
 		return
 	}
@@ -477,19 +758,19 @@ func (m *Monitor) updateDepositContractBalance() {
 	roundedBalance := int(balance / math.Pow(10, 18))
 
 	m.depositContractBalance = roundedBalance
+	m.sources.record(depositContractSource, etherscanSource, time.Now())
 }
 
-func (m *Monitor) startDepositContractMonitor() {
-	m.updateDepositContractBalance()
-	for {
-		time.Sleep(30 * time.Minute)
-
+func (m *Monitor) startDepositContractMonitor(beat func() bool) {
+	for beat() {
 		m.updateDepositContractBalance()
+
+		time.Sleep(depositContractPollInterval)
 	}
 }
 
 func (m *Monitor) getCurrentEpoch() int {
-	currentSlot := computeCurrentSlot(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot)
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
 	return int(currentSlot / m.config.Eth2.SlotsPerEpoch)
 }
 
@@ -502,8 +783,7 @@ func (m *Monitor) updateWSData() error {
 	defer resp.Body.Close()
 
 	data := WeakSubjectivityData{}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponse(resp, &data)
 	if err != nil {
 		return err
 	}
@@ -515,60 +795,117 @@ func (m *Monitor) updateWSData() error {
 	m.weakSubjectivityLock.Lock()
 	m.weakSubjectivityData = data
 	m.weakSubjectivityLock.Unlock()
+	m.sources.record(weakSubjectivitySource, endpoint, time.Now())
+
+	m.checkWSExpiry(data)
 	return nil
 }
 
-func (m *Monitor) startWSProviderMonitor() {
-	err := m.updateWSData()
-	if err != nil {
-		log.Println(err)
-	}
-	for {
-		waitUntilNextEpoch(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot, m.config.Eth2.SlotsPerEpoch)
-
-		err = m.updateWSData()
+func (m *Monitor) startWSProviderMonitor(beat func() bool) {
+	for beat() {
+		err := m.updateWSData()
 		if err != nil {
 			log.Println(err)
 		}
+
+		waitUntilNextEpoch(m.genesisTime(), m.config.Eth2.SecondsPerSlot, m.config.Eth2.SlotsPerEpoch)
 	}
 }
 
+// Start kicks off all background fetches. It returns right away so the API can
+// be served while the data is filled in, see `initializingSections`.
 func (m *Monitor) Start() error {
-	go func() {
+	config := m.eth2()
+	slot := time.Duration(config.SecondsPerSlot) * time.Second
+	epoch := time.Duration(config.SlotsPerEpoch) * slot
+
+	go m.initialize()
+	m.pollers.supervise("heads", pollingDuration, func(beat func() bool) {
 		log.Println("synchronizing to next slot")
-		waitUntilNextSlot(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot)
+		waitUntilNextSlot(config.GenesisTime, config.SecondsPerSlot)
 		log.Println("aligned to slot, continuting")
-		m.startHeadMonitor()
-	}()
-	go func() {
-		if m.currentForkChoiceProvider != nil {
-			log.Println("starting participation monitor")
-			err := m.fetchLatestParticipation()
-			if err != nil {
-				log.Println(err)
-			}
-			waitUntilNextEpoch(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot, m.config.Eth2.SlotsPerEpoch)
-			m.startParticipationPoll()
-		}
-	}()
-	go func() {
-		if m.config.EtherscanAPIKey != "" {
+		m.startHeadMonitor(beat)
+	})
+	m.pollers.supervise("sampler", m.timeseriesResolution(), m.startSampler)
+	m.pollers.supervise("pruner", retentionPruneInterval, m.startPruner)
+	if len(m.pendingEndpoints) > 0 {
+		log.Printf("retrying %d unreachable endpoints in the background", len(m.pendingEndpoints))
+		go m.startDiscovery(m.pendingEndpoints)
+	}
+	if m.config.DataDir != "" {
+		m.pollers.supervise("persistence", persistenceInterval, m.startPersistence)
+	}
+	if m.config.watchesGenesis() {
+		log.Println("starting genesis monitor")
+		m.pollers.supervise("genesis", genesisCheckInterval, m.startGenesisMonitor)
+	}
+	m.startProviderPollers()
+	if m.config.EtherscanAPIKey != "" {
+		if m.config.Eth2.DepositContractAddress == "" || m.config.Eth2.DepositChainID == 0 {
+			log.Println("warn: deposit contract balance requires `deposit_contract_address` and `deposit_chain_id` for this network")
+		} else {
 			log.Println("starting deposit contract monitor")
-			m.startDepositContractMonitor()
+			m.pollers.supervise("deposit_contract", depositContractPollInterval, m.startDepositContractMonitor)
 		}
-	}()
-	go func() {
-		if m.config.WSProviderEndpoint != "" {
-			log.Println("starting weak subjectivity provider monitor")
-			err := m.updateWSData()
+	}
+	if m.config.ProtoArraySnapshots && m.config.DataDir == "" {
+		log.Println("warn: proto array snapshots require `data_dir`")
+	}
+	if len(m.nodeList())+len(m.pendingEndpoints) > 1 {
+		log.Println("starting peering monitor")
+		m.pollers.supervise("peering", epoch, m.startPeeringMonitor)
+	}
+	if m.config.hasMetricsEndpoints() {
+		log.Println("starting subnet monitor")
+		m.pollers.supervise("subnets", epoch, m.startSubnetMonitor)
+	}
+	if m.config.TrackBalances {
+		log.Println("starting balance monitor")
+		m.pollers.supervise("balances", epoch, m.startBalanceMonitor)
+	}
+	if m.config.ExecutionEndpoint != "" {
+		log.Println("starting deposit log ingestion")
+		m.pollers.supervise("deposit_logs", depositIngestionInterval, m.startDepositLogIngestion)
+	}
+	if m.config.WSProviderEndpoint != "" {
+		log.Println("starting weak subjectivity provider monitor")
+		m.pollers.supervise("weak_subjectivity", epoch, m.startWSProviderMonitor)
+	}
+	go m.startWatchdog()
+	return nil
+}
+
+// startProviderPollers starts the pollers that need a fork choice or
+// participation provider, once a node that can serve them is known. Start
+// calls it and so does discovery whenever it adds nodes late.
+func (m *Monitor) startProviderPollers() {
+	config := m.eth2()
+	slot := time.Duration(config.SecondsPerSlot) * time.Second
+	epoch := time.Duration(config.SlotsPerEpoch) * slot
+
+	m.providerPollersLock.Lock()
+	defer m.providerPollersLock.Unlock()
+	if !m.forkChoicePollersStarted && len(m.candidatesFor(forkChoiceQuery)) > 0 {
+		m.forkChoicePollersStarted = true
+		log.Println("starting fork choice sanity check")
+		m.pollers.supervise("fork_choice_sanity", slot, m.startForkChoiceSanityCheck)
+		if m.config.ProtoArraySnapshots && m.config.DataDir != "" {
+			log.Println("starting proto array snapshots")
+			m.pollers.supervise("proto_array_snapshots", epoch, m.startProtoArraySnapshots)
+		}
+	}
+	if !m.participationPollersStarted && len(m.candidatesFor(participationQuery)) > 0 {
+		m.participationPollersStarted = true
+		log.Println("starting participation monitor")
+		m.pollers.supervise("participation", epoch, func(beat func() bool) {
+			err := m.fetchLatestParticipation()
 			if err != nil {
 				log.Println(err)
 			}
-			waitUntilNextEpoch(m.config.Eth2.GenesisTime, m.config.Eth2.SecondsPerSlot, m.config.Eth2.SlotsPerEpoch)
-			m.startWSProviderMonitor()
-		}
-	}()
-	return nil
+			waitUntilNextEpoch(config.GenesisTime, config.SecondsPerSlot, config.SlotsPerEpoch)
+			m.startParticipationPoll(beat)
+		})
+	}
 }
 
 func (m *Monitor) Serve() error {
@@ -576,58 +913,80 @@ func (m *Monitor) Serve() error {
 	return <-m.errc
 }
 
-func FromConfig(config *Config) *Monitor {
-	var nodes []*Node
-	var forkChoiceProvider *Node
-	var participationProvider *Node
-	for _, endpoint := range config.Endpoints {
-		node, err := nodeAtEndpoint(endpoint.Addr, endpoint.Eth1, time.Duration(config.MillisecondsTimeout))
+// FromConfig discovers the configured nodes and sets up the monitor. A
+// signing key that is configured but cannot be loaded is an error rather
+// than serving unsigned responses.
+func FromConfig(config *Config) (*Monitor, error) {
+	var signingKey ed25519.PrivateKey
+	if config.SigningKeyFile != "" {
+		var err error
+		signingKey, err = loadSigningKey(config.SigningKeyFile)
 		if err != nil {
-			log.Println(err)
-			continue
+			return nil, err
 		}
+	}
 
-		err = node.doFetchLatestHead()
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		node.isHealthy = true
-		if strings.Contains(node.version, "Lighthouse") {
-			forkChoiceProvider = node
-			participationProvider = node
+	registerSecrets(config)
+	redactLogs()
+	for _, endpoint := range config.Endpoints {
+		if endpoint.TLS.InsecureSkipVerify {
+			log.Printf("warn: not verifying the TLS certificate of %s", redactURL(endpoint.Addr))
 		}
-		nodes = append(nodes, node)
 	}
+	nodes, pendingEndpoints := discoverNodes(config.Endpoints, config.MillisecondsTimeout)
 
-	m := &Monitor{config: config, nodes: nodes, currentForkChoiceProvider: forkChoiceProvider, currentParticipationProvider: participationProvider, errc: make(chan error)}
+	var forkChoiceProvider *Node
+	forkChoiceProviders := selectProviders(nodes, func(e Endpoint) *bool { return e.ForkChoice })
+	if len(forkChoiceProviders) > 0 {
+		forkChoiceProvider = forkChoiceProviders[0]
+	}
+	var participationProvider *Node
+	participationProviders := selectProviders(nodes, func(e Endpoint) *bool { return e.Participation })
+	if len(participationProviders) > 0 {
+		participationProvider = participationProviders[0]
+	}
 
-	if m.currentForkChoiceProvider == nil {
-		log.Println("warn: no lighthouse node provided so fork choice endpoint will be empty (requires lighthouse protoarray)")
-	} else {
-		err := m.buildLatestForkChoiceSummary()
-		if err != nil {
-			log.Println(err)
-			forkChoiceProvider = nil
-		}
-		justified, finalized, err := m.currentForkChoiceProvider.fetchFinalityCheckpoints()
-		if err != nil {
-			log.Println(err)
+	m := &Monitor{config: config, nodes: nodes, currentForkChoiceProvider: forkChoiceProvider, forkChoiceProviders: forkChoiceProviders, currentParticipationProvider: participationProvider, participationProviders: participationProviders, pendingEndpoints: pendingEndpoints, saveRequests: make(chan struct{}, 1), samples: newSampleStore(), alerts: newAlertSet(), errc: make(chan error)}
+	m.subscribeConsumers()
+
+	if config.watchesGenesis() {
+		if config.Eth2.GenesisTime == 0 {
+			genesisTime, ok := m.observedGenesisTime()
+			if !ok {
+				log.Println("warn: could not learn genesis time from any node")
+			}
+			config.Eth2.GenesisTime = genesisTime
 		} else {
-			m.justifiedCheckpoint = justified
-			m.finalizedCheckpoint = finalized
+			m.checkGenesis()
 		}
 	}
 
-	if m.currentParticipationProvider == nil {
-		log.Println("warn: no lighthouse node provided so participation endpoint will be empty (requires lighthouse protoarray)")
-	} else {
-		err := m.fetchLatestParticipation()
+	adminCIDRs := config.AdminAllowedCIDRs
+	if len(adminCIDRs) == 0 {
+		adminCIDRs = defaultAdminCIDRs
+	}
+	adminNets, err := parseCIDRs(adminCIDRs)
+	if err != nil {
+		log.Println(err)
+		log.Println("warn: admin routes disabled due to invalid `admin_allowed_cidrs`")
+	}
+	m.adminNets = adminNets
+	m.fetches.setLimit(m.maxConcurrentRequests())
+	m.signingKey = signingKey
+
+	if config.DataDir != "" {
+		err := m.restoreState()
 		if err != nil {
 			log.Println(err)
-			participationProvider = nil
 		}
 	}
 
-	return m
+	if m.forkChoiceProvider() == nil {
+		log.Println("warn: no fork choice provider (e.g. lighthouse node) available so fork choice endpoint will be empty (requires lighthouse protoarray)")
+	}
+	if m.participationProvider() == nil {
+		log.Println("warn: no participation provider (e.g. lighthouse node) available so participation endpoint will be empty (requires lighthouse validator inclusion API)")
+	}
+
+	return m, nil
 }
@@ -7,7 +7,6 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -22,14 +21,15 @@ const nodeIdentityPath = "/eth/v1/node/identity"
 const nodeSyncingPath = "/eth/v1/node/syncing"
 const finalityCheckpointsPath = "/eth/v1/beacon/states/head/finality_checkpoints"
 const participationPathFmt = "/lighthouse/validator_inclusion/%d/global"
+const genesisPath = "/eth/v1/beacon/genesis"
 
 type HeadRef struct {
-	slot string
+	slot int
 	root string
 }
 
 func (h HeadRef) String() string {
-	return fmt.Sprintf("(%s, %s)", h.slot, h.root)
+	return fmt.Sprintf("(%d, %s)", h.slot, h.root)
 }
 
 // Return some descriptor unique to the peer.
@@ -42,8 +42,12 @@ func idHashOf(peerID string) string {
 	return hex.EncodeToString(digest)[:8]
 }
 
-func nodeAtEndpoint(endpoint string, eth1 string, msHTTPTimeout time.Duration) (*Node, error) {
-	n := &Node{endpoint: endpoint, eth1: eth1}
+func nodeAtEndpoint(endpoint string, eth1 string, transport http.RoundTripper, msHTTPTimeout time.Duration) (*Node, error) {
+	n := &Node{endpoint: endpoint, addr: redactURL(endpoint), eth1: eth1}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	n.useTransport(transport)
 
 	// set timeout for all HTTP requests...
 	// in particular, Prysm endpoint can be slow...
@@ -112,8 +116,7 @@ func nodeAtEndpoint(endpoint string, eth1 string, msHTTPTimeout time.Duration) (
 
 	defer resp.Body.Close()
 	clientResp := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&clientResp)
+	err = decodeResponse(resp, &clientResp)
 	if err != nil {
 		return nil, err
 	}
@@ -133,8 +136,7 @@ func nodeAtEndpoint(endpoint string, eth1 string, msHTTPTimeout time.Duration) (
 	}
 	defer identityResp.Body.Close()
 	identityData := make(map[string]interface{})
-	dec = json.NewDecoder(identityResp.Body)
-	err = dec.Decode(&identityData)
+	err = decodeResponse(identityResp, &identityData)
 	if err != nil {
 		return nil, err
 	}
@@ -142,25 +144,26 @@ func nodeAtEndpoint(endpoint string, eth1 string, msHTTPTimeout time.Duration) (
 	if !ok {
 		return nil, fmt.Errorf("data not a map or missing")
 	}
-	peerID, ok := inner["peer_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("peer id not a string")
+	identity, err := parseNodeIdentity(inner)
+	if err != nil {
+		return nil, err
 	}
-	n.id = idHashOf(peerID)
+	n.identity = identity
+	n.id = idHashOf(identity.PeerID)
 
 	err = n.doFetchSyncStatus()
 	return n, err
 }
 
-func (n *Node) doFetchSyncStatus() error {
+func (n *Node) doFetchSyncStatus() (err error) {
+	defer n.recordError(&err)
 	syncResp, err := n.client.Get(n.endpoint + nodeSyncingPath)
 	if err != nil {
 		return err
 	}
 	defer syncResp.Body.Close()
 	syncData := make(map[string]interface{})
-	dec := json.NewDecoder(syncResp.Body)
-	err = dec.Decode(&syncData)
+	err = decodeResponse(syncResp, &syncData)
 	if err != nil {
 		return err
 	}
@@ -182,24 +185,67 @@ func (n *Node) doFetchSyncStatus() error {
 		return err
 	}
 	n.isSyncing = syncDistance > 1
+
+	headSlot := 0
+	if headSlotStr, ok := inner["head_slot"].(string); ok {
+		headSlot, _ = strconv.Atoi(headSlotStr)
+	}
+	n.sync.observe(headSlot, syncDistance, time.Now())
 	return nil
 }
 
 type Node struct {
-	id       string
-	eth1     string
+	id   string
+	eth1 string
+	// base URL for requests, without credentials
 	endpoint string
+	// the endpoint as shown in logs and the API
+	addr     string
 	version  string
+	label    string
+	config   Endpoint
+	identity NodeIdentity
 
 	latestHead HeadRef
 	isHealthy  bool // node responding?
 	isSyncing  bool
+	sync       syncTracker
+
+	// outcome of the last head fetch and when the head last changed
+	status NodeStatus
+	// the status and head last published to the event bus
+	publishedStatus NodeStatus
+	publishedHeadAt time.Time
+	headUpdatedAt   time.Time
+	lastError       *NodeError
+	errorLock       sync.Mutex
+	backoff         backoff
+
+	// consecutive checks where the fork choice and headers heads differ
+	headMismatchSlots int
+
+	// requests sent to the node, limited by its `requests_per_minute`
+	requests requestBudget
 
 	client http.Client
 }
 
 func (n *Node) String() string {
-	return fmt.Sprintf("[healthy: %t] %s - %s at %s has head %s", n.isHealthy, n.eth1, n.version, n.endpoint, n.latestHead)
+	return fmt.Sprintf("[healthy: %t] %s - %s at %s has head %s", n.isHealthy, n.eth1, n.version, n.addr, n.latestHead)
+}
+
+// clientFamily returns the name of the client implementation from a version
+// string like "Lighthouse/v1.0.0-1234abcd/x86_64-linux"
+func clientFamily(version string) string {
+	family := strings.SplitN(version, "/", 2)[0]
+	if family == "" {
+		return "unknown"
+	}
+	return strings.ToLower(family)
+}
+
+func isLighthouse(identifier string) bool {
+	return strings.Contains(strings.ToLower(identifier), "lighthouse")
 }
 
 func isPrysm(identifier string) bool {
@@ -227,8 +273,7 @@ func (n *Node) doFetchLatestHeadPrysm() error {
 	}
 	defer resp.Body.Close()
 	data := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponse(resp, &data)
 	if err != nil {
 		return err
 	}
@@ -249,10 +294,14 @@ func (n *Node) doFetchLatestHeadPrysm() error {
 		return nil
 	}
 
-	slot, ok := data["headSlot"].(string)
+	slotStr, ok := data["headSlot"].(string)
 	if !ok {
 		return fmt.Errorf("head slot is not a string")
 	}
+	slot, err := strconv.Atoi(slotStr)
+	if err != nil {
+		return fmt.Errorf("malformed head slot %q", slotStr)
+	}
 
 	// This API can be slow, so if we get an old response,
 	// just drop it
@@ -260,7 +309,7 @@ func (n *Node) doFetchLatestHeadPrysm() error {
 		return nil
 	}
 
-	n.latestHead = HeadRef{slot, root}
+	n.setHead(HeadRef{slot, root})
 	return nil
 }
 
@@ -291,8 +340,7 @@ func (n *Node) doFetchLatestHeadNimbus() error {
 	}
 	defer resp.Body.Close()
 	data := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponse(resp, &data)
 	if err != nil {
 		return err
 	}
@@ -314,10 +362,7 @@ func (n *Node) doFetchLatestHeadNimbus() error {
 	if !ok {
 		return fmt.Errorf("head slot is not a JSON number")
 	}
-	slotNumerical := int(slotNumericalFloat)
-	slot := fmt.Sprintf("%d", slotNumerical)
-
-	n.latestHead = HeadRef{slot, root}
+	n.setHead(HeadRef{int(slotNumericalFloat), root})
 	return nil
 }
 
@@ -330,6 +375,14 @@ func (n *Node) fetchLatestHead(wg *sync.WaitGroup) {
 	} else {
 		n.isHealthy = true
 	}
+	n.status = classifyError(err)
+	n.recordError(&err)
+}
+
+// useTransport sends the requests to the node through `transport`, within
+// the request budget of the node and not while it asks us to back off.
+func (n *Node) useTransport(transport http.RoundTripper) {
+	n.client.Transport = &budgetTransport{next: transport, budget: &n.requests, backoff: &n.backoff}
 }
 
 func (n *Node) doFetchLatestHead() error {
@@ -344,8 +397,7 @@ func (n *Node) doFetchLatestHead() error {
 	}
 	defer resp.Body.Close()
 	headerResp := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&headerResp)
+	err = decodeResponse(resp, &headerResp)
 	if err != nil {
 		return err
 	}
@@ -372,12 +424,16 @@ func (n *Node) doFetchLatestHead() error {
 	if !ok {
 		return fmt.Errorf("inner header message is not a map of data")
 	}
-	slot := header["slot"].(string)
+	slotStr, ok := header["slot"].(string)
 	if !ok {
 		return fmt.Errorf("slot is not a string")
 	}
+	slot, err := strconv.Atoi(slotStr)
+	if err != nil {
+		return fmt.Errorf("malformed slot %q", slotStr)
+	}
 
-	n.latestHead = HeadRef{slot, root}
+	n.setHead(HeadRef{slot, root})
 	return nil
 }
 
@@ -388,14 +444,17 @@ type ProtoArrayResp struct {
 }
 
 type ProtoArrayNode struct {
-	Slot           string   `json:"slot"`
+	Slot           int      `json:"slot,string"`
 	Root           string   `json:"root"`
 	ParentIndex    *float64 `json:"parent"`
 	Weight         float64  `json:"weight"`
 	BestDescendant float64  `json:"best_descendant"`
+	// the justified checkpoint of the state of the block, if reported
+	JustifiedCheckpoint *Checkpoint `json:"justified_checkpoint,omitempty"`
 }
 
-func (n *Node) fetchProtoArray() ([]ProtoArrayNode, error) {
+func (n *Node) fetchProtoArray() (_ []ProtoArrayNode, err error) {
+	defer n.recordError(&err)
 	url := n.endpoint + protoArrayPath
 	resp, err := n.client.Get(url)
 	if err != nil {
@@ -403,17 +462,23 @@ func (n *Node) fetchProtoArray() ([]ProtoArrayNode, error) {
 	}
 	defer resp.Body.Close()
 	protoArrayResp := ProtoArrayResp{}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&protoArrayResp)
-	return protoArrayResp.Data.Nodes, err
+	err = decodeResponse(resp, &protoArrayResp)
+	if err != nil {
+		return nil, err
+	}
+	if len(protoArrayResp.Data.Nodes) == 0 {
+		return nil, fmt.Errorf("%s returned an empty proto array", redactURL(url))
+	}
+	return protoArrayResp.Data.Nodes, nil
 }
 
 type Checkpoint struct {
-	Epoch string `json:"epoch"`
+	Epoch int    `json:"epoch,string"`
 	Root  string `json:"root"`
 }
 
 func (n *Node) fetchFinalityCheckpoints() (justified Checkpoint, finalized Checkpoint, err error) {
+	defer n.recordError(&err)
 	url := n.endpoint + finalityCheckpointsPath
 	resp, err := n.client.Get(url)
 	if err != nil {
@@ -421,8 +486,7 @@ func (n *Node) fetchFinalityCheckpoints() (justified Checkpoint, finalized Check
 	}
 	defer resp.Body.Close()
 	data := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponse(resp, &data)
 	if err != nil {
 		return
 	}
@@ -437,11 +501,16 @@ func (n *Node) fetchFinalityCheckpoints() (justified Checkpoint, finalized Check
 		err = fmt.Errorf("current justified not a map or missing")
 		return
 	}
-	justified.Epoch, ok = justifiedData["epoch"].(string)
+	justifiedEpoch, ok := justifiedData["epoch"].(string)
 	if !ok {
 		err = fmt.Errorf("current justified epoch not a string")
 		return
 	}
+	justified.Epoch, err = strconv.Atoi(justifiedEpoch)
+	if err != nil {
+		err = fmt.Errorf("malformed current justified epoch %q", justifiedEpoch)
+		return
+	}
 	justified.Root, ok = justifiedData["root"].(string)
 	if !ok {
 		err = fmt.Errorf("current justified root not a string")
@@ -452,11 +521,16 @@ func (n *Node) fetchFinalityCheckpoints() (justified Checkpoint, finalized Check
 		err = fmt.Errorf("finalized data not a map or missing")
 		return
 	}
-	finalized.Epoch, ok = finalizedData["epoch"].(string)
+	finalizedEpoch, ok := finalizedData["epoch"].(string)
 	if !ok {
 		err = fmt.Errorf("finalized epoch not a string")
 		return
 	}
+	finalized.Epoch, err = strconv.Atoi(finalizedEpoch)
+	if err != nil {
+		err = fmt.Errorf("malformed finalized epoch %q", finalizedEpoch)
+		return
+	}
 	finalized.Root, ok = finalizedData["root"].(string)
 	if !ok {
 		err = fmt.Errorf("finalized root not a string")
@@ -466,21 +540,15 @@ func (n *Node) fetchFinalityCheckpoints() (justified Checkpoint, finalized Check
 }
 
 func (n *Node) doFetchParticipation(epoch int) (current Participation, previous Participation, err error) {
+	defer n.recordError(&err)
 	url := n.endpoint + fmt.Sprintf(participationPathFmt, epoch)
 	resp, err := n.client.Get(url)
 	if err != nil {
 		return
 	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		err = errors.New("participation fetch failed")
-		return
-	}
-
 	defer resp.Body.Close()
 	data := make(map[string]interface{})
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&data)
+	err = decodeResponseNumbers(resp, &data)
 	if err != nil {
 		return
 	}
@@ -490,51 +558,34 @@ func (n *Node) doFetchParticipation(epoch int) (current Participation, previous
 		err = fmt.Errorf("participation data not a map or missing")
 		return
 	}
-	currentEpochActiveGwei, ok := participationData["current_epoch_active_gwei"].(float64)
-	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
-	}
-	previousEpochActiveGwei, ok := participationData["previous_epoch_active_gwei"].(float64)
-	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
-	}
-	currentEpochAttestingGwei, ok := participationData["current_epoch_attesting_gwei"].(float64)
-	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
+	current, err = parseEpochParticipation(participationData, currentEpochFields, epoch)
+	if err != nil {
 		return
 	}
-	currentEpochTargetAttestingGwei, ok := participationData["current_epoch_target_attesting_gwei"].(float64)
-	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
+	previous, err = parseEpochParticipation(participationData, previousEpochFields, epoch-1)
+	return
+}
+
+func (n *Node) fetchGenesisTime() (_ int, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + genesisPath)
+	if err != nil {
+		return 0, err
 	}
-	previousEpochAttestingGwei, ok := participationData["previous_epoch_attesting_gwei"].(float64)
-	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
+	defer resp.Body.Close()
+	data := make(map[string]interface{})
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return 0, err
 	}
-	previousEpochTargetAttestingGwei, ok := participationData["previous_epoch_target_attesting_gwei"].(float64)
+
+	genesisData, ok := data["data"].(map[string]interface{})
 	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
+		return 0, fmt.Errorf("genesis data not a map or missing")
 	}
-	previousEpochHeadAttestingGwei, ok := participationData["previous_epoch_head_attesting_gwei"].(float64)
+	genesisTime, ok := genesisData["genesis_time"].(string)
 	if !ok {
-		err = fmt.Errorf("wrong type for participation data")
-		return
+		return 0, fmt.Errorf("genesis time not a string")
 	}
-
-	current.Epoch = epoch
-	current.ParticipationRate = currentEpochAttestingGwei / currentEpochActiveGwei * 100
-	current.JustificationRate = currentEpochTargetAttestingGwei / currentEpochActiveGwei * 100
-
-	previous.Epoch = epoch - 1
-	previous.ParticipationRate = previousEpochAttestingGwei / previousEpochActiveGwei * 100
-	previous.JustificationRate = previousEpochTargetAttestingGwei / previousEpochActiveGwei * 100
-	headRate := previousEpochHeadAttestingGwei / previousEpochActiveGwei * 100
-	previous.HeadRate = &headRate
-
-	return
+	return strconv.Atoi(genesisTime)
 }
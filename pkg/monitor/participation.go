@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Participation is the share of active stake, in percent, behind each
+// component of the attestations for an epoch.
+type Participation struct {
+	Epoch int `json:"epoch"`
+	// same as the source and target rates, kept for existing consumers
+	ParticipationRate float64 `json:"participation_rate"`
+	JustificationRate float64 `json:"justification_rate"`
+	SourceRate        float64 `json:"source_rate"`
+	TargetRate        float64 `json:"target_rate"`
+	// null while the provider has not reported it yet, which for the
+	// current epoch is usually until the epoch is over
+	HeadRate *float64 `json:"head_rate"`
+	// whether the justified checkpoint reached this epoch once it was
+	// complete, null until then
+	Justified *bool `json:"justified"`
+	// the epoch failed to justify, see /api/v1/bad-epochs
+	BadEpoch bool `json:"bad_epoch"`
+	// only served when asked for with `?gwei=true`
+	Gwei *ParticipationGwei `json:"gwei,omitempty"`
+}
+
+// ParticipationGwei is the stake behind the rates of an epoch.
+type ParticipationGwei struct {
+	Active          uint64  `json:"active"`
+	SourceAttesting uint64  `json:"source_attesting"`
+	TargetAttesting uint64  `json:"target_attesting"`
+	HeadAttesting   *uint64 `json:"head_attesting"`
+	// target attesting stake needed to justify the epoch
+	JustificationThreshold uint64 `json:"justification_threshold"`
+}
+
+// the keys of each vote component in the lighthouse global validator
+// inclusion data; lighthouse reports the source vote as plain "attesting"
+type inclusionFields struct {
+	active string
+	source string
+	target string
+	head   string
+}
+
+var currentEpochFields = inclusionFields{
+	active: "current_epoch_active_gwei",
+	source: "current_epoch_attesting_gwei",
+	target: "current_epoch_target_attesting_gwei",
+	head:   "current_epoch_head_attesting_gwei",
+}
+
+var previousEpochFields = inclusionFields{
+	active: "previous_epoch_active_gwei",
+	source: "previous_epoch_attesting_gwei",
+	target: "previous_epoch_target_attesting_gwei",
+	head:   "previous_epoch_head_attesting_gwei",
+}
+
+// gweiField reads an amount decoded with `UseNumber`, as the stake in gwei
+// exceeds the integers a float64 holds exactly.
+func gweiField(data map[string]interface{}, key string) (uint64, bool, error) {
+	value, ok := data[key]
+	if !ok {
+		return 0, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false, fmt.Errorf("wrong type for participation data %s", key)
+	}
+	gwei, err := strconv.ParseUint(number.String(), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid participation data %s: %v", key, err)
+	}
+	return gwei, true, nil
+}
+
+func requiredGweiField(data map[string]interface{}, key string) (uint64, error) {
+	gwei, ok, err := gweiField(data, key)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("participation data missing %s", key)
+	}
+	return gwei, nil
+}
+
+// parseEpochParticipation computes the rates of one epoch from the gwei
+// amounts under `fields`. The head vote is optional as not every epoch has it.
+func parseEpochParticipation(data map[string]interface{}, fields inclusionFields, epoch int) (Participation, error) {
+	participation := Participation{Epoch: epoch}
+	active, err := requiredGweiField(data, fields.active)
+	if err != nil {
+		return participation, err
+	}
+	if active == 0 {
+		return participation, fmt.Errorf("no active stake in participation data for epoch %d", epoch)
+	}
+	source, err := requiredGweiField(data, fields.source)
+	if err != nil {
+		return participation, err
+	}
+	target, err := requiredGweiField(data, fields.target)
+	if err != nil {
+		return participation, err
+	}
+	head, hasHead, err := gweiField(data, fields.head)
+	if err != nil {
+		return participation, err
+	}
+
+	participation.SourceRate = float64(source) / float64(active) * 100
+	participation.TargetRate = float64(target) / float64(active) * 100
+	participation.ParticipationRate = participation.SourceRate
+	participation.JustificationRate = participation.TargetRate
+	participation.Gwei = &ParticipationGwei{
+		Active:          active,
+		SourceAttesting: source,
+		TargetAttesting: target,
+		// two thirds rounded up
+		JustificationThreshold: (2*active + 2) / 3,
+	}
+	if hasHead {
+		headRate := float64(head) / float64(active) * 100
+		participation.HeadRate = &headRate
+		participation.Gwei.HeadAttesting = &head
+	}
+	return participation, nil
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseEpochParticipation(t *testing.T) {
+	data := map[string]interface{}{
+		"current_epoch_active_gwei":            json.Number("200"),
+		"current_epoch_attesting_gwei":         json.Number("180"),
+		"current_epoch_target_attesting_gwei":  json.Number("170"),
+		"previous_epoch_active_gwei":           json.Number("100"),
+		"previous_epoch_attesting_gwei":        json.Number("90"),
+		"previous_epoch_target_attesting_gwei": json.Number("80"),
+		"previous_epoch_head_attesting_gwei":   json.Number("70"),
+	}
+
+	current, err := parseEpochParticipation(data, currentEpochFields, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Epoch != 5 || current.SourceRate != 90 || current.TargetRate != 85 || current.HeadRate != nil {
+		t.Errorf("unexpected current participation %+v", current)
+	}
+	if current.Gwei == nil || current.Gwei.Active != 200 || current.Gwei.TargetAttesting != 170 || current.Gwei.JustificationThreshold != 134 {
+		t.Errorf("unexpected current gwei %+v", current.Gwei)
+	}
+	if current.ParticipationRate != current.SourceRate || current.JustificationRate != current.TargetRate {
+		t.Error("expected legacy rates to match the source and target rates")
+	}
+
+	previous, err := parseEpochParticipation(data, previousEpochFields, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous.SourceRate != 90 || previous.TargetRate != 80 || previous.HeadRate == nil || *previous.HeadRate != 70 {
+		t.Errorf("unexpected previous participation %+v", previous)
+	}
+
+	// mainnet stake is beyond the integers a float64 holds exactly
+	data["current_epoch_active_gwei"] = json.Number("34000000000000001")
+	current, err = parseEpochParticipation(data, currentEpochFields, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Gwei.Active != 34000000000000001 || current.Gwei.JustificationThreshold != 22666666666666668 {
+		t.Errorf("expected exact gwei amounts, got %+v", current.Gwei)
+	}
+
+	data["previous_epoch_active_gwei"] = json.Number("0")
+	_, err = parseEpochParticipation(data, previousEpochFields, 4)
+	if err == nil {
+		t.Error("expected an error without active stake")
+	}
+}
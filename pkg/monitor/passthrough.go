@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const sszContentType = "application/octet-stream"
+
+const blockV2PathFmt = "/eth/v2/beacon/blocks/%s"
+const statePathFmt = "/eth/v2/debug/beacon/states/%s"
+
+// block ids as accepted by the beacon API: a name, a slot or a root
+var blockIDPattern = regexp.MustCompile(`^(head|genesis|finalized|justified|[0-9]+|0x[0-9a-fA-F]{64})$`)
+
+var checkpointIDs = map[string]bool{
+	"finalized": true,
+	"justified": true,
+}
+
+// passthroughHeaders are copied from the upstream response
+var passthroughHeaders = []string{"Content-Type", "Eth-Consensus-Version"}
+
+func wantsSSZ(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), sszContentType)
+}
+
+// passthroughProvider picks a healthy node to answer the request, preferring
+// the ones following the majority head.
+func (m *Monitor) passthroughProvider() (*Node, error) {
+	node, _, err := m.chainProvider()
+	if err == nil {
+		return node, nil
+	}
+	now := time.Now()
+	for _, node := range m.nodeList() {
+		if node.isHealthy && !isPrysm(node.version) && node.requests.allowsOptional(now) {
+			return node, nil
+		}
+	}
+	return nil, errors.New("no healthy node available")
+}
+
+// passthrough relays the response of a node for `path` unchanged, in SSZ if
+// the client asks for it with `Accept: application/octet-stream`.
+func (m *Monitor) passthrough(w http.ResponseWriter, r *http.Request, path string) {
+	node, err := m.passthroughProvider()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	request, err := http.NewRequestWithContext(r.Context(), http.MethodGet, node.endpoint+path, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	request.Header.Set("Accept", "application/json")
+	if wantsSSZ(r) {
+		request.Header.Set("Accept", sszContentType)
+	}
+	m.fetches.acquire(cosmeticFetch)
+	defer m.fetches.release()
+	resp, err := node.client.Do(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, header := range passthroughHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(resp.StatusCode)
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// sendBlock serves `/api/v1/block/{id}`.
+func (m *Monitor) sendBlock(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/block/"), "/")
+	if !blockIDPattern.MatchString(id) {
+		http.NotFound(w, r)
+		return
+	}
+	m.passthrough(w, r, fmt.Sprintf(blockV2PathFmt, id))
+}
+
+// sendCheckpoint serves `/api/v1/checkpoint/{finalized|justified}/{block|state}`,
+// e.g. for checkpoint sync.
+func (m *Monitor) sendCheckpoint(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/checkpoint/"), "/")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || !checkpointIDs[parts[0]] {
+		http.NotFound(w, r)
+		return
+	}
+	switch parts[1] {
+	case "block":
+		m.passthrough(w, r, fmt.Sprintf(blockV2PathFmt, parts[0]))
+	case "state":
+		m.passthrough(w, r, fmt.Sprintf(statePathFmt, parts[0]))
+	default:
+		http.NotFound(w, r)
+	}
+}
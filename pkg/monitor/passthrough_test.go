@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPassthroughSSZ(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/finalized" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Eth-Consensus-Version", "deneb")
+		if r.Header.Get("Accept") == sszContentType {
+			w.Header().Set("Content-Type", sszContentType)
+			w.Write([]byte{0x01, 0x02})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	m := &Monitor{
+		config: &Config{},
+		nodes:  []*Node{{id: "a", endpoint: upstream.URL, isHealthy: true}},
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/checkpoint/finalized/block", nil)
+	request.Header.Set("Accept", sszContentType)
+	recorder := httptest.NewRecorder()
+	m.sendCheckpoint(recorder, request)
+	body, _ := ioutil.ReadAll(recorder.Body)
+	if recorder.Header().Get("Content-Type") != sszContentType || string(body) != "\x01\x02" {
+		t.Errorf("expected SSZ to be passed through, got %q as %s", body, recorder.Header().Get("Content-Type"))
+	}
+	if recorder.Header().Get("Eth-Consensus-Version") != "deneb" {
+		t.Error("expected the consensus version to be passed through")
+	}
+
+	recorder = httptest.NewRecorder()
+	m.sendBlock(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/block/finalized", nil))
+	if recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON by default, got %s", recorder.Header().Get("Content-Type"))
+	}
+
+	recorder = httptest.NewRecorder()
+	m.sendBlock(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/block/../../eth/v1/node/identity", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected invalid block ids to be rejected, got %d", recorder.Code)
+	}
+}
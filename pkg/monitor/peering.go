@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const nodePeersPath = "/eth/v1/node/peers?state=connected"
+
+type nodePeersResp struct {
+	Data []struct {
+		PeerID string `json:"peer_id"`
+	} `json:"data"`
+}
+
+// fetchPeerIDs returns the set of peers `n` is currently connected to.
+func (n *Node) fetchPeerIDs() (_ map[string]bool, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + nodePeersPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data := nodePeersResp{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return nil, err
+	}
+	peers := make(map[string]bool, len(data.Data))
+	for _, peer := range data.Data {
+		peers[peer.PeerID] = true
+	}
+	return peers, nil
+}
+
+// peeringMatrix reports which monitored nodes are peered with one another.
+// A node without any monitored peer is isolated, a common reason for a single
+// node to fork off.
+type peeringMatrix struct {
+	Nodes []string `json:"nodes"`
+	// Connected[a][b] is whether `a` lists `b` among its connected peers,
+	// nodes whose peers could not be fetched have no row
+	Connected map[string]map[string]bool `json:"connected"`
+	Isolated  []string                   `json:"isolated"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// buildPeeringMatrix checks the `peers` of every node, keyed by node id,
+// against the peer IDs of the other nodes. As connections are symmetric a
+// node only counts as isolated if no fetched peer list links it to another node.
+func buildPeeringMatrix(nodes []*Node, peers map[string]map[string]bool) peeringMatrix {
+	matrix := peeringMatrix{Connected: make(map[string]map[string]bool)}
+	linked := make(map[string]bool)
+	for _, a := range nodes {
+		matrix.Nodes = append(matrix.Nodes, a.id)
+		aPeers, ok := peers[a.id]
+		if !ok {
+			continue
+		}
+		row := make(map[string]bool)
+		for _, b := range nodes {
+			if a == b {
+				continue
+			}
+			connected := b.identity.PeerID != "" && aPeers[b.identity.PeerID]
+			row[b.id] = connected
+			if connected {
+				linked[a.id] = true
+				linked[b.id] = true
+			}
+		}
+		matrix.Connected[a.id] = row
+	}
+	for _, node := range nodes {
+		if _, ok := peers[node.id]; ok && !linked[node.id] {
+			matrix.Isolated = append(matrix.Isolated, node.id)
+		}
+	}
+	sort.Strings(matrix.Nodes)
+	sort.Strings(matrix.Isolated)
+	return matrix
+}
+
+type peeringState struct {
+	lock   sync.Mutex
+	matrix *peeringMatrix
+}
+
+func (p *peeringState) set(matrix peeringMatrix) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.matrix = &matrix
+}
+
+func (p *peeringState) get() *peeringMatrix {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.matrix
+}
+
+func (m *Monitor) updatePeering() {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	peers := make(map[string]map[string]bool)
+	nodes := m.nodeList()
+	now := time.Now()
+	for _, node := range nodes {
+		if !node.isHealthy || !node.requests.allowsOptional(now) {
+			continue
+		}
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			m.fetches.acquire(cosmeticFetch)
+			nodePeers, err := node.fetchPeerIDs()
+			m.fetches.release()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			lock.Lock()
+			peers[node.id] = nodePeers
+			lock.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	matrix := buildPeeringMatrix(nodes, peers)
+	matrix.UpdatedAt = time.Now()
+	m.peering.set(matrix)
+}
+
+func (m *Monitor) startPeeringMonitor(beat func() bool) {
+	config := m.eth2()
+	for beat() {
+		m.updatePeering()
+		waitUntilNextEpoch(config.GenesisTime, config.SecondsPerSlot, config.SlotsPerEpoch)
+	}
+}
+
+func (m *Monitor) sendPeering(w http.ResponseWriter, r *http.Request) {
+	matrix := m.peering.get()
+	if matrix == nil {
+		http.Error(w, "peering not checked yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, r, matrix)
+}
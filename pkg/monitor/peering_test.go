@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildPeeringMatrix(t *testing.T) {
+	a := &Node{id: "a", identity: NodeIdentity{PeerID: "peer-a"}}
+	b := &Node{id: "b", identity: NodeIdentity{PeerID: "peer-b"}}
+	c := &Node{id: "c", identity: NodeIdentity{PeerID: "peer-c"}}
+	d := &Node{id: "d", identity: NodeIdentity{PeerID: "peer-d"}}
+	peers := map[string]map[string]bool{
+		"a": {"peer-b": true, "peer-x": true},
+		"b": {},
+		"c": {"peer-y": true},
+	}
+
+	matrix := buildPeeringMatrix([]*Node{a, b, c, d}, peers)
+	if !matrix.Connected["a"]["b"] || matrix.Connected["b"]["a"] || matrix.Connected["a"]["c"] {
+		t.Errorf("unexpected connections %v", matrix.Connected)
+	}
+	if _, ok := matrix.Connected["d"]; ok {
+		t.Error("expected no row for a node without a peer list")
+	}
+	if !reflect.DeepEqual(matrix.Isolated, []string{"c"}) {
+		t.Errorf("expected only c to be isolated, got %v", matrix.Isolated)
+	}
+}
+
+func TestFetchPeerIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "connected" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":[{"peer_id":"peer-a","state":"connected"},{"peer_id":"peer-b","state":"connected"}]}`))
+	}))
+	defer server.Close()
+
+	peers, err := (&Node{endpoint: server.URL}).fetchPeerIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || !peers["peer-a"] || !peers["peer-b"] {
+		t.Errorf("unexpected peers %v", peers)
+	}
+}
@@ -0,0 +1,159 @@
+package monitor
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFileName = "state.json"
+const persistenceInterval = 1 * time.Minute
+
+// persistedState is everything the monitor restores after a restart
+// so that the dashboard does not start out empty.
+type persistedState struct {
+	SavedAt       time.Time               `json:"saved_at"`
+	GenesisTime   int                     `json:"genesis_time"`
+	Participation []Participation         `json:"participation"`
+	Samples       map[string][]nodeSample `json:"samples"`
+	Justified     Checkpoint              `json:"justified_checkpoint"`
+	Finalized     Checkpoint              `json:"finalized_checkpoint"`
+	Reorgs        []Reorg                 `json:"reorgs"`
+	Deposits      *depositState           `json:"deposits"`
+}
+
+func (m *Monitor) stateFilePath() string {
+	return filepath.Join(m.config.DataDir, stateFileName)
+}
+
+func (m *Monitor) snapshotState() persistedState {
+	state := persistedState{
+		SavedAt:       time.Now(),
+		GenesisTime:   m.genesisTime(),
+		Justified:     m.justifiedCheckpoint,
+		Finalized:     m.finalizedCheckpoint,
+		Participation: m.participationHistory(),
+		Reorgs:        m.reorgHistory(-1),
+	}
+	if m.config.ExecutionEndpoint != "" {
+		deposits := m.deposits.snapshot()
+		state.Deposits = &deposits
+	}
+
+	m.samples.lock.Lock()
+	state.Samples = make(map[string][]nodeSample, len(m.samples.samples))
+	for id, samples := range m.samples.samples {
+		state.Samples[id] = append([]nodeSample{}, samples...)
+	}
+	m.samples.lock.Unlock()
+
+	return state
+}
+
+func (m *Monitor) applyState(state persistedState) {
+	m.justifiedCheckpoint = state.Justified
+	m.finalizedCheckpoint = state.Finalized
+
+	err := m.store().SaveParticipation(state.Participation...)
+	if err != nil {
+		log.Println(err)
+	}
+	m.reorgs.reset()
+	for _, reorg := range state.Reorgs {
+		err = m.store().SaveReorg(reorg)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if state.Deposits != nil {
+		m.deposits.restore(*state.Deposits)
+	}
+
+	m.samples.lock.Lock()
+	if state.Samples != nil {
+		m.samples.samples = state.Samples
+	}
+	m.samples.lock.Unlock()
+}
+
+// writeState replaces the file at `path` atomically so a crash
+// mid-write never leaves a truncated state behind.
+func writeState(path string, state persistedState) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	err = enc.Encode(&state)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func readState(path string) (persistedState, error) {
+	state := persistedState{}
+	f, err := os.Open(path)
+	if err != nil {
+		return state, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	err = dec.Decode(&state)
+	return state, err
+}
+
+func (m *Monitor) saveState() error {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	return writeState(m.stateFilePath(), m.snapshotState())
+}
+
+// restoreState warms the in-memory caches from the last saved state, if any.
+func (m *Monitor) restoreState() error {
+	state, err := readState(m.stateFilePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if state.GenesisTime != 0 && state.GenesisTime != m.genesisTime() {
+		log.Println("ignoring state saved for a different genesis")
+		return nil
+	}
+	m.applyState(state)
+	// drop anything that expired while we were down
+	m.prune(time.Now())
+	log.Printf("restored state saved at %s", state.SavedAt.Format(time.RFC3339))
+	return nil
+}
+
+// startPersistence saves the state periodically and whenever an epoch completes.
+func (m *Monitor) startPersistence(beat func() bool) {
+	for beat() {
+		select {
+		case <-time.After(persistenceInterval):
+		case <-m.saveRequests:
+		}
+
+		err := m.saveState()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
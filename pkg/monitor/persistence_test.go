@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	config := &Config{
+		Eth2:    Eth2Config{GenesisTime: int(time.Now().Unix()) - 3600, SecondsPerSlot: 12, SlotsPerEpoch: 32},
+		DataDir: t.TempDir(),
+	}
+	m := &Monitor{config: config, samples: newSampleStore()}
+	m.justifiedCheckpoint = Checkpoint{Epoch: 7, Root: "0x07"}
+	m.finalizedCheckpoint = Checkpoint{Epoch: 6, Root: "0x06"}
+	m.store().SaveParticipation(Participation{Epoch: 6, ParticipationRate: 99}, Participation{Epoch: 7, ParticipationRate: 50})
+	m.samples.append("a", nodeSample{Time: time.Now().Add(-time.Minute).Round(0), Lag: 1, Healthy: true})
+
+	err := m.saveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &Monitor{config: config, samples: newSampleStore()}
+	err = restored.restoreState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.justifiedCheckpoint != m.justifiedCheckpoint || restored.finalizedCheckpoint != m.finalizedCheckpoint {
+		t.Error("checkpoints were not restored")
+	}
+	if !reflect.DeepEqual(restored.participationHistory(), m.participationHistory()) {
+		t.Errorf("participation was not restored: %v", restored.participationHistory())
+	}
+	samples := restored.samples.since("a", time.Time{})
+	if len(samples) != 1 || !samples[0].Time.Equal(m.samples.samples["a"][0].Time) {
+		t.Errorf("samples were not restored: %v", samples)
+	}
+
+	empty := &Monitor{config: &Config{DataDir: t.TempDir()}, samples: newSampleStore()}
+	if err := empty.restoreState(); err != nil {
+		t.Errorf("missing state should not be an error: %v", err)
+	}
+}
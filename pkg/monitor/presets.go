@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const defaultSecondsPerSlot = 12
+const defaultSlotsPerEpoch = 32
+const defaultEpochsPerSyncCommitteePeriod = 256
+
+var depositContractAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// networkPresets are the known networks selectable with `eth2.network`
+var networkPresets = map[string]Eth2Config{
+	"mainnet": {
+		SecondsPerSlot:             12,
+		GenesisTime:                1606824023,
+		SlotsPerEpoch:              32,
+		DepositContractAddress:     "0x00000000219ab540356cBB839Cbe05303d7705Fa",
+		DepositChainID:             1,
+		DepositContractDeployBlock: 11052984,
+		ForkEpochs: map[string]int{
+			"altair":    74240,
+			"bellatrix": 144896,
+			"capella":   194048,
+			"deneb":     269568,
+			"electra":   364032,
+		},
+	},
+	"holesky": {
+		SecondsPerSlot:         12,
+		GenesisTime:            1695902400,
+		SlotsPerEpoch:          32,
+		DepositContractAddress: "0x4242424242424242424242424242424242424242",
+		DepositChainID:         17000,
+		// the contract is part of the execution genesis state
+		DepositContractDeployBlock: 0,
+		ForkEpochs: map[string]int{
+			"altair":    0,
+			"bellatrix": 0,
+			"capella":   256,
+			"deneb":     29696,
+			"electra":   115968,
+		},
+	},
+	"sepolia": {
+		SecondsPerSlot:             12,
+		GenesisTime:                1655733600,
+		SlotsPerEpoch:              32,
+		DepositContractAddress:     "0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D",
+		DepositChainID:             11155111,
+		DepositContractDeployBlock: 1273020,
+		ForkEpochs: map[string]int{
+			"altair":    50,
+			"bellatrix": 100,
+			"capella":   56832,
+			"deneb":     132608,
+			"electra":   222464,
+		},
+	},
+	"gnosis": {
+		SecondsPerSlot:               5,
+		GenesisTime:                  1638993340,
+		SlotsPerEpoch:                16,
+		DepositContractAddress:       "0x0B98057eA310F4d31F2a452B414647007d1645d9",
+		DepositChainID:               100,
+		DepositContractDeployBlock:   19469077,
+		EpochsPerSyncCommitteePeriod: 512,
+		ForkEpochs: map[string]int{
+			"altair":    512,
+			"bellatrix": 385536,
+			"capella":   648704,
+			"deneb":     889856,
+			"electra":   1337856,
+		},
+	},
+}
+
+// fillFrom sets any unset parameters from `other`
+func (c *Eth2Config) fillFrom(other Eth2Config) {
+	if c.Network == "" {
+		c.Network = other.Network
+	}
+	if c.SecondsPerSlot == 0 {
+		c.SecondsPerSlot = other.SecondsPerSlot
+	}
+	if c.GenesisTime == 0 {
+		c.GenesisTime = other.GenesisTime
+	}
+	if c.SlotsPerEpoch == 0 {
+		c.SlotsPerEpoch = other.SlotsPerEpoch
+	}
+	if c.DepositContractAddress == "" {
+		c.DepositContractAddress = other.DepositContractAddress
+	}
+	if c.DepositChainID == 0 {
+		c.DepositChainID = other.DepositChainID
+	}
+	if c.DepositContractDeployBlock == 0 {
+		c.DepositContractDeployBlock = other.DepositContractDeployBlock
+	}
+	if c.EpochsPerSyncCommitteePeriod == 0 {
+		c.EpochsPerSyncCommitteePeriod = other.EpochsPerSyncCommitteePeriod
+	}
+	for fork, epoch := range other.ForkEpochs {
+		if c.ForkEpochs == nil {
+			c.ForkEpochs = make(map[string]int)
+		}
+		if _, ok := c.ForkEpochs[fork]; !ok {
+			c.ForkEpochs[fork] = epoch
+		}
+	}
+}
+
+// applyPreset fills in any unset parameters from the spec file, if given, and
+// then the preset for the configured network, so that explicitly configured
+// values always win.
+func (c *Eth2Config) applyPreset() error {
+	if c.SpecFile != "" {
+		spec, err := loadSpecFile(c.SpecFile)
+		if err != nil {
+			return err
+		}
+		c.fillFrom(spec)
+	}
+	if c.DepositContractAddress != "" && !depositContractAddressPattern.MatchString(c.DepositContractAddress) {
+		return fmt.Errorf("invalid deposit contract address %q", c.DepositContractAddress)
+	}
+	if preset, ok := networkPresets[strings.ToLower(c.Network)]; ok {
+		// a mismatch means either the network or the address is wrong
+		if c.DepositContractAddress != "" && !strings.EqualFold(c.DepositContractAddress, preset.DepositContractAddress) {
+			return fmt.Errorf("deposit contract address %s does not match the %s network, expected %s", c.DepositContractAddress, c.Network, preset.DepositContractAddress)
+		}
+		if c.DepositChainID != 0 && c.DepositChainID != preset.DepositChainID {
+			return fmt.Errorf("deposit chain id %d does not match the %s network, expected %d", c.DepositChainID, c.Network, preset.DepositChainID)
+		}
+		c.fillFrom(preset)
+	}
+
+	if c.SecondsPerSlot == 0 {
+		c.SecondsPerSlot = defaultSecondsPerSlot
+	}
+	if c.SlotsPerEpoch == 0 {
+		c.SlotsPerEpoch = defaultSlotsPerEpoch
+	}
+	if c.EpochsPerSyncCommitteePeriod == 0 {
+		c.EpochsPerSyncCommitteePeriod = defaultEpochsPerSyncCommitteePeriod
+	}
+	return nil
+}
+
+// ApplyDefaults completes the configuration after it has been loaded.
+func (c *Config) ApplyDefaults() error {
+	err := c.Eth2.applyPreset()
+	if err != nil {
+		return err
+	}
+	// if we follow genesis, it is learned from the nodes
+	if c.Eth2.GenesisTime == 0 && !c.watchesGenesis() {
+		return fmt.Errorf("no genesis time configured for unknown network %q", c.Eth2.Network)
+	}
+	return nil
+}
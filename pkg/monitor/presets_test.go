@@ -0,0 +1,79 @@
+package monitor
+
+import "testing"
+
+func TestNetworkPresets(t *testing.T) {
+	config := Config{Eth2: Eth2Config{Network: "Gnosis"}}
+	err := config.ApplyDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Eth2.SecondsPerSlot != 5 || config.Eth2.SlotsPerEpoch != 16 || config.Eth2.GenesisTime != 1638993340 {
+		t.Errorf("gnosis preset not applied: %+v", config.Eth2)
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "mainnet", GenesisTime: 42}}
+	err = config.ApplyDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Eth2.GenesisTime != 42 || config.Eth2.SecondsPerSlot != 12 {
+		t.Errorf("explicit values should override the preset: %+v", config.Eth2)
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "devnet-7"}}
+	if err := config.ApplyDefaults(); err == nil {
+		t.Error("expected unknown network without genesis time to be rejected")
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "ephemery"}}
+	if err := config.ApplyDefaults(); err != nil {
+		t.Errorf("genesis time should be learned for ephemery: %v", err)
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "devnet-7", GenesisTime: 42}}
+	err = config.ApplyDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Eth2.SecondsPerSlot != defaultSecondsPerSlot || config.Eth2.SlotsPerEpoch != defaultSlotsPerEpoch {
+		t.Errorf("expected default slot timing: %+v", config.Eth2)
+	}
+}
+
+func TestDepositContractPreset(t *testing.T) {
+	config := Config{Eth2: Eth2Config{Network: "gnosis"}}
+	err := config.ApplyDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Eth2.DepositChainID != 100 || config.Eth2.DepositContractAddress != "0x0B98057eA310F4d31F2a452B414647007d1645d9" {
+		t.Errorf("expected the gnosis deposit contract: %+v", config.Eth2)
+	}
+
+	deployBlocks := map[string]int{"mainnet": 11052984, "holesky": 0, "sepolia": 1273020, "gnosis": 19469077}
+	for network, block := range deployBlocks {
+		config = Config{Eth2: Eth2Config{Network: network}}
+		if err := config.ApplyDefaults(); err != nil {
+			t.Fatal(err)
+		}
+		if config.Eth2.DepositContractDeployBlock != block {
+			t.Errorf("expected %s deposit logs from block %d, got %d", network, block, config.Eth2.DepositContractDeployBlock)
+		}
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "mainnet", DepositContractAddress: "0x00000000219AB540356CBB839CBE05303D7705FA"}}
+	if err := config.ApplyDefaults(); err != nil {
+		t.Errorf("the address should be compared without case: %v", err)
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "sepolia", DepositContractAddress: "0x00000000219ab540356cBB839Cbe05303d7705Fa"}}
+	if err := config.ApplyDefaults(); err == nil {
+		t.Error("expected the mainnet contract to be rejected on sepolia")
+	}
+
+	config = Config{Eth2: Eth2Config{Network: "devnet-7", GenesisTime: 42, DepositContractAddress: "0x1234"}}
+	if err := config.ApplyDefaults(); err == nil {
+		t.Error("expected a malformed address to be rejected")
+	}
+}
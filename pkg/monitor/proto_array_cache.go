@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// protoArrayKey identifies the fork choice of a node in a slot; a new head
+// within the slot changes the fork choice and so the key.
+type protoArrayKey struct {
+	slot int
+	head string
+}
+
+type protoArrayEntry struct {
+	key        protoArrayKey
+	done       chan struct{}
+	protoArray []ProtoArrayNode
+	err        error
+}
+
+// protoArrayCache shares the proto array of each node between the fork
+// choice summary and the sanity checks, so a node serves it at most once per
+// slot and head; the zero value is ready to use. Callers must not modify the
+// proto arrays they get.
+type protoArrayCache struct {
+	lock    sync.Mutex
+	entries map[string]*protoArrayEntry
+}
+
+// get returns the proto array of `node` for `key`, calling `fetch` unless
+// it was fetched for the same key already. Concurrent callers wait for the
+// same fetch. Failures are not kept so the next caller tries again.
+func (c *protoArrayCache) get(node string, key protoArrayKey, fetch func() ([]ProtoArrayNode, error)) ([]ProtoArrayNode, error) {
+	c.lock.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*protoArrayEntry)
+	}
+	entry, ok := c.entries[node]
+	if ok && entry.key == key {
+		c.lock.Unlock()
+		<-entry.done
+		return entry.protoArray, entry.err
+	}
+	entry = &protoArrayEntry{key: key, done: make(chan struct{})}
+	c.entries[node] = entry
+	c.lock.Unlock()
+
+	entry.protoArray, entry.err = fetch()
+	close(entry.done)
+	if entry.err != nil {
+		c.lock.Lock()
+		if c.entries[node] == entry {
+			delete(c.entries, node)
+		}
+		c.lock.Unlock()
+	}
+	return entry.protoArray, entry.err
+}
+
+func (c *protoArrayCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = nil
+}
+
+// protoArrayOf returns the proto array of `node` as of the current slot and
+// its latest head, fetching it only if no one else did.
+func (m *Monitor) protoArrayOf(node *Node) ([]ProtoArrayNode, error) {
+	config := m.eth2()
+	key := protoArrayKey{
+		slot: computeCurrentSlot(config.GenesisTime, config.SecondsPerSlot),
+		head: node.latestHead.root,
+	}
+	return m.protoArrays.get(node.id, key, func() ([]ProtoArrayNode, error) {
+		m.fetches.acquire(forkChoiceFetch)
+		start := time.Now()
+		protoArray, err := node.fetchProtoArray()
+		now := time.Now()
+		m.fetches.release()
+		m.providerScores.record(forkChoiceQuery, node.id, now.Sub(start), err, now)
+		return protoArray, err
+	})
+}
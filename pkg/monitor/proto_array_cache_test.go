@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestProtoArrayFetchedOncePerSlot(t *testing.T) {
+	zero := float64(0)
+	protoArray := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	var lock sync.Mutex
+	fetches := make(map[string]int)
+	server := func(id string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			fetches[id]++
+			lock.Unlock()
+			resp := ProtoArrayResp{}
+			resp.Data.Nodes = protoArray
+			json.NewEncoder(w).Encode(&resp)
+		}))
+	}
+	a := server("a")
+	defer a.Close()
+	b := server("b")
+	defer b.Close()
+
+	nodes := []*Node{
+		{id: "a", endpoint: a.URL, isHealthy: true, latestHead: HeadRef{1, hash("1")}},
+		{id: "b", endpoint: b.URL, isHealthy: true, latestHead: HeadRef{1, hash("1")}},
+	}
+	m := &Monitor{
+		config:              &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		forkChoiceProviders: nodes,
+		alerts:              newAlertSet(),
+	}
+	m.setForkChoiceProvider(nodes[0])
+
+	err := m.buildLatestForkChoiceSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.checkForkChoiceProviders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.checkHeadConsistency()
+	if fetches["a"] != 1 || fetches["b"] != 1 {
+		t.Errorf("expected each proto array to be fetched once, got %v", fetches)
+	}
+
+	// a new head within the slot changes the fork choice
+	nodes[0].latestHead = HeadRef{2, hash("2")}
+	m.checkHeadConsistency()
+	if fetches["a"] != 2 || fetches["b"] != 1 {
+		t.Errorf("expected a new head to be fetched again, got %v", fetches)
+	}
+}
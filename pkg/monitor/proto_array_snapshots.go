@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const protoArraySnapshotDir = "proto_array"
+const protoArraySnapshotSuffix = ".json.gz"
+const defaultProtoArraySnapshotRetention = 30 * 24 * time.Hour
+
+func (m *Monitor) protoArraySnapshotRetention() time.Duration {
+	return retentionOrDefault(m.config.Retention.ProtoArraySnapshots, defaultProtoArraySnapshotRetention)
+}
+
+func (m *Monitor) protoArraySnapshotDir() string {
+	return filepath.Join(m.config.DataDir, protoArraySnapshotDir)
+}
+
+func (m *Monitor) protoArraySnapshotPath(epoch int) string {
+	return filepath.Join(m.protoArraySnapshotDir(), strconv.Itoa(epoch)+protoArraySnapshotSuffix)
+}
+
+// writeProtoArraySnapshot stores the unmodified proto array response of
+// `node` compressed, so that it keeps any fields we do not otherwise parse.
+func (m *Monitor) writeProtoArraySnapshot(node *Node, epoch int) error {
+	resp, err := node.client.Get(node.endpoint + protoArrayPath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	err = checkStatus(resp)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(m.protoArraySnapshotDir(), 0755)
+	if err != nil {
+		return err
+	}
+	path := m.protoArraySnapshotPath(epoch)
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	gz.Comment = fmt.Sprintf("proto array at epoch %d from node %s", epoch, node.id)
+	_, err = io.Copy(gz, resp.Body)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+type protoArraySnapshot struct {
+	Epoch   int       `json:"epoch"`
+	Bytes   int64     `json:"compressed_bytes"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+func (m *Monitor) listProtoArraySnapshots() ([]protoArraySnapshot, error) {
+	entries, err := ioutil.ReadDir(m.protoArraySnapshotDir())
+	if os.IsNotExist(err) {
+		return []protoArraySnapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []protoArraySnapshot{}
+	for _, entry := range entries {
+		epoch, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), protoArraySnapshotSuffix))
+		if err != nil || !strings.HasSuffix(entry.Name(), protoArraySnapshotSuffix) {
+			continue
+		}
+		snapshots = append(snapshots, protoArraySnapshot{Epoch: epoch, Bytes: entry.Size(), SavedAt: entry.ModTime()})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Epoch < snapshots[j].Epoch })
+	return snapshots, nil
+}
+
+func (m *Monitor) pruneProtoArraySnapshots(cutoff time.Time) error {
+	snapshots, err := m.listProtoArraySnapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.SavedAt.Before(cutoff) {
+			err := os.Remove(m.protoArraySnapshotPath(snapshot.Epoch))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) startProtoArraySnapshots(beat func() bool) {
+	config := m.eth2()
+	for beat() {
+		waitUntilNextEpoch(config.GenesisTime, config.SecondsPerSlot, config.SlotsPerEpoch)
+
+		provider := m.providerFor(forkChoiceQuery)
+		if provider == nil || !provider.requests.allowsOptional(time.Now()) {
+			continue
+		}
+		m.fetches.acquire(cosmeticFetch)
+		err := m.writeProtoArraySnapshot(provider, m.getCurrentEpoch())
+		m.fetches.release()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+type protoArraySnapshotsResp struct {
+	Snapshots []protoArraySnapshot `json:"snapshots"`
+}
+
+// sendProtoArraySnapshots lists the snapshots at `/api/v1/proto-array/snapshots`
+// and serves the one of an epoch at `/api/v1/proto-array/snapshots/{epoch}`,
+// compressed if the client accepts it.
+func (m *Monitor) sendProtoArraySnapshots(w http.ResponseWriter, r *http.Request) {
+	if !m.config.ProtoArraySnapshots || m.config.DataDir == "" {
+		http.NotFound(w, r)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/proto-array/snapshots"), "/")
+	if path == "" {
+		snapshots, err := m.listProtoArraySnapshots()
+		if err != nil {
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, r, &protoArraySnapshotsResp{Snapshots: snapshots})
+		return
+	}
+
+	epoch, err := strconv.Atoi(path)
+	if err != nil || epoch < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(m.protoArraySnapshotPath(epoch))
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	var body io.Reader = f
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+	} else {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	_, err = io.Copy(w, body)
+	if err != nil {
+		log.Println(err)
+	}
+}
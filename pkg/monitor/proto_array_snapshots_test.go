@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProtoArraySnapshots(t *testing.T) {
+	zero := float64(0)
+	protoArray := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100, BestDescendant: 1},
+	}
+	server := protoArrayServer(t, &protoArray)
+	defer server.Close()
+
+	m := &Monitor{config: &Config{DataDir: t.TempDir(), ProtoArraySnapshots: true}}
+	err := m.writeProtoArraySnapshot(&Node{id: "a", endpoint: server.URL}, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := m.listProtoArraySnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Epoch != 7 {
+		t.Fatalf("unexpected snapshots %+v", snapshots)
+	}
+
+	recorder := httptest.NewRecorder()
+	m.sendProtoArraySnapshots(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/proto-array/snapshots/7", nil))
+	if !strings.Contains(recorder.Body.String(), hash("1")) {
+		t.Errorf("expected the decompressed proto array, got %q", recorder.Body.String())
+	}
+
+	err = m.pruneProtoArraySnapshots(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, _ = m.listProtoArraySnapshots()
+	if len(snapshots) != 0 {
+		t.Errorf("expected snapshots to be pruned, got %+v", snapshots)
+	}
+}
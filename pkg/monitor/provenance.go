@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// sections of the served data that are tracked for provenance
+const (
+	forkChoiceSource       = "fork_choice"
+	participationSource    = "participation"
+	finalitySource         = "finality"
+	depositContractSource  = "deposit_contract"
+	weakSubjectivitySource = "weak_subjectivity"
+)
+
+const etherscanSource = "etherscan"
+
+// Provenance records which source supplied a piece of data and when, so that
+// consumers can judge how far to trust it, e.g. after a failover.
+type Provenance struct {
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// sourceSet is the latest provenance of each section; the zero value is ready to use.
+type sourceSet struct {
+	lock    sync.Mutex
+	sources map[string]Provenance
+}
+
+func (s *sourceSet) record(section string, source string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sources == nil {
+		s.sources = make(map[string]Provenance)
+	}
+	s.sources[section] = Provenance{Source: source, UpdatedAt: at}
+}
+
+// get returns the provenance of `section` or nil if it was never updated.
+func (s *sourceSet) get(section string) *Provenance {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	provenance, ok := s.sources[section]
+	if !ok {
+		return nil
+	}
+	return &provenance
+}
+
+func (s *sourceSet) all() map[string]Provenance {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	all := make(map[string]Provenance, len(s.sources))
+	for section, provenance := range s.sources {
+		all[section] = provenance
+	}
+	return all
+}
@@ -0,0 +1,26 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSourceSet(t *testing.T) {
+	var sources sourceSet
+	if sources.get(forkChoiceSource) != nil {
+		t.Error("expected no provenance before any update")
+	}
+
+	at := time.Unix(1606824023, 0)
+	sources.record(forkChoiceSource, "node-a", at)
+	sources.record(forkChoiceSource, "node-b", at.Add(time.Minute))
+	sources.record(participationSource, "node-a", at)
+
+	provenance := sources.get(forkChoiceSource)
+	if provenance == nil || provenance.Source != "node-b" || !provenance.UpdatedAt.Equal(at.Add(time.Minute)) {
+		t.Errorf("unexpected provenance %+v", provenance)
+	}
+	if len(sources.all()) != 2 {
+		t.Errorf("expected provenance of two sections, got %v", sources.all())
+	}
+}
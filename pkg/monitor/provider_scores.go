@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// weight of the latest outcome in the moving averages of a provider
+const providerScoreSmoothing = 0.2
+
+// points a provider loses per slot its head is behind and per second its
+// responses take, out of 100 for a provider that never fails
+const providerLagPenalty = 5.0
+const providerLatencyPenalty = 10.0
+
+// the averages of a provider fade toward a clean record at this half-life
+// while it serves no queries, so a node that is passed over after failing is
+// tried again later and selected once it has recovered
+const providerScoreHalfLife = 10 * time.Minute
+
+// names of the query kinds in the served scores
+var queryKindNames = map[queryKind]string{
+	forkChoiceQuery:    forkChoiceSource,
+	participationQuery: participationSource,
+	finalityQuery:      finalitySource,
+}
+
+// providerStats keeps moving averages of the outcomes of the queries a node
+// served for one kind of data.
+type providerStats struct {
+	Queries         int       `json:"queries"`
+	ErrorRate       float64   `json:"error_rate"`
+	ResponseSeconds float64   `json:"response_seconds"`
+	LastQuery       time.Time `json:"last_query"`
+	LastSuccess     time.Time `json:"last_success"`
+}
+
+// decayed returns the stats as of `now`, faded since the last query.
+func (s providerStats) decayed(now time.Time) providerStats {
+	if s.LastQuery.IsZero() || !now.After(s.LastQuery) {
+		return s
+	}
+	factor := math.Pow(0.5, float64(now.Sub(s.LastQuery))/float64(providerScoreHalfLife))
+	s.ErrorRate *= factor
+	s.ResponseSeconds *= factor
+	return s
+}
+
+// score rates a provider whose head is `lag` slots behind the current slot.
+// Nodes without any queries yet are rated on their lag alone so they get a
+// chance to serve.
+func (s providerStats) score(lag int) float64 {
+	if lag < 0 {
+		lag = 0
+	}
+	return 100*(1-s.ErrorRate) - providerLagPenalty*float64(lag) - providerLatencyPenalty*s.ResponseSeconds
+}
+
+func smooth(average float64, value float64, first bool) float64 {
+	if first {
+		return value
+	}
+	return average + providerScoreSmoothing*(value-average)
+}
+
+type providerStatsKey struct {
+	kind queryKind
+	node string
+}
+
+// providerScores tracks how well each node serves each kind of query; the
+// zero value is ready to use.
+type providerScores struct {
+	lock  sync.Mutex
+	stats map[providerStatsKey]providerStats
+}
+
+// record notes the outcome of a query of `kind` sent to `node`, taking
+// `elapsed` to complete.
+func (p *providerScores) record(kind queryKind, node string, elapsed time.Duration, err error, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.stats == nil {
+		p.stats = make(map[providerStatsKey]providerStats)
+	}
+	key := providerStatsKey{kind: kind, node: node}
+	stats := p.stats[key].decayed(now)
+	first := stats.Queries == 0
+	stats.Queries++
+	stats.LastQuery = now
+	if err != nil {
+		stats.ErrorRate = smooth(stats.ErrorRate, 1, first)
+	} else {
+		stats.ErrorRate = smooth(stats.ErrorRate, 0, first)
+		stats.ResponseSeconds = smooth(stats.ResponseSeconds, elapsed.Seconds(), stats.LastSuccess.IsZero())
+		stats.LastSuccess = now
+	}
+	p.stats[key] = stats
+}
+
+// get returns the stats of `node` for `kind` as of `now`.
+func (p *providerScores) get(kind queryKind, node string, now time.Time) providerStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.stats[providerStatsKey{kind: kind, node: node}].decayed(now)
+}
+
+// best picks the node to serve `kind` among `nodes`: the configured priority
+// comes first, the score decides among nodes of the same priority and the
+// earlier node wins a tie.
+func (p *providerScores) best(kind queryKind, nodes []*Node, currentSlot int, now time.Time) *Node {
+	var best *Node
+	bestScore := 0.0
+	for _, node := range nodes {
+		score := p.get(kind, node.id, now).score(currentSlot - node.latestHead.slot)
+		if best == nil || node.config.Priority < best.config.Priority ||
+			(node.config.Priority == best.config.Priority && score > bestScore) {
+			best = node
+			bestScore = score
+		}
+	}
+	return best
+}
+
+type providerScoreResp struct {
+	ID       string  `json:"id"`
+	Label    string  `json:"label,omitempty"`
+	Priority int     `json:"priority"`
+	Score    float64 `json:"score"`
+	Lag      int     `json:"lag"`
+	Selected bool    `json:"selected"`
+	providerStats
+}
+
+// sendProviderScores serves the score of every candidate for each kind of
+// query and which of them is selected to serve it.
+func (m *Monitor) sendProviderScores(w http.ResponseWriter, r *http.Request) {
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+	now := time.Now()
+	resp := make(map[string][]providerScoreResp)
+	for kind, name := range queryKindNames {
+		selected := m.peekProviderFor(kind)
+		scores := []providerScoreResp{}
+		for _, node := range m.candidatesFor(kind) {
+			stats := m.providerScores.get(kind, node.id, now)
+			lag := currentSlot - node.latestHead.slot
+			scores = append(scores, providerScoreResp{
+				ID:            node.id,
+				Label:         node.label,
+				Priority:      node.config.Priority,
+				Score:         stats.score(lag),
+				Lag:           lag,
+				Selected:      node == selected,
+				providerStats: stats,
+			})
+		}
+		resp[name] = scores
+	}
+	writeJSON(w, r, resp)
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProviderScores(t *testing.T) {
+	nodes := []*Node{
+		{id: "a", latestHead: HeadRef{slot: 100}},
+		{id: "b", latestHead: HeadRef{slot: 100}},
+		{id: "c", latestHead: HeadRef{slot: 90}},
+	}
+	var scores providerScores
+	now := time.Now()
+
+	if best := scores.best(forkChoiceQuery, nodes, 100, now); best != nodes[0] {
+		t.Errorf("expected the first node without any queries, got %s", best.id)
+	}
+
+	scores.record(forkChoiceQuery, "a", time.Second, nil, now)
+	scores.record(forkChoiceQuery, "a", time.Second, errors.New("timeout"), now)
+	scores.record(forkChoiceQuery, "b", 100*time.Millisecond, nil, now)
+	scores.record(forkChoiceQuery, "c", 100*time.Millisecond, nil, now)
+	if stats := scores.get(forkChoiceQuery, "a", now); stats.Queries != 2 || stats.ErrorRate != providerScoreSmoothing || stats.ResponseSeconds != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if best := scores.best(forkChoiceQuery, nodes, 100, now); best != nodes[1] {
+		t.Errorf("expected the fast and fresh node to be preferred, got %s", best.id)
+	}
+	if best := scores.best(participationQuery, nodes, 100, now); best != nodes[0] {
+		t.Errorf("expected kinds of queries to be scored separately, got %s", best.id)
+	}
+
+	nodes[0].config.Priority = -1
+	if best := scores.best(forkChoiceQuery, nodes, 100, now); best != nodes[0] {
+		t.Errorf("expected the configured priority to come first, got %s", best.id)
+	}
+
+	// a node passed over after failing is selected again once its record
+	// fades and it serves without errors, even over a node that is behind
+	candidates := nodes[1:]
+	later := now.Add(time.Minute)
+	for i := 0; i < 5; i++ {
+		scores.record(forkChoiceQuery, "b", 100*time.Millisecond, errors.New("timeout"), later)
+	}
+	if best := scores.best(forkChoiceQuery, candidates, 100, later); best == candidates[0] {
+		t.Errorf("expected the failing node to be passed over")
+	}
+	recovered := later.Add(4 * providerScoreHalfLife)
+	if best := scores.best(forkChoiceQuery, candidates, 100, recovered); best != candidates[0] {
+		t.Errorf("expected the failing node to be tried again once its record faded, got %s", best.id)
+	}
+	scores.record(forkChoiceQuery, "b", 100*time.Millisecond, nil, recovered)
+	if best := scores.best(forkChoiceQuery, candidates, 100, recovered); best != candidates[0] {
+		t.Errorf("expected the recovered node to be selected, got %s", best.id)
+	}
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// selectProviders returns the nodes that may serve a role, most preferred
+// first. `role` reads the explicit assignment of the role from the endpoint
+// configuration; nodes without one are candidates if they run Lighthouse,
+// as the heavy queries use Lighthouse specific APIs.
+func selectProviders(nodes []*Node, role func(Endpoint) *bool) []*Node {
+	var candidates []*Node
+	for _, node := range nodes {
+		assigned := role(node.config)
+		if assigned != nil {
+			if *assigned {
+				candidates = append(candidates, node)
+			}
+			continue
+		}
+		if isLighthouse(node.version) {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].config.Priority < candidates[j].config.Priority
+	})
+	return candidates
+}
+
+// the kinds of heavy queries we send to provider nodes
+type queryKind int
+
+const (
+	forkChoiceQuery queryKind = iota
+	participationQuery
+	finalityQuery
+)
+
+type stickyProvider struct {
+	epoch int
+	node  *Node
+}
+
+func (m *Monitor) candidatesFor(kind queryKind) []*Node {
+	m.nodesLock.Lock()
+	defer m.nodesLock.Unlock()
+	if kind == participationQuery {
+		return m.participationProviders
+	}
+	return m.forkChoiceProviders
+}
+
+// providerFor picks the node to serve the given kind of query. By default this
+// is the best scoring available candidate of the most preferred priority.
+// With `balance_queries` the kinds of queries are spread round-robin over all
+// available candidates, keeping the same node for a given kind for the rest
+// of the epoch.
+func (m *Monitor) providerFor(kind queryKind) *Node {
+	return m.pickProvider(kind, true)
+}
+
+// peekProviderFor returns the node `providerFor` would pick without keeping
+// it for the rest of the epoch, for callers that only report the choice.
+func (m *Monitor) peekProviderFor(kind queryKind) *Node {
+	return m.pickProvider(kind, false)
+}
+
+func (m *Monitor) pickProvider(kind queryKind, keep bool) *Node {
+	candidates := m.candidatesFor(kind)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var available []*Node
+	for _, node := range candidates {
+		if node.isHealthy && !node.isSyncing && !node.backoff.active(now) {
+			available = append(available, node)
+		}
+	}
+	if len(available) == 0 {
+		return candidates[0]
+	}
+	if !m.config.BalanceQueries {
+		currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+		return m.providerScores.best(kind, available, currentSlot, now)
+	}
+
+	epoch := m.getCurrentEpoch()
+	m.providerLock.Lock()
+	defer m.providerLock.Unlock()
+	if m.stickyProviders == nil {
+		m.stickyProviders = make(map[queryKind]stickyProvider)
+	}
+	if sticky, ok := m.stickyProviders[kind]; ok && sticky.epoch == epoch {
+		for _, node := range available {
+			if node == sticky.node {
+				return node
+			}
+		}
+	}
+	index := (epoch + int(kind)) % len(available)
+	if index < 0 {
+		index += len(available)
+	}
+	node := available[index]
+	if keep {
+		m.stickyProviders[kind] = stickyProvider{epoch: epoch, node: node}
+	}
+	return node
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectProviders(t *testing.T) {
+	yes := true
+	no := false
+	nodes := []*Node{
+		{id: "a", version: "Lighthouse/v1.0.0"},
+		{id: "b", version: "Lighthouse/v1.0.0", config: Endpoint{ForkChoice: &no}},
+		{id: "c", version: "Lighthouse/v1.0.0", config: Endpoint{Priority: -1}},
+		{id: "d", version: "teku/v20.11.0", config: Endpoint{ForkChoice: &yes, Priority: 1}},
+		{id: "e", version: "teku/v20.11.0"},
+	}
+
+	providers := selectProviders(nodes, func(e Endpoint) *bool { return e.ForkChoice })
+	var ids []string
+	for _, node := range providers {
+		ids = append(ids, node.id)
+	}
+	if !reflect.DeepEqual(ids, []string{"c", "a", "d"}) {
+		t.Errorf("unexpected providers %v", ids)
+	}
+}
+
+func TestProviderFor(t *testing.T) {
+	nodes := []*Node{
+		{id: "a", isHealthy: true},
+		{id: "b", isHealthy: true},
+		{id: "c"},
+	}
+	m := &Monitor{
+		config:                 &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		forkChoiceProviders:    nodes,
+		participationProviders: nodes,
+	}
+
+	// without balancing, the preferred healthy node serves everything
+	for _, kind := range []queryKind{forkChoiceQuery, participationQuery, finalityQuery} {
+		if provider := m.providerFor(kind); provider.id != "a" {
+			t.Errorf("expected node a to serve query %d, got %s", kind, provider.id)
+		}
+	}
+
+	m.config.BalanceQueries = true
+	forkChoice := m.providerFor(forkChoiceQuery)
+	participation := m.providerFor(participationQuery)
+	if forkChoice == participation {
+		t.Errorf("expected queries to be spread over nodes, both went to %s", forkChoice.id)
+	}
+	if forkChoice.id == "c" || participation.id == "c" {
+		t.Error("unhealthy node selected as provider")
+	}
+	if m.providerFor(forkChoiceQuery) != forkChoice {
+		t.Error("expected provider to stay the same within an epoch")
+	}
+	if m.peekProviderFor(forkChoiceQuery) != forkChoice {
+		t.Error("expected the peeked provider to be the kept one")
+	}
+	m.peekProviderFor(finalityQuery)
+	if _, ok := m.stickyProviders[finalityQuery]; ok {
+		t.Error("expected peeking not to keep a provider")
+	}
+
+	nodes[0].isHealthy = false
+	nodes[1].isHealthy = false
+	if provider := m.providerFor(participationQuery); provider != nodes[0] {
+		t.Errorf("expected fallback to the preferred node, got %s", provider.id)
+	}
+}
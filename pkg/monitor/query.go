@@ -0,0 +1,238 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultNodesPerPage = 25
+const maxNodesPerPage = 1000
+
+// fieldSet is a tree of requested JSON field names parsed from a
+// `?fields=` query parameter. Nested fields are separated by dots, so
+// `nodes.slot` selects `slot` from every element of `nodes`.
+type fieldSet map[string]fieldSet
+
+func parseFields(raw string) fieldSet {
+	if raw == "" {
+		return nil
+	}
+	fields := fieldSet{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		current := fields
+		for _, name := range strings.Split(path, ".") {
+			next, ok := current[name]
+			if !ok {
+				next = fieldSet{}
+				current[name] = next
+			}
+			current = next
+		}
+	}
+	return fields
+}
+
+// apply drops every field of `data` not named in the set.
+// `data` is expected to be the generic form of some decoded JSON.
+func (f fieldSet) apply(data interface{}) interface{} {
+	if len(f) == 0 {
+		return data
+	}
+	switch value := data.(type) {
+	case map[string]interface{}:
+		filtered := make(map[string]interface{})
+		for name, inner := range f {
+			if field, ok := value[name]; ok {
+				filtered[name] = inner.apply(field)
+			}
+		}
+		return filtered
+	case []interface{}:
+		filtered := make([]interface{}, len(value))
+		for i, elem := range value {
+			filtered[i] = f.apply(elem)
+		}
+		return filtered
+	default:
+		return data
+	}
+}
+
+func (f fieldSet) filter(v interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// keep numbers as they are, large gwei amounts do not fit a float64
+	var data interface{}
+	err = unmarshalNumbers(encoded, &data)
+	if err != nil {
+		return nil, err
+	}
+	return f.apply(data), nil
+}
+
+// writeJSON sends `v` to the client, honoring any `?fields=` selection.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	body, err := jsonBody(r, v)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// jsonBody encodes `v` as the body of the response to `r`, keeping only
+// the `?fields=` asked for.
+func jsonBody(r *http.Request, v interface{}) ([]byte, error) {
+	if fields := parseFields(r.URL.Query().Get("fields")); fields != nil {
+		filtered, err := fields.filter(v)
+		if err != nil {
+			return nil, err
+		}
+		v = filtered
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+type pageInfo struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+// each comparator reports whether node `a` sorts before node `b`
+var nodeSorts = map[string]func(a, b *nodeResp) bool{
+	"slot":   func(a, b *nodeResp) bool { return a.Slot < b.Slot },
+	"lag":    func(a, b *nodeResp) bool { return a.Lag < b.Lag },
+	"health": func(a, b *nodeResp) bool { return a.Healthy && !b.Healthy },
+	"id":     func(a, b *nodeResp) bool { return a.ID < b.ID },
+}
+
+// matchesFilter checks `node` against terms like `client:lighthouse` or
+// `label:eu-west`; a bare term matches either the client or the label.
+func matchesFilter(node *nodeResp, terms []string) bool {
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		label := strings.ToLower(node.Label)
+		parts := strings.SplitN(term, ":", 2)
+		if len(parts) == 1 {
+			if node.Client != term && label != term {
+				return false
+			}
+			continue
+		}
+		switch parts[0] {
+		case "client":
+			if node.Client != parts[1] {
+				return false
+			}
+		case "label":
+			if label != parts[1] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func parsePositiveInt(query url.Values, name string, fallback int) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return value, nil
+}
+
+// applyNodeQuery filters, sorts and paginates `nodes` according to the
+// `filter`, `sort`, `page` and `per_page` query parameters. A sort key may be
+// prefixed with "-" to reverse the order. Pagination only applies if one of
+// the paging parameters is given.
+func applyNodeQuery(nodes []nodeResp, query url.Values) ([]nodeResp, *pageInfo, error) {
+	if filter := query.Get("filter"); filter != "" {
+		terms := strings.Split(filter, ",")
+		var matching []nodeResp
+		for i := range nodes {
+			if matchesFilter(&nodes[i], terms) {
+				matching = append(matching, nodes[i])
+			}
+		}
+		nodes = matching
+	}
+
+	if key := query.Get("sort"); key != "" {
+		descending := strings.HasPrefix(key, "-")
+		less, ok := nodeSorts[strings.TrimPrefix(key, "-")]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown sort key %q", key)
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if descending {
+				return less(&nodes[j], &nodes[i])
+			}
+			return less(&nodes[i], &nodes[j])
+		})
+	}
+
+	if query.Get("page") == "" && query.Get("per_page") == "" {
+		return nodes, nil, nil
+	}
+
+	page, err := parsePositiveInt(query, "page", 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	perPage, err := parsePositiveInt(query, "per_page", defaultNodesPerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if perPage > maxNodesPerPage {
+		perPage = maxNodesPerPage
+	}
+
+	pagination := &pageInfo{Page: page, PerPage: perPage, Total: len(nodes)}
+	// pages past the end are empty, checked first so the offset cannot overflow
+	start := len(nodes)
+	if page-1 <= len(nodes)/perPage {
+		start = (page - 1) * perPage
+	}
+	if start > len(nodes) {
+		start = len(nodes)
+	}
+	end := start + perPage
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+	return nodes[start:end], pagination, nil
+}
@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFieldFiltering(t *testing.T) {
+	resp := monitorResp{
+		Nodes: []nodeResp{
+			{ID: "a", Slot: 10, Root: "0xaa", Healthy: true},
+			{ID: "b", Slot: 11, Root: "0xbb"},
+		},
+		Justified: Checkpoint{Epoch: 1, Root: "0x01"},
+	}
+
+	filtered, err := parseFields("nodes.id,nodes.slot, justified_checkpoint").filter(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"nodes": []interface{}{
+			map[string]interface{}{"id": "a", "slot": "10"},
+			map[string]interface{}{"id": "b", "slot": "11"},
+		},
+		"justified_checkpoint": map[string]interface{}{"epoch": "1", "root": "0x01"},
+	}
+	if !reflect.DeepEqual(filtered, expected) {
+		t.Log(filtered)
+		t.Error("did not filter the expected fields")
+	}
+
+	if parseFields("") != nil {
+		t.Error("empty selection should not filter")
+	}
+}
+
+func TestFieldFilteringKeepsLargeNumbers(t *testing.T) {
+	resp := balancesResp{Epochs: []balanceEpoch{{Epoch: 1, TotalEffectiveGwei: 34000000000000001}}}
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/balances?fields=epochs.total_effective_gwei", nil)
+	body, err := jsonBody(request, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"epochs":[{"total_effective_gwei":34000000000000001}]}`+"\n" {
+		t.Errorf("expected the gwei amount to be kept exactly, got %s", body)
+	}
+}
+
+func idsOf(nodes []nodeResp) []string {
+	var ids []string
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestNodeQuery(t *testing.T) {
+	nodes := []nodeResp{
+		{ID: "a", Client: "lighthouse", Label: "eu", Slot: 10, Lag: 2, Healthy: true},
+		{ID: "b", Client: "prysm", Label: "us", Slot: 12, Lag: 0, Healthy: true},
+		{ID: "c", Client: "lighthouse", Label: "us", Slot: 9, Lag: 3},
+		{ID: "d", Client: "teku", Label: "eu", Slot: 11, Lag: 1, Healthy: true},
+	}
+
+	query := url.Values{"sort": {"-slot"}}
+	result, pagination, err := applyNodeQuery(append([]nodeResp{}, nodes...), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(idsOf(result), []string{"b", "d", "a", "c"}) || pagination != nil {
+		t.Errorf("unexpected sort by slot: %v", idsOf(result))
+	}
+
+	query = url.Values{"filter": {"client:lighthouse"}, "sort": {"lag"}}
+	result, _, err = applyNodeQuery(append([]nodeResp{}, nodes...), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(idsOf(result), []string{"a", "c"}) {
+		t.Errorf("unexpected filter by client: %v", idsOf(result))
+	}
+
+	query = url.Values{"filter": {"eu"}, "sort": {"id"}, "page": {"2"}, "per_page": {"1"}}
+	result, pagination, err = applyNodeQuery(append([]nodeResp{}, nodes...), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPage := &pageInfo{Page: 2, PerPage: 1, Total: 2}
+	if !reflect.DeepEqual(idsOf(result), []string{"d"}) || !reflect.DeepEqual(pagination, expectedPage) {
+		t.Errorf("unexpected page: %v %v", idsOf(result), pagination)
+	}
+
+	if _, _, err := applyNodeQuery(nodes, url.Values{"sort": {"color"}}); err == nil {
+		t.Error("expected unknown sort key to be rejected")
+	}
+	if _, _, err := applyNodeQuery(nodes, url.Values{"page": {"0"}}); err == nil {
+		t.Error("expected invalid page to be rejected")
+	}
+
+	huge := strconv.FormatInt(math.MaxInt64, 10)
+	result, pagination, err = applyNodeQuery(nodes, url.Values{"page": {huge}, "per_page": {huge}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 || pagination.PerPage != maxNodesPerPage {
+		t.Errorf("expected an empty page past the end, got %v %v", idsOf(result), pagination)
+	}
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import "sync"
+
+// bound on the requests in flight to all nodes if no `max_concurrent_requests` is set
+const defaultMaxConcurrentRequests = 32
+
+// cosmetic fetches leave at least this share of the slots to the others, so
+// streaming a large passthrough response or walking the chain for an API
+// client cannot hold up the head polls
+const cosmeticReserveDivisor = 4
+
+// fetchPriority orders the waiting fetches, the lowest value goes first.
+type fetchPriority int
+
+const (
+	headFetch fetchPriority = iota
+	finalityFetch
+	forkChoiceFetch
+	participationFetch
+	// peering, subnets, balances, the chain and anything else for display
+	cosmeticFetch
+	fetchPriorities
+)
+
+// fetchQueue caps the number of concurrent fetches. Once the cap is reached
+// a finished fetch hands its slot to the oldest waiting fetch of the highest
+// priority, so per-slot data stays fresh while heavy queries are backed up.
+// The zero value places no cap.
+type fetchQueue struct {
+	lock    sync.Mutex
+	limit   int
+	active  int
+	waiting [fetchPriorities][]chan struct{}
+}
+
+func (q *fetchQueue) setLimit(limit int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.limit = limit
+}
+
+// admits reports whether a fetch of `priority` may take a free slot.
+func (q *fetchQueue) admits(priority fetchPriority) bool {
+	if q.limit <= 0 {
+		return true
+	}
+	limit := q.limit
+	if priority == cosmeticFetch && limit > 1 {
+		reserve := limit / cosmeticReserveDivisor
+		if reserve < 1 {
+			reserve = 1
+		}
+		limit -= reserve
+	}
+	return q.active < limit
+}
+
+// acquire blocks until a fetch of `priority` may run; it must be followed by
+// a call to `release`.
+func (q *fetchQueue) acquire(priority fetchPriority) {
+	q.lock.Lock()
+	if q.admits(priority) {
+		q.active++
+		q.lock.Unlock()
+		return
+	}
+	ready := make(chan struct{})
+	q.waiting[priority] = append(q.waiting[priority], ready)
+	q.lock.Unlock()
+	<-ready
+}
+
+func (q *fetchQueue) release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.active--
+	for priority, waiting := range q.waiting {
+		if len(waiting) > 0 && q.admits(fetchPriority(priority)) {
+			close(waiting[0])
+			q.waiting[priority] = waiting[1:]
+			q.active++
+			return
+		}
+	}
+}
+
+// run performs `fetch` once the queue admits it.
+func (q *fetchQueue) run(priority fetchPriority, fetch func()) {
+	q.acquire(priority)
+	defer q.release()
+	fetch()
+}
+
+func (m *Monitor) maxConcurrentRequests() int {
+	if m.config.MaxConcurrentRequests > 0 {
+		return m.config.MaxConcurrentRequests
+	}
+	return defaultMaxConcurrentRequests
+}
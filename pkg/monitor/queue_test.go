@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func (q *fetchQueue) queued() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	queued := 0
+	for _, waiting := range q.waiting {
+		queued += len(waiting)
+	}
+	return queued
+}
+
+func TestFetchQueuePriority(t *testing.T) {
+	var queue fetchQueue
+	queue.setLimit(1)
+	queue.acquire(cosmeticFetch)
+
+	var order []fetchPriority
+	var wg sync.WaitGroup
+	priorities := []fetchPriority{cosmeticFetch, participationFetch, headFetch, forkChoiceFetch, headFetch}
+	for i, priority := range priorities {
+		wg.Add(1)
+		go func(priority fetchPriority) {
+			defer wg.Done()
+			// only one fetch runs at a time
+			queue.run(priority, func() { order = append(order, priority) })
+		}(priority)
+		// let the fetch join the queue before the next one
+		for queue.queued() < i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	queue.release()
+	wg.Wait()
+
+	expected := []fetchPriority{headFetch, headFetch, forkChoiceFetch, participationFetch, cosmeticFetch}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected fetches in order %v, got %v", expected, order)
+		}
+	}
+	if queue.active != 0 {
+		t.Errorf("expected all slots to be released, %d still active", queue.active)
+	}
+}
+
+func TestFetchQueueUnlimited(t *testing.T) {
+	var queue fetchQueue
+	for i := 0; i < 100; i++ {
+		queue.acquire(cosmeticFetch)
+	}
+	if queue.active != 100 || queue.queued() != 0 {
+		t.Error("expected the zero value not to cap fetches")
+	}
+}
+
+func TestFetchQueueReservesSlotsFromCosmeticFetches(t *testing.T) {
+	var queue fetchQueue
+	queue.setLimit(4)
+	for i := 0; i < 3; i++ {
+		queue.acquire(cosmeticFetch)
+	}
+
+	admitted := make(chan struct{})
+	go func() {
+		queue.acquire(cosmeticFetch)
+		close(admitted)
+	}()
+	for queue.queued() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// long running cosmetic fetches leave the last slot to the head poll
+	queue.acquire(headFetch)
+	queue.release()
+	select {
+	case <-admitted:
+		t.Fatal("expected the cosmetic fetch to wait while the head fetch runs")
+	case <-time.After(10 * time.Millisecond):
+	}
+	queue.release()
+	<-admitted
+	if queue.active != 3 || queue.queued() != 0 {
+		t.Errorf("expected 3 active fetches and none waiting, got %d and %d", queue.active, queue.queued())
+	}
+}
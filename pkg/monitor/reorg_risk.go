@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"fmt"
+)
+
+const reorgRiskAlert = "reorg_risk"
+const defaultReorgRiskThreshold = 0.3
+const defaultReorgRiskAlertSlots = 3
+
+// only forks this close to the head count towards the risk
+const reorgRiskWindowSlots = 4
+
+// reorgRisk compares the weight of the strongest competing branch near the
+// head with the weight of the canonical branch at the same fork point.
+type reorgRisk struct {
+	Score         float64 `json:"score"`
+	High          bool    `json:"high"`
+	ForkSlot      int     `json:"fork_slot,string,omitempty"`
+	CanonicalRoot string  `json:"canonical_root,omitempty"`
+	CompetingRoot string  `json:"competing_root,omitempty"`
+}
+
+func (m *Monitor) reorgRiskThreshold() float64 {
+	if m.config.ReorgRiskThreshold > 0 {
+		return m.config.ReorgRiskThreshold
+	}
+	return defaultReorgRiskThreshold
+}
+
+func (m *Monitor) reorgRiskAlertSlots() int {
+	if m.config.ReorgRiskAlertSlots > 0 {
+		return m.config.ReorgRiskAlertSlots
+	}
+	return defaultReorgRiskAlertSlots
+}
+
+func computeReorgRisk(protoArray []ProtoArrayNode, threshold float64) reorgRisk {
+	risk := reorgRisk{}
+	if len(protoArray) == 0 {
+		return risk
+	}
+
+	children := make(map[int][]int)
+	for i, node := range protoArray {
+		if node.ParentIndex != nil {
+			parent := int(*node.ParentIndex)
+			children[parent] = append(children[parent], i)
+		}
+	}
+
+	head := protoArrayHead(protoArray)
+	path := ancestorIndices(protoArray, protoArrayIndex(protoArray, head.Root))
+	for i := 1; i < len(path); i++ {
+		forkPoint := protoArray[path[i]]
+		if forkPoint.Slot < head.Slot-reorgRiskWindowSlots {
+			break
+		}
+		canonical := protoArray[path[i-1]]
+		if canonical.Weight <= 0 {
+			continue
+		}
+		for _, child := range children[path[i]] {
+			competing := protoArray[child]
+			if child == path[i-1] {
+				continue
+			}
+			score := competing.Weight / canonical.Weight
+			if score > risk.Score {
+				risk = reorgRisk{
+					Score:         score,
+					ForkSlot:      forkPoint.Slot,
+					CanonicalRoot: canonical.Root,
+					CompetingRoot: competing.Root,
+				}
+			}
+		}
+	}
+	risk.High = risk.Score >= threshold
+	return risk
+}
+
+// updateReorgRisk alerts once the risk has stayed high for several slots.
+func (m *Monitor) updateReorgRisk(protoArray []ProtoArrayNode) {
+	risk := computeReorgRisk(protoArray, m.reorgRiskThreshold())
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+
+	m.forkchoiceLock.Lock()
+	m.reorgRisk = &risk
+	if risk.High && !m.reorgRiskHigh {
+		m.reorgRiskSince = currentSlot
+	}
+	m.reorgRiskHigh = risk.High
+	since := m.reorgRiskSince
+	m.forkchoiceLock.Unlock()
+
+	if !risk.High {
+		m.alerts.resolve(reorgRiskAlert)
+	} else if currentSlot-since+1 >= m.reorgRiskAlertSlots() {
+		message := fmt.Sprintf("competing branch at slot %d has %.0f%% of the weight of the canonical branch since slot %d", risk.ForkSlot, risk.Score*100, since)
+		m.alerts.raise(reorgRiskAlert, SeverityWarning, message)
+	}
+}
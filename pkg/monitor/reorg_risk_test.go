@@ -0,0 +1,30 @@
+package monitor
+
+import "testing"
+
+func TestComputeReorgRisk(t *testing.T) {
+	zero := float64(0)
+	one := float64(1)
+	protoArray := []ProtoArrayNode{
+		{Slot: 10, Root: hash("10"), Weight: 100, BestDescendant: 2},
+		{Slot: 11, Root: hash("11"), ParentIndex: &zero, Weight: 60},
+		{Slot: 12, Root: hash("12"), ParentIndex: &one, Weight: 60},
+		{Slot: 12, Root: hash("12'"), ParentIndex: &one, Weight: 0},
+		{Slot: 11, Root: hash("11'"), ParentIndex: &zero, Weight: 30},
+	}
+
+	risk := computeReorgRisk(protoArray, 0.3)
+	if risk.Score != 0.5 || !risk.High || risk.CompetingRoot != hash("11'") || risk.ForkSlot != 10 {
+		t.Errorf("unexpected reorg risk %+v", risk)
+	}
+
+	risk = computeReorgRisk(protoArray, 0.6)
+	if risk.High {
+		t.Errorf("expected risk below the threshold, got %+v", risk)
+	}
+
+	protoArray[4].Weight = 0
+	if risk := computeReorgRisk(protoArray, 0.3); risk.Score != 0 || risk.High {
+		t.Errorf("expected no risk without competing weight, got %+v", risk)
+	}
+}
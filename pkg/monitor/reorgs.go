@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BlockRef identifies a block in the fork choice.
+type BlockRef struct {
+	Slot int    `json:"slot,string"`
+	Root string `json:"root"`
+}
+
+// Reorg is a change of the canonical head to a block that does not
+// descend from the previous head.
+type Reorg struct {
+	DetectedAt     time.Time `json:"detected_at"`
+	Epoch          int       `json:"epoch"`
+	Depth          int       `json:"depth"`
+	OldHead        BlockRef  `json:"old_head"`
+	NewHead        BlockRef  `json:"new_head"`
+	CommonAncestor BlockRef  `json:"common_ancestor"`
+	// the fork choice provider that observed it
+	Source string `json:"source"`
+}
+
+func protoArrayIndex(protoArray []ProtoArrayNode, root string) int {
+	for i, node := range protoArray {
+		if node.Root == root {
+			return i
+		}
+	}
+	return -1
+}
+
+// ancestorIndices walks the parent links from `index` back to the root of `protoArray`.
+func ancestorIndices(protoArray []ProtoArrayNode, index int) []int {
+	var indices []int
+	for index >= 0 && index < len(protoArray) && len(indices) < len(protoArray) {
+		indices = append(indices, index)
+		parent := protoArray[index].ParentIndex
+		if parent == nil {
+			break
+		}
+		index = int(*parent)
+	}
+	return indices
+}
+
+// detectReorg returns the reorg away from `previousRoot` if the head of
+// `protoArray` does not descend from it. If the previous head is no longer
+// in the proto array nothing can be said and nil is returned.
+func detectReorg(protoArray []ProtoArrayNode, previousRoot string) *Reorg {
+	if len(protoArray) == 0 || previousRoot == "" {
+		return nil
+	}
+	head := protoArrayHead(protoArray)
+	canonical := make(map[string]bool)
+	for _, i := range ancestorIndices(protoArray, protoArrayIndex(protoArray, head.Root)) {
+		canonical[protoArray[i].Root] = true
+	}
+	if canonical[previousRoot] {
+		return nil
+	}
+
+	previousIndex := protoArrayIndex(protoArray, previousRoot)
+	for _, i := range ancestorIndices(protoArray, previousIndex) {
+		if !canonical[protoArray[i].Root] {
+			continue
+		}
+		previous := protoArray[previousIndex]
+		common := protoArray[i]
+		return &Reorg{
+			Depth:          previous.Slot - common.Slot,
+			OldHead:        BlockRef{Slot: previous.Slot, Root: previous.Root},
+			NewHead:        BlockRef{Slot: head.Slot, Root: head.Root},
+			CommonAncestor: BlockRef{Slot: common.Slot, Root: common.Root},
+		}
+	}
+	return nil
+}
+
+// reorgLog follows the canonical head to detect reorgs; the zero value is
+// ready to use.
+type reorgLog struct {
+	lock     sync.Mutex
+	lastHead string
+}
+
+// observe returns a reorg if the head of `protoArray` does not build on the
+// head seen on the previous call.
+func (l *reorgLog) observe(protoArray []ProtoArrayNode, source string, slotsPerEpoch int, now time.Time) *Reorg {
+	if len(protoArray) == 0 {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	reorg := detectReorg(protoArray, l.lastHead)
+	l.lastHead = protoArrayHead(protoArray).Root
+	if reorg == nil {
+		return nil
+	}
+	reorg.DetectedAt = now
+	reorg.Epoch = reorg.NewHead.Slot / slotsPerEpoch
+	reorg.Source = source
+	return reorg
+}
+
+// reset forgets the last head, e.g. when it is no longer part of the chain.
+func (l *reorgLog) reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lastHead = ""
+}
+
+func (m *Monitor) recordReorg(protoArray []ProtoArrayNode, source string) {
+	reorg := m.reorgs.observe(protoArray, source, m.config.Eth2.SlotsPerEpoch, time.Now())
+	if reorg != nil {
+		m.events.publish(ReorgDetected{Reorg: *reorg, ProtoArray: protoArray})
+	}
+}
+
+type reorgsResp struct {
+	Reorgs []Reorg `json:"reorgs"`
+}
+
+// sendReorgs serves the observed reorgs, optionally only those of `?epoch=`.
+func (m *Monitor) sendReorgs(w http.ResponseWriter, r *http.Request) {
+	epoch := -1
+	if value := r.URL.Query().Get("epoch"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid epoch", http.StatusBadRequest)
+			return
+		}
+		epoch = parsed
+	}
+	writeJSON(w, r, &reorgsResp{Reorgs: m.reorgHistory(epoch)})
+}
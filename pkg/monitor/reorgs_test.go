@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// protoArrayWithHead builds a proto array where each entry is (slot, parent index),
+// with the best descendant of the root set to `head`.
+func protoArrayWithHead(head int, blocks ...[2]int) []ProtoArrayNode {
+	var protoArray []ProtoArrayNode
+	for i, block := range blocks {
+		node := ProtoArrayNode{Slot: block[0], Root: hash(strconv.Itoa(i))}
+		if block[1] >= 0 {
+			parent := float64(block[1])
+			node.ParentIndex = &parent
+		}
+		protoArray = append(protoArray, node)
+	}
+	protoArray[0].BestDescendant = float64(head)
+	return protoArray
+}
+
+func TestDetectReorg(t *testing.T) {
+	// 0 <- 1 <- 2 <- 3
+	//        \- 4 <- 5
+	blocks := [][2]int{{0, -1}, {1, 0}, {2, 1}, {3, 2}, {2, 1}, {4, 4}}
+
+	if detectReorg(protoArrayWithHead(3, blocks...), hash(strconv.Itoa(2))) != nil {
+		t.Error("expected no reorg when the head builds on the previous head")
+	}
+
+	reorg := detectReorg(protoArrayWithHead(5, blocks...), hash(strconv.Itoa(3)))
+	if reorg == nil {
+		t.Fatal("expected a reorg")
+	}
+	if reorg.Depth != 2 || reorg.CommonAncestor.Root != hash(strconv.Itoa(1)) || reorg.NewHead.Root != hash(strconv.Itoa(5)) {
+		t.Errorf("unexpected reorg %+v", reorg)
+	}
+
+	if detectReorg(protoArrayWithHead(5, blocks...), hash("pruned")) != nil {
+		t.Error("expected no reorg for an unknown previous head")
+	}
+}
+
+func TestReorgLog(t *testing.T) {
+	blocks := [][2]int{{0, -1}, {1, 0}, {2, 1}, {3, 2}, {2, 1}, {33, 4}}
+	var reorgs reorgLog
+	now := time.Now()
+
+	if reorgs.observe(protoArrayWithHead(3, blocks...), "a", 32, now) != nil {
+		t.Error("expected no reorg on the first observation")
+	}
+	reorg := reorgs.observe(protoArrayWithHead(5, blocks...), "a", 32, now)
+	if reorg == nil {
+		t.Fatal("expected a reorg")
+	}
+	if reorg.Epoch != 1 || reorg.Source != "a" || !reorg.DetectedAt.Equal(now) {
+		t.Errorf("unexpected reorg %+v", reorg)
+	}
+	if reorgs.observe(protoArrayWithHead(5, blocks...), "a", 32, now) != nil {
+		t.Error("expected no reorg while the head is unchanged")
+	}
+}
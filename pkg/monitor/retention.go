@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+const defaultHeadObservationRetention = 7 * 24 * time.Hour
+const defaultParticipationRetention = 90 * 24 * time.Hour
+
+// reorgs are rare and valuable after an incident so keep them forever
+const defaultReorgRetention = -1
+const retentionPruneInterval = 10 * time.Minute
+
+func retentionOrDefault(retention time.Duration, fallback time.Duration) time.Duration {
+	if retention == 0 {
+		return fallback
+	}
+	return retention
+}
+
+func (m *Monitor) headObservationRetention() time.Duration {
+	return retentionOrDefault(m.config.Retention.HeadObservations, defaultHeadObservationRetention)
+}
+
+func (m *Monitor) participationRetention() time.Duration {
+	return retentionOrDefault(m.config.Retention.Participation, defaultParticipationRetention)
+}
+
+func (m *Monitor) reorgRetention() time.Duration {
+	return retentionOrDefault(m.config.Retention.Reorgs, defaultReorgRetention)
+}
+
+func (m *Monitor) epochStartTime(epoch int) time.Time {
+	config := m.eth2()
+	return time.Unix(int64(config.GenesisTime+epoch*config.SlotsPerEpoch*config.SecondsPerSlot), 0)
+}
+
+// prune drops any data older than its configured retention
+func (m *Monitor) prune(now time.Time) {
+	if retention := m.headObservationRetention(); retention > 0 {
+		m.samples.pruneBefore(now.Add(-retention))
+	}
+	if retention := m.participationRetention(); retention > 0 {
+		err := m.store().PruneParticipation(m.firstEpochFrom(now.Add(-retention)))
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	if retention := m.reorgRetention(); retention > 0 {
+		err := m.store().PruneReorgs(now.Add(-retention))
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	if retention := m.protoArraySnapshotRetention(); retention > 0 && m.config.ProtoArraySnapshots && m.config.DataDir != "" {
+		err := m.pruneProtoArraySnapshots(now.Add(-retention))
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	// arrivals are only needed for the recent slots of the ledger
+	config := m.eth2()
+	currentEpoch := computeCurrentSlot(config.GenesisTime, config.SecondsPerSlot) / config.SlotsPerEpoch
+	m.arrivals.pruneBefore((currentEpoch - slotLedgerHistoryEpochs) * config.SlotsPerEpoch)
+}
+
+func (m *Monitor) startPruner(beat func() bool) {
+	for beat() {
+		m.prune(time.Now())
+		time.Sleep(retentionPruneInterval)
+	}
+}
+
+type storeEntryStats struct {
+	Entries int        `json:"entries"`
+	Oldest  *time.Time `json:"oldest"`
+	// -1 if entries are kept forever
+	RetentionSeconds int64 `json:"retention_seconds"`
+}
+
+type storeStatsResp struct {
+	HeadObservations storeEntryStats `json:"head_observations"`
+	Participation    storeEntryStats `json:"participation"`
+	Reorgs           storeEntryStats `json:"reorgs"`
+}
+
+func newStoreEntryStats(entries int, oldest time.Time, retention time.Duration) storeEntryStats {
+	stats := storeEntryStats{
+		Entries:          entries,
+		RetentionSeconds: int64(retention / time.Second),
+	}
+	if retention < 0 {
+		stats.RetentionSeconds = -1
+	}
+	if !oldest.IsZero() {
+		stats.Oldest = &oldest
+	}
+	return stats
+}
+
+func (m *Monitor) sendStoreStats(w http.ResponseWriter, r *http.Request) {
+	sampleCount, oldestSample := m.samples.stats()
+
+	participation := m.participationHistory()
+	oldestParticipation := time.Time{}
+	if len(participation) > 0 {
+		oldestParticipation = m.epochStartTime(participation[0].Epoch)
+	}
+
+	reorgs := m.reorgHistory(-1)
+	oldestReorg := time.Time{}
+	if len(reorgs) > 0 {
+		oldestReorg = reorgs[0].DetectedAt
+	}
+
+	resp := storeStatsResp{
+		HeadObservations: newStoreEntryStats(sampleCount, oldestSample, m.headObservationRetention()),
+		Participation:    newStoreEntryStats(len(participation), oldestParticipation, m.participationRetention()),
+		Reorgs:           newStoreEntryStats(len(reorgs), oldestReorg, m.reorgRetention()),
+	}
+	writeJSON(w, r, &resp)
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRetentionConfig(t *testing.T) {
+	config := Config{}
+	err := yaml.Unmarshal([]byte("retention:\n head_observations: 48h\n participation: -1s\n"), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Monitor{config: &config}
+	if m.headObservationRetention() != 48*time.Hour {
+		t.Errorf("unexpected head observation retention %s", m.headObservationRetention())
+	}
+	if m.participationRetention() >= 0 {
+		t.Error("expected participation to be kept forever")
+	}
+
+	m.config.Retention = RetentionConfig{}
+	if m.participationRetention() != defaultParticipationRetention {
+		t.Error("expected default participation retention")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	genesis := time.Now().Add(-100 * 24 * time.Hour)
+	m := &Monitor{
+		config: &Config{
+			Eth2: Eth2Config{GenesisTime: int(genesis.Unix()), SecondsPerSlot: 12, SlotsPerEpoch: 32},
+		},
+		samples: newSampleStore(),
+	}
+	epochsPerDay := 24 * 3600 / (12 * 32)
+	m.store().SaveParticipation(
+		Participation{Epoch: 5 * epochsPerDay},
+		Participation{Epoch: 50 * epochsPerDay},
+		Participation{Epoch: 99 * epochsPerDay},
+	)
+	now := time.Now()
+	m.samples.append("a", nodeSample{Time: now.Add(-8 * 24 * time.Hour)})
+	m.samples.append("a", nodeSample{Time: now.Add(-time.Hour)})
+
+	m.prune(now)
+
+	if participation := m.participationHistory(); len(participation) != 2 || participation[0].Epoch != 50*epochsPerDay {
+		t.Errorf("unexpected participation after prune: %v", participation)
+	}
+	if count, _ := m.samples.stats(); count != 1 {
+		t.Errorf("expected a single sample after prune, have %d", count)
+	}
+}
@@ -0,0 +1,45 @@
+package monitor
+
+// a block is safe once the votes for it reach this share of the weight
+// behind the justified checkpoint
+const safeHeadQuorum = 2.0 / 3.0
+
+// computeSafeHead returns the most recent canonical block descending from the
+// justified checkpoint that is supported by a supermajority of the weight on
+// that checkpoint, mirroring the "safe" block tag of the execution APIs.
+// If the justified block is not in `protoArray` the anchor of the proto array
+// is used instead.
+func computeSafeHead(protoArray []ProtoArrayNode, justifiedRoot string) *BlockRef {
+	if len(protoArray) == 0 {
+		return nil
+	}
+
+	justifiedIndex := protoArrayIndex(protoArray, justifiedRoot)
+	if justifiedIndex < 0 {
+		justifiedIndex = 0
+	}
+	head := protoArrayHead(protoArray)
+	path := ancestorIndices(protoArray, protoArrayIndex(protoArray, head.Root))
+
+	justifiedDepth := -1
+	for i, index := range path {
+		if index == justifiedIndex {
+			justifiedDepth = i
+			break
+		}
+	}
+	if justifiedDepth < 0 {
+		// the head does not descend from the justified checkpoint
+		return nil
+	}
+
+	quorum := protoArray[justifiedIndex].Weight * safeHeadQuorum
+	for _, index := range path[:justifiedDepth] {
+		node := protoArray[index]
+		if node.Weight >= quorum {
+			return &BlockRef{Slot: node.Slot, Root: node.Root}
+		}
+	}
+	justified := protoArray[justifiedIndex]
+	return &BlockRef{Slot: justified.Slot, Root: justified.Root}
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import "testing"
+
+func TestComputeSafeHead(t *testing.T) {
+	zero := float64(0)
+	one := float64(1)
+	two := float64(2)
+	protoArray := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 120, BestDescendant: 3},
+		{Slot: 8, Root: hash("8"), ParentIndex: &zero, Weight: 90},
+		{Slot: 9, Root: hash("9"), ParentIndex: &one, Weight: 70},
+		{Slot: 10, Root: hash("10"), ParentIndex: &two, Weight: 40},
+		{Slot: 9, Root: hash("9'"), ParentIndex: &one, Weight: 20},
+	}
+
+	safe := computeSafeHead(protoArray, hash("8"))
+	if safe == nil || safe.Root != hash("9") {
+		t.Errorf("expected slot 9 to be safe, got %+v", safe)
+	}
+
+	safe = computeSafeHead(protoArray, hash("unknown"))
+	if safe == nil || safe.Root != hash("8") {
+		t.Errorf("expected the anchor quorum to make slot 8 safe, got %+v", safe)
+	}
+
+	safe = computeSafeHead(protoArray, hash("9'"))
+	if safe != nil {
+		t.Errorf("expected no safe head off the justified branch, got %+v", safe)
+	}
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// shorter secrets are not scrubbed as they would mangle unrelated text
+const minSecretLength = 4
+
+const redacted = "***"
+
+// redactURL returns `raw` in a form fit for logs and API output: only the
+// scheme, host and port are kept, credentials, paths (which often embed API
+// keys) and queries are masked.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(malformed URL)"
+	}
+	display := u.Scheme + "://"
+	if u.User != nil {
+		display += redacted + "@"
+	}
+	display += u.Host
+	if strings.Trim(u.Path, "/") != "" {
+		display += "/" + redacted
+	}
+	if u.RawQuery != "" {
+		display += "?" + redacted
+	}
+	return display
+}
+
+// splitCredentials separates the basic auth credentials from `addr`, so
+// the address used to build request URLs carries no secrets.
+func splitCredentials(addr string) (string, *url.Userinfo) {
+	u, err := url.Parse(addr)
+	if err != nil || u.User == nil {
+		return addr, nil
+	}
+	user := u.User
+	u.User = nil
+	return u.String(), user
+}
+
+// basicAuthTransport adds the credentials split off an endpoint to every request.
+type basicAuthTransport struct {
+	next http.RoundTripper
+	user *url.Userinfo
+}
+
+func (t *basicAuthTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request it is given
+	request = request.Clone(request.Context())
+	password, _ := t.user.Password()
+	request.SetBasicAuth(t.user.Username(), password)
+	return t.next.RoundTrip(request)
+}
+
+// secretSet holds the credentials found in the configuration so they can be
+// scrubbed from log lines and API output; the zero value is ready to use.
+type secretSet struct {
+	lock         sync.RWMutex
+	replacements map[string]string
+	replacer     *strings.Replacer
+}
+
+var secrets secretSet
+
+func (s *secretSet) add(secret string, replacement string) {
+	if len(secret) < minSecretLength || secret == replacement {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.replacements == nil {
+		s.replacements = make(map[string]string)
+	}
+	s.replacements[secret] = replacement
+
+	// replace the longest secrets first so a URL is not partially replaced
+	// by a credential it contains
+	all := make([]string, 0, len(s.replacements))
+	for secret := range s.replacements {
+		all = append(all, secret)
+	}
+	sort.Slice(all, func(i, j int) bool { return len(all[i]) > len(all[j]) })
+	pairs := make([]string, 0, 2*len(all))
+	for _, secret := range all {
+		pairs = append(pairs, secret, s.replacements[secret])
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+}
+
+// addURL registers the forms in which `raw` may show up in text, including
+// the one the HTTP client uses in errors, with its password masked.
+func (s *secretSet) addURL(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return
+	}
+	display := redactURL(raw)
+	forms := []string{raw}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			s.add(password, redacted)
+			forms = append(forms, strings.Replace(raw, u.User.String()+"@", u.User.Username()+":***@", 1))
+		}
+		stripped, _ := splitCredentials(raw)
+		forms = append(forms, stripped)
+	}
+	for _, form := range forms {
+		s.add(strings.TrimRight(form, "/"), display)
+	}
+}
+
+func (s *secretSet) redact(text string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.replacer == nil {
+		return text
+	}
+	return s.replacer.Replace(text)
+}
+
+// registerSecrets collects every credential in `config`.
+func registerSecrets(config *Config) {
+	for _, endpoint := range config.Endpoints {
+		secrets.addURL(endpoint.Addr)
+		secrets.addURL(endpoint.Metrics)
+		secrets.addURL(endpoint.Proxy)
+	}
+	secrets.addURL(config.ExecutionEndpoint)
+	secrets.addURL(config.WSProviderEndpoint)
+	secrets.add(config.EtherscanAPIKey, redacted)
+	for _, key := range config.APIKeys {
+		secrets.add(key.Key, redacted)
+	}
+}
+
+// redactingWriter scrubs the registered secrets from everything logged.
+type redactingWriter struct {
+	out io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(w.out, secrets.redact(string(p)))
+	return len(p), err
+}
+
+func redactLogs() {
+	if _, ok := log.Writer().(redactingWriter); !ok {
+		log.SetOutput(redactingWriter{out: log.Writer()})
+	}
+}
@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outcomes of a self-test check
+const (
+	SelfTestPass = "pass"
+	SelfTestFail = "fail"
+	SelfTestSkip = "skip"
+)
+
+// the self-test writes its state here instead of over the real one
+const selfTestStateFileName = "selftest-" + stateFileName
+
+// SelfTestResult is the outcome of one stage of the pipeline.
+type SelfTestResult struct {
+	Check  string
+	Result string
+	Detail string
+}
+
+func selfTestResult(check string, err error, detail string) SelfTestResult {
+	if err != nil {
+		return SelfTestResult{Check: check, Result: SelfTestFail, Detail: secrets.redact(err.Error())}
+	}
+	return SelfTestResult{Check: check, Result: SelfTestPass, Detail: detail}
+}
+
+// SelfTest runs each stage of the pipeline once against the configured nodes:
+// discovery, a head poll, the finality, fork choice and participation
+// fetches and a write to the data directory that leaves the saved state
+// alone. Later stages are still run if an earlier one fails.
+func SelfTest(config *Config) []SelfTestResult {
+	m, err := FromConfig(config)
+	if err != nil {
+		return []SelfTestResult{selfTestResult("startup", err, "")}
+	}
+	var results []SelfTestResult
+
+	nodes := m.nodeList()
+	reachable := fmt.Sprintf("%d of %d endpoints reachable", len(nodes), len(config.Endpoints))
+	if len(nodes) < len(config.Endpoints) {
+		err = errors.New(reachable)
+	}
+	results = append(results, selfTestResult("discovery", err, reachable))
+
+	results = append(results, m.selfTestHeads(nodes))
+
+	provider := m.providerFor(finalityQuery)
+	if provider == nil {
+		results = append(results, selfTestResult("finality", errors.New("no finality provider available"), ""))
+	} else {
+		err = m.updateFinality(provider)
+		results = append(results, selfTestResult("finality", err, fmt.Sprintf("finalized epoch %d from %s", m.finalizedCheckpoint.Epoch, provider.id)))
+	}
+
+	provider = m.providerFor(forkChoiceQuery)
+	m.setForkChoiceProvider(provider)
+	if provider == nil {
+		results = append(results, selfTestResult("fork choice", errors.New("no fork choice provider available"), ""))
+	} else {
+		err = m.buildLatestForkChoiceSummary()
+		detail := ""
+		if head := m.forkChoiceHead; head != nil {
+			detail = fmt.Sprintf("head %s at slot %d from %s", head.Root, head.Slot, provider.id)
+		}
+		results = append(results, selfTestResult("fork choice", err, detail))
+	}
+
+	err = m.fetchLatestParticipation()
+	detail := ""
+	if err == nil {
+		participation := m.participationHistory()
+		detail = fmt.Sprintf("epoch %d from %s", participation[len(participation)-1].Epoch, m.participationProvider().id)
+	}
+	results = append(results, selfTestResult("participation", err, detail))
+
+	results = append(results, m.selfTestStorage())
+	return results
+}
+
+// selfTestHeads polls the head of every node once.
+func (m *Monitor) selfTestHeads(nodes []*Node) SelfTestResult {
+	if len(nodes) == 0 {
+		return selfTestResult("head poll", errors.New("no nodes to poll"), "")
+	}
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go node.fetchLatestHead(&wg)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, node := range nodes {
+		if !node.isHealthy {
+			failed = append(failed, node.id)
+		}
+	}
+	if len(failed) > 0 {
+		return selfTestResult("head poll", fmt.Errorf("no head from %s", strings.Join(failed, ", ")), "")
+	}
+	return selfTestResult("head poll", nil, fmt.Sprintf("%d nodes responded", len(nodes)))
+}
+
+// selfTestStorage writes the state to a scratch file in the data directory,
+// reads it back and removes it.
+func (m *Monitor) selfTestStorage() SelfTestResult {
+	if m.config.DataDir == "" {
+		return SelfTestResult{Check: "storage", Result: SelfTestSkip, Detail: "no `data_dir` configured"}
+	}
+	path := filepath.Join(m.config.DataDir, selfTestStateFileName)
+	err := writeState(path, m.snapshotState())
+	if err != nil {
+		return selfTestResult("storage", err, "")
+	}
+	defer os.Remove(path)
+	_, err = readState(path)
+	return selfTestResult("storage", err, fmt.Sprintf("wrote and read back %s", path))
+}
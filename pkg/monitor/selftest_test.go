@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	node := beaconStub("peer-a")
+	defer node.Close()
+	dataDir := t.TempDir()
+
+	config := &Config{
+		Endpoints:           []Endpoint{{Addr: node.URL}},
+		Eth2:                Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32},
+		DataDir:             dataDir,
+		MillisecondsTimeout: 1000,
+	}
+	results := make(map[string]SelfTestResult)
+	for _, result := range SelfTest(config) {
+		results[result.Check] = result
+	}
+
+	// the stub serves heads but no fork choice or participation data
+	expected := map[string]string{
+		"discovery":     SelfTestPass,
+		"head poll":     SelfTestPass,
+		"finality":      SelfTestFail,
+		"fork choice":   SelfTestFail,
+		"participation": SelfTestFail,
+		"storage":       SelfTestPass,
+	}
+	for check, outcome := range expected {
+		if results[check].Result != outcome {
+			t.Errorf("expected %s to %s, got %+v", check, outcome, results[check])
+		}
+	}
+
+	entries, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the self-test to leave no files behind, found %d", len(entries))
+	}
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+)
+
+// responses of signed endpoints carry an ed25519 signature over the exact
+// body bytes in these headers
+const signatureHeader = "X-Signature"
+const signatureKeyIDHeader = "X-Signature-Key-Id"
+
+// loadSigningKey reads a PEM encoded PKCS #8 ed25519 private key, e.g. as
+// generated with `openssl genpkey -algorithm ed25519`.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in signing key file %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signing key %s: %v", path, err)
+	}
+	signingKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an ed25519 key")
+	}
+	return signingKey, nil
+}
+
+// keyID is a short fingerprint of the public key so consumers can tell keys apart
+func keyID(publicKey ed25519.PublicKey) string {
+	digest := sha256.Sum256(publicKey)
+	return hex.EncodeToString(digest[:8])
+}
+
+// writeSignedJSON is writeJSON that also signs the body if a signing key is configured.
+func (m *Monitor) writeSignedJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	if m.signingKey == nil {
+		writeJSON(w, r, v)
+		return
+	}
+
+	body, err := jsonBody(r, v)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	signature := ed25519.Sign(m.signingKey, body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set(signatureHeader, base64.StdEncoding.EncodeToString(signature))
+	w.Header().Set(signatureKeyIDHeader, keyID(m.signingKey.Public().(ed25519.PublicKey)))
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+type signingKeyResp struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	// base64 encoded raw public key
+	PublicKey string `json:"public_key"`
+}
+
+// sendSigningKey publishes the public key to verify signed responses with.
+func (m *Monitor) sendSigningKey(w http.ResponseWriter, r *http.Request) {
+	if m.signingKey == nil {
+		http.NotFound(w, r)
+		return
+	}
+	publicKey := m.signingKey.Public().(ed25519.PublicKey)
+	resp := signingKeyResp{
+		Algorithm: "ed25519",
+		KeyID:     keyID(publicKey),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+	writeJSON(w, r, &resp)
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignedSummary(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingKey, err := loadSigningKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Monitor{
+		config:     &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		signingKey: signingKey,
+	}
+
+	recorder := httptest.NewRecorder()
+	m.sendSummary(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/summary", nil))
+	signature, err := base64.StdEncoding.DecodeString(recorder.Header().Get(signatureHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, recorder.Body.Bytes(), signature) {
+		t.Error("signature does not verify")
+	}
+	if recorder.Header().Get(signatureKeyIDHeader) != keyID(publicKey) {
+		t.Error("unexpected key id")
+	}
+}
+
+func TestUnusableSigningKeyFailsStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	err := ioutil.WriteFile(path, []byte("not a key"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FromConfig(&Config{SigningKeyFile: path})
+	if err == nil {
+		t.Error("expected an error for an unusable signing key")
+	}
+	_, err = FromConfig(&Config{SigningKeyFile: filepath.Join(t.TempDir(), "missing.pem")})
+	if err == nil {
+		t.Error("expected an error for a missing signing key")
+	}
+}
@@ -0,0 +1,247 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const proposerDutiesPathFmt = "/eth/v1/validator/duties/proposer/%d"
+
+const defaultSlotLedgerEpochs = 2
+
+// how many epochs of arrivals and duties are kept for the ledger
+const slotLedgerHistoryEpochs = 4
+
+const (
+	SlotOnTime   = "on_time"
+	SlotLate     = "late"
+	SlotIncluded = "included"
+	SlotMissed   = "missed"
+	SlotOrphaned = "orphaned"
+	SlotPending  = "pending"
+)
+
+type blockArrival struct {
+	slot      int
+	firstSeen time.Time
+}
+
+// arrivalLog is when each block was first seen as the head of any node; the
+// zero value is ready to use.
+type arrivalLog struct {
+	lock     sync.Mutex
+	arrivals map[string]blockArrival
+}
+
+func (l *arrivalLog) observe(nodes []*Node, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.arrivals == nil {
+		l.arrivals = make(map[string]blockArrival)
+	}
+	for _, node := range nodes {
+		head := node.latestHead
+		if head.root == "" {
+			continue
+		}
+		if _, ok := l.arrivals[head.root]; ok {
+			continue
+		}
+		l.arrivals[head.root] = blockArrival{slot: head.slot, firstSeen: now}
+	}
+}
+
+func (l *arrivalLog) pruneBefore(slot int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for root, arrival := range l.arrivals {
+		if arrival.slot < slot {
+			delete(l.arrivals, root)
+		}
+	}
+}
+
+func (l *arrivalLog) copy() map[string]blockArrival {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	arrivals := make(map[string]blockArrival, len(l.arrivals))
+	for root, arrival := range l.arrivals {
+		arrivals[root] = arrival
+	}
+	return arrivals
+}
+
+// proposerDuties caches the proposer of each slot by epoch; the zero value is ready to use.
+type proposerDuties struct {
+	lock    sync.Mutex
+	byEpoch map[int]map[int]string
+}
+
+type proposerDutiesResp struct {
+	Data []struct {
+		ValidatorIndex string `json:"validator_index"`
+		Slot           int    `json:"slot,string"`
+	} `json:"data"`
+}
+
+func (n *Node) fetchProposerDuties(epoch int) (_ map[int]string, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.endpoint + fmt.Sprintf(proposerDutiesPathFmt, epoch))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data := proposerDutiesResp{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return nil, err
+	}
+	duties := make(map[int]string)
+	for _, duty := range data.Data {
+		duties[duty.Slot] = duty.ValidatorIndex
+	}
+	return duties, nil
+}
+
+func (d *proposerDuties) forEpoch(node *Node, epoch int) (map[int]string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if duties, ok := d.byEpoch[epoch]; ok {
+		return duties, nil
+	}
+	duties, err := node.fetchProposerDuties(epoch)
+	if err != nil {
+		return nil, err
+	}
+	if d.byEpoch == nil {
+		d.byEpoch = make(map[int]map[int]string)
+	}
+	d.byEpoch[epoch] = duties
+	for cached := range d.byEpoch {
+		if cached < epoch-slotLedgerHistoryEpochs {
+			delete(d.byEpoch, cached)
+		}
+	}
+	return duties, nil
+}
+
+type slotEntry struct {
+	Slot     int    `json:"slot"`
+	Epoch    int    `json:"epoch"`
+	Proposer string `json:"proposer_index"`
+	Status   string `json:"status"`
+	Root     string `json:"root,omitempty"`
+	// seconds after the start of the slot the block was first seen, if it was
+	ArrivalDelay *float64 `json:"arrival_delay_seconds"`
+	// blocks seen for this slot that are not canonical
+	Orphaned []string `json:"orphaned"`
+}
+
+type slotsResp struct {
+	Slots []slotEntry `json:"slots"`
+}
+
+// buildSlotLedger classifies the slots in [fromSlot, toSlot] with the
+// canonical blocks in `chain` and the blocks we saw arrive. Blocks that arrive
+// after the attestation deadline, a third into the slot, are late.
+func buildSlotLedger(config Eth2Config, fromSlot int, toSlot int, currentSlot int, chain []ChainBlock, arrivals map[string]blockArrival, proposers map[int]string) []slotEntry {
+	canonical := make(map[int]string)
+	for _, block := range chain {
+		canonical[block.Slot] = block.Root
+	}
+	seen := make(map[int][]string)
+	for root, arrival := range arrivals {
+		seen[arrival.slot] = append(seen[arrival.slot], root)
+	}
+	deadline := float64(config.SecondsPerSlot) / 3
+
+	ledger := []slotEntry{}
+	for slot := fromSlot; slot <= toSlot; slot++ {
+		entry := slotEntry{
+			Slot:     slot,
+			Epoch:    slot / config.SlotsPerEpoch,
+			Proposer: proposers[slot],
+			Root:     canonical[slot],
+			Orphaned: []string{},
+		}
+		for _, root := range seen[slot] {
+			if root != entry.Root {
+				entry.Orphaned = append(entry.Orphaned, root)
+			}
+		}
+
+		switch {
+		case entry.Root != "":
+			entry.Status = SlotIncluded
+			if arrival, ok := arrivals[entry.Root]; ok {
+				slotStart := time.Unix(int64(config.GenesisTime+slot*config.SecondsPerSlot), 0)
+				delay := arrival.firstSeen.Sub(slotStart).Seconds()
+				entry.ArrivalDelay = &delay
+				entry.Status = SlotOnTime
+				if delay > deadline {
+					entry.Status = SlotLate
+				}
+			}
+		case slot >= currentSlot:
+			entry.Status = SlotPending
+		case len(entry.Orphaned) > 0:
+			entry.Status = SlotOrphaned
+		default:
+			entry.Status = SlotMissed
+		}
+		ledger = append(ledger, entry)
+	}
+	return ledger
+}
+
+// sendSlots serves the slot ledger for the last `?epochs=` epochs.
+func (m *Monitor) sendSlots(w http.ResponseWriter, r *http.Request) {
+	epochs, err := parsePositiveInt(r.URL.Query(), "epochs", defaultSlotLedgerEpochs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if epochs > slotLedgerHistoryEpochs {
+		epochs = slotLedgerHistoryEpochs
+	}
+
+	config := m.eth2()
+	currentSlot := computeCurrentSlot(config.GenesisTime, config.SecondsPerSlot)
+	currentEpoch := currentSlot / config.SlotsPerEpoch
+	firstEpoch := currentEpoch - epochs + 1
+	if firstEpoch < 0 {
+		firstEpoch = 0
+	}
+
+	node, headRoot, err := m.chainProvider()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	m.fetches.acquire(cosmeticFetch)
+	defer m.fetches.release()
+	proposers := make(map[int]string)
+	for epoch := firstEpoch; epoch <= currentEpoch; epoch++ {
+		duties, err := m.proposers.forEpoch(node, epoch)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		for slot, proposer := range duties {
+			proposers[slot] = proposer
+		}
+	}
+	fromSlot := firstEpoch * config.SlotsPerEpoch
+	// the ledger needs every block, it is bounded by `slotLedgerHistoryEpochs`
+	chain, _, err := m.slotChain.canonicalChain(node, headRoot, currentSlot-fromSlot+1, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	toSlot := (currentEpoch+1)*config.SlotsPerEpoch - 1
+	ledger := buildSlotLedger(config, fromSlot, toSlot, currentSlot, chain, m.arrivals.copy(), proposers)
+	writeJSON(w, r, &slotsResp{Slots: ledger})
+}
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSlotLedger(t *testing.T) {
+	config := Eth2Config{GenesisTime: 1000, SecondsPerSlot: 12, SlotsPerEpoch: 4}
+	slotStart := func(slot int) time.Time {
+		return time.Unix(int64(1000+slot*12), 0)
+	}
+	chain := []ChainBlock{
+		{Slot: 3, Root: "c"},
+		{Slot: 1, Root: "b"},
+		{Slot: 0, Root: "a"},
+	}
+	arrivals := map[string]blockArrival{
+		"a": {slot: 0, firstSeen: slotStart(0).Add(2 * time.Second)},
+		"b": {slot: 1, firstSeen: slotStart(1).Add(6 * time.Second)},
+		"x": {slot: 2, firstSeen: slotStart(2).Add(1 * time.Second)},
+	}
+	proposers := map[int]string{0: "10", 1: "11", 2: "12", 3: "13"}
+
+	ledger := buildSlotLedger(config, 0, 5, 4, chain, arrivals, proposers)
+	expected := []string{SlotOnTime, SlotLate, SlotOrphaned, SlotIncluded, SlotPending, SlotPending}
+	if len(ledger) != len(expected) {
+		t.Fatalf("expected %d slots, got %d", len(expected), len(ledger))
+	}
+	for i, entry := range ledger {
+		if entry.Status != expected[i] {
+			t.Errorf("expected slot %d to be %s, got %s", entry.Slot, expected[i], entry.Status)
+		}
+	}
+	if ledger[2].Proposer != "12" || len(ledger[2].Orphaned) != 1 {
+		t.Errorf("unexpected orphaned slot %+v", ledger[2])
+	}
+	if ledger[1].ArrivalDelay == nil || *ledger[1].ArrivalDelay != 6 {
+		t.Errorf("unexpected arrival delay %+v", ledger[1])
+	}
+
+	ledger = buildSlotLedger(config, 0, 0, 4, nil, nil, proposers)
+	if ledger[0].Status != SlotMissed {
+		t.Errorf("expected an empty slot to be missed, got %s", ledger[0].Status)
+	}
+}
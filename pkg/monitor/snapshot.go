@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExportSnapshot writes the persisted state under the configured data
+// directory, including its subdirectories, to `w` as a gzipped tarball.
+func ExportSnapshot(config *Config, w io.Writer) error {
+	if config.DataDir == "" {
+		return fmt.Errorf("no `data_dir` configured")
+	}
+	_, err := os.Stat(config.DataDir)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	err = filepath.Walk(config.DataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// directories are recreated from the file paths on import; skip
+		// anything else that is not a file and any partially written files
+		if !info.Mode().IsRegular() || strings.HasSuffix(info.Name(), ".tmp") {
+			return nil
+		}
+		name, err := filepath.Rel(config.DataDir, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		err = archive.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(archive, f)
+		f.Close()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = archive.Close()
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ImportSnapshot restores an archive made by `ExportSnapshot` into the
+// configured data directory, replacing any state already there.
+// The monitor should not be running while a snapshot is imported.
+func ImportSnapshot(config *Config, r io.Reader) error {
+	if config.DataDir == "" {
+		return fmt.Errorf("no `data_dir` configured")
+	}
+	err := os.MkdirAll(config.DataDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	foundState := false
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.FromSlash(header.Name)
+		if filepath.IsAbs(name) || name != filepath.Clean(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("unexpected path %q in snapshot", header.Name)
+		}
+
+		path := filepath.Join(config.DataDir, name)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = extractFile(archive, path+".tmp")
+		if err != nil {
+			return err
+		}
+		if name == stateFileName {
+			_, err := readState(path + ".tmp")
+			if err != nil {
+				os.Remove(path + ".tmp")
+				return fmt.Errorf("invalid state in snapshot: %v", err)
+			}
+			foundState = true
+		}
+		err = os.Rename(path+".tmp", path)
+		if err != nil {
+			return err
+		}
+	}
+	if !foundState {
+		return fmt.Errorf("snapshot did not contain %s", stateFileName)
+	}
+	return nil
+}
+
+func extractFile(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
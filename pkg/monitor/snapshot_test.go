@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotExportImport(t *testing.T) {
+	source := &Monitor{config: &Config{DataDir: t.TempDir()}, samples: newSampleStore()}
+	source.store().SaveParticipation(Participation{Epoch: 3, ParticipationRate: 80})
+	source.finalizedCheckpoint = Checkpoint{Epoch: 2, Root: "0x02"}
+	err := source.saveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(source.protoArraySnapshotDir(), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(source.protoArraySnapshotPath(7), []byte("proto array"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	err = ExportSnapshot(source.config, &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &Monitor{config: &Config{DataDir: t.TempDir()}, samples: newSampleStore()}
+	err = ImportSnapshot(target.config, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := readState(target.stateFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Participation, source.participationHistory()) || state.Finalized != source.finalizedCheckpoint {
+		t.Errorf("imported state does not match: %v", state)
+	}
+	protoArray, err := ioutil.ReadFile(target.protoArraySnapshotPath(7))
+	if err != nil || string(protoArray) != "proto array" {
+		t.Errorf("expected the proto array snapshot to be imported, got %q: %v", protoArray, err)
+	}
+
+	err = ImportSnapshot(target.config, bytes.NewReader([]byte("not an archive")))
+	if err == nil {
+		t.Error("expected garbage input to be rejected")
+	}
+
+	var escaping bytes.Buffer
+	gz := gzip.NewWriter(&escaping)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0644})
+	tw.Close()
+	gz.Close()
+	err = ImportSnapshot(target.config, &escaping)
+	if err == nil {
+		t.Error("expected a path outside the data directory to be rejected")
+	}
+}
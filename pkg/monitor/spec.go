@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const forkEpochSuffix = "_FORK_EPOCH"
+
+// these are part of the preset, not the config, so infer them
+var presetSlotsPerEpoch = map[string]int{
+	"mainnet": 32,
+	"minimal": 8,
+}
+
+var presetEpochsPerSyncCommitteePeriod = map[string]int{
+	"mainnet": 256,
+	"minimal": 8,
+}
+
+func specInt(spec map[string]interface{}, key string) (uint64, bool, error) {
+	value, ok := spec[key]
+	if !ok {
+		return 0, false, nil
+	}
+	parsed, err := strconv.ParseUint(fmt.Sprint(value), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %v", key, err)
+	}
+	return parsed, true, nil
+}
+
+// parseSpec reads the parameters we need from a consensus client style
+// configuration, e.g. as generated for a devnet. An explicit `GENESIS_TIME`
+// is honored, otherwise genesis is assumed to happen `GENESIS_DELAY` after
+// `MIN_GENESIS_TIME` as is the case for generated devnet genesis states.
+func parseSpec(data []byte) (Eth2Config, error) {
+	config := Eth2Config{}
+	spec := make(map[string]interface{})
+	err := yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return config, err
+	}
+
+	if name, ok := spec["CONFIG_NAME"].(string); ok {
+		config.Network = name
+	}
+	if base, ok := spec["PRESET_BASE"].(string); ok {
+		config.SlotsPerEpoch = presetSlotsPerEpoch[strings.Trim(base, "'")]
+		config.EpochsPerSyncCommitteePeriod = presetEpochsPerSyncCommitteePeriod[strings.Trim(base, "'")]
+	}
+	if address, ok := spec["DEPOSIT_CONTRACT_ADDRESS"]; ok {
+		config.DepositContractAddress = fmt.Sprint(address)
+	}
+
+	integers := map[string]*int{
+		"SECONDS_PER_SLOT": &config.SecondsPerSlot,
+		"SLOTS_PER_EPOCH":  &config.SlotsPerEpoch,
+		"GENESIS_TIME":     &config.GenesisTime,
+		"DEPOSIT_CHAIN_ID": &config.DepositChainID,
+
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": &config.EpochsPerSyncCommitteePeriod,
+	}
+	for key, target := range integers {
+		value, ok, err := specInt(spec, key)
+		if err != nil {
+			return config, err
+		}
+		if ok {
+			*target = int(value)
+		}
+	}
+
+	if config.GenesisTime == 0 {
+		minGenesisTime, hasMinGenesisTime, err := specInt(spec, "MIN_GENESIS_TIME")
+		if err != nil {
+			return config, err
+		}
+		genesisDelay, _, err := specInt(spec, "GENESIS_DELAY")
+		if err != nil {
+			return config, err
+		}
+		if hasMinGenesisTime {
+			config.GenesisTime = int(minGenesisTime + genesisDelay)
+		}
+	}
+
+	for key := range spec {
+		if !strings.HasSuffix(key, forkEpochSuffix) {
+			continue
+		}
+		epoch, _, err := specInt(spec, key)
+		if err != nil {
+			return config, err
+		}
+		// skip forks scheduled for the "far future", i.e. not scheduled at all
+		if epoch >= uint64(^uint32(0)) {
+			continue
+		}
+		if config.ForkEpochs == nil {
+			config.ForkEpochs = make(map[string]int)
+		}
+		fork := strings.ToLower(strings.TrimSuffix(key, forkEpochSuffix))
+		config.ForkEpochs[fork] = int(epoch)
+	}
+	return config, nil
+}
+
+func loadSpecFile(path string) (Eth2Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Eth2Config{}, err
+	}
+	config, err := parseSpec(data)
+	if err != nil {
+		return config, fmt.Errorf("could not load spec file %s: %v", path, err)
+	}
+	return config, nil
+}
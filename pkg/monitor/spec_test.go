@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const devnetSpec = `
+PRESET_BASE: 'minimal'
+CONFIG_NAME: 'kurtosis'
+MIN_GENESIS_TIME: 1700000000
+GENESIS_DELAY: 60
+SECONDS_PER_SLOT: 6
+ALTAIR_FORK_EPOCH: 0
+BELLATRIX_FORK_EPOCH: 0
+CAPELLA_FORK_EPOCH: 1
+DENEB_FORK_EPOCH: 2
+ELECTRA_FORK_EPOCH: 18446744073709551615
+DEPOSIT_CONTRACT_ADDRESS: 0x4242424242424242424242424242424242424242
+`
+
+func TestLoadSpecFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := ioutil.WriteFile(path, []byte(devnetSpec), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{Eth2: Eth2Config{SpecFile: path, SecondsPerSlot: 2}}
+	err = config.ApplyDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Eth2Config{
+		SecondsPerSlot:         2,
+		GenesisTime:            1700000060,
+		SlotsPerEpoch:          8,
+		Network:                "kurtosis",
+		DepositContractAddress: "0x4242424242424242424242424242424242424242",
+		ForkEpochs:             map[string]int{"altair": 0, "bellatrix": 0, "capella": 1, "deneb": 2},
+		SpecFile:               path,
+
+		EpochsPerSyncCommitteePeriod: 8,
+	}
+	if !reflect.DeepEqual(config.Eth2, expected) {
+		t.Errorf("unexpected config from spec file: %+v", config.Eth2)
+	}
+}
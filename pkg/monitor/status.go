@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type NodeStatus string
+
+const (
+	StatusOK            NodeStatus = "ok"
+	StatusDegraded      NodeStatus = "degraded"
+	StatusStale         NodeStatus = "stale"
+	StatusUnreachable   NodeStatus = "unreachable"
+	StatusMisconfigured NodeStatus = "misconfigured"
+	StatusRateLimited   NodeStatus = "rate_limited"
+	// the monitor held back requests to stay within `requests_per_minute`
+	StatusBudgetExhausted NodeStatus = "budget_exhausted"
+)
+
+const defaultStaleAfterSlots = 4
+const nodeStatusAlertPrefix = "node_status:"
+
+// at most this much of a failed response body is kept for reporting
+const errorBodyExcerptLength = 256
+
+func bodyExcerpt(body []byte) string {
+	excerpt := strings.TrimSpace(string(body))
+	if len(excerpt) <= errorBodyExcerptLength {
+		return excerpt
+	}
+	// cut at the start of a rune so no UTF-8 sequence is split
+	end := errorBodyExcerptLength
+	for end > 0 && !utf8.RuneStart(excerpt[end]) {
+		end--
+	}
+	return excerpt[:end] + "..."
+}
+
+type httpStatusError struct {
+	url        string
+	statusCode int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.url, e.statusCode)
+}
+
+type malformedBodyError struct {
+	url  string
+	body string
+	err  error
+}
+
+func (e *malformedBodyError) Error() string {
+	return fmt.Sprintf("%s returned a malformed body: %v", e.url, e.err)
+}
+
+func (e *malformedBodyError) Unwrap() error {
+	return e.err
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, errorBodyExcerptLength+1))
+		return &httpStatusError{
+			url:        resp.Request.URL.String(),
+			statusCode: resp.StatusCode,
+			body:       bodyExcerpt(body),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return nil
+}
+
+// decodeResponse checks the status of `resp` and decodes its JSON body into
+// `v`, keeping an excerpt of the body around if either fails.
+func decodeResponse(resp *http.Response, v interface{}) error {
+	return decodeResponseWith(resp, v, json.Unmarshal)
+}
+
+// decodeResponseNumbers is decodeResponse keeping the numbers in the body
+// as `json.Number` so large integers are not rounded.
+func decodeResponseNumbers(resp *http.Response, v interface{}) error {
+	return decodeResponseWith(resp, v, unmarshalNumbers)
+}
+
+func unmarshalNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func decodeResponseWith(resp *http.Response, v interface{}, unmarshal func([]byte, interface{}) error) error {
+	err := checkStatus(resp)
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	err = unmarshal(body, v)
+	if err != nil {
+		return &malformedBodyError{url: resp.Request.URL.String(), body: bodyExcerpt(body), err: err}
+	}
+	return nil
+}
+
+// NodeError is the most recent failure observed for a node.
+type NodeError struct {
+	Time       time.Time `json:"time"`
+	Message    string    `json:"message"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Body       string    `json:"body,omitempty"`
+}
+
+func newNodeError(err error, now time.Time) *NodeError {
+	nodeErr := &NodeError{Time: now, Message: secrets.redact(err.Error())}
+	var statusErr *httpStatusError
+	var bodyErr *malformedBodyError
+	if errors.As(err, &statusErr) {
+		nodeErr.StatusCode = statusErr.statusCode
+		nodeErr.Body = secrets.redact(statusErr.body)
+	} else if errors.As(err, &bodyErr) {
+		nodeErr.Body = secrets.redact(bodyErr.body)
+	}
+	return nodeErr
+}
+
+// classifyError maps the result of a request to a node onto a status:
+// we could not connect, the node rejects our requests (e.g. a wrong address
+// or API disabled), asks us to slow down or it is up but failing to serve
+// sensible data. Requests held back by our own budget never reached the node
+// and say nothing about it being reachable.
+func classifyError(err error) NodeStatus {
+	if err == nil {
+		return StatusOK
+	}
+	if errors.Is(err, errBudgetExhausted) {
+		return StatusBudgetExhausted
+	}
+	if errors.Is(err, errBackingOff) {
+		return StatusRateLimited
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		if isRateLimit(statusErr.statusCode) {
+			return StatusRateLimited
+		}
+		if statusErr.statusCode >= 500 {
+			return StatusDegraded
+		}
+		return StatusMisconfigured
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return StatusUnreachable
+	}
+	return StatusDegraded
+}
+
+// recordError keeps the error `err` points to, if any, as the last failure
+// of the node. The fetch helpers defer it on their error result.
+func (n *Node) recordError(err *error) {
+	if *err == nil {
+		return
+	}
+	nodeErr := newNodeError(*err, time.Now())
+	n.errorLock.Lock()
+	n.lastError = nodeErr
+	n.errorLock.Unlock()
+}
+
+func (n *Node) latestError() *NodeError {
+	n.errorLock.Lock()
+	defer n.errorLock.Unlock()
+	return n.lastError
+}
+
+func (n *Node) setHead(head HeadRef) {
+	n.latestHead = head
+	n.headUpdatedAt = time.Now()
+}
+
+// currentStatus refines the status of the last fetch with the freshness of
+// the data: a responsive node whose head has not moved for a while is stale.
+func (n *Node) currentStatus(now time.Time, staleAfter time.Duration) NodeStatus {
+	if n.status == StatusOK && !n.headUpdatedAt.IsZero() && now.Sub(n.headUpdatedAt) > staleAfter {
+		return StatusStale
+	}
+	return n.status
+}
+
+func (m *Monitor) staleAfter() time.Duration {
+	slots := m.config.StaleAfterSlots
+	if slots <= 0 {
+		slots = defaultStaleAfterSlots
+	}
+	return time.Duration(slots*m.config.Eth2.SecondsPerSlot) * time.Second
+}
+
+func (m *Monitor) nodeStatus(node *Node) NodeStatus {
+	return node.currentStatus(time.Now(), m.staleAfter())
+}
+
+// publishHealthChanges publishes the nodes whose status changed since the
+// last poll; alerts are raised for every node not serving fresh data.
+func (m *Monitor) publishHealthChanges(nodes []*Node) {
+	for _, node := range nodes {
+		status := m.nodeStatus(node)
+		if status == node.publishedStatus {
+			continue
+		}
+		m.events.publish(NodeHealthChanged{Node: node.id, Addr: node.addr, Previous: node.publishedStatus, Current: status})
+		node.publishedStatus = status
+	}
+}
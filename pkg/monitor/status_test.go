@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func statusAfterFetch(endpoint string) NodeStatus {
+	node := &Node{endpoint: endpoint, version: "teku/v20.11.0"}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	node.fetchLatestHead(&wg)
+	return node.status
+}
+
+func TestNodeStatusClassification(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer broken.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer busy.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"root": "0xaa", "header": {"message": {"slot": "10"}}}}`))
+	}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cases := map[string]NodeStatus{
+		notFound.URL: StatusMisconfigured,
+		broken.URL:   StatusDegraded,
+		failing.URL:  StatusDegraded,
+		busy.URL:     StatusRateLimited,
+		healthy.URL:  StatusOK,
+		down.URL:     StatusUnreachable,
+	}
+	for endpoint, expected := range cases {
+		if status := statusAfterFetch(endpoint); status != expected {
+			t.Errorf("%s: expected %s, got %s", endpoint, expected, status)
+		}
+	}
+}
+
+func TestStaleNodeStatus(t *testing.T) {
+	now := time.Now()
+	node := &Node{status: StatusOK, headUpdatedAt: now.Add(-time.Minute)}
+	if status := node.currentStatus(now, 2*time.Minute); status != StatusOK {
+		t.Errorf("expected fresh node to be ok, got %s", status)
+	}
+	if status := node.currentStatus(now, 30*time.Second); status != StatusStale {
+		t.Errorf("expected node to be stale, got %s", status)
+	}
+	node.status = StatusUnreachable
+	if status := node.currentStatus(now, 30*time.Second); status != StatusUnreachable {
+		t.Errorf("expected fetch failure to take precedence, got %s", status)
+	}
+}
+
+func TestNodeLastError(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(strings.Repeat("busy ", 100)))
+	}))
+	defer failing.Close()
+
+	node := &Node{id: "abcd1234", endpoint: failing.URL, version: "teku/v20.11.0"}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	node.fetchLatestHead(&wg)
+
+	if node.lastError == nil || node.lastError.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status code not captured: %+v", node.lastError)
+	}
+	if !strings.HasPrefix(node.lastError.Body, "busy busy") || len(node.lastError.Body) > errorBodyExcerptLength+3 {
+		t.Errorf("unexpected body excerpt: %q", node.lastError.Body)
+	}
+
+	m := &Monitor{config: &Config{Eth2: Eth2Config{SecondsPerSlot: 12, SlotsPerEpoch: 32}}, nodes: []*Node{node}}
+	w := httptest.NewRecorder()
+	m.sendNodeAPI(w, httptest.NewRequest(http.MethodGet, "/api/v1/nodes/abcd1234", nil))
+	resp := nodeDetailResp{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "abcd1234" || resp.LastError == nil || resp.LastError.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("last error not exposed in node detail: %+v", resp)
+	}
+}
+
+func TestNodeLastErrorFromOtherFetches(t *testing.T) {
+	malformed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer malformed.Close()
+
+	node := &Node{endpoint: malformed.URL}
+	if _, _, err := node.fetchFinalityCheckpoints(); err == nil {
+		t.Fatal("expected the finality fetch to fail")
+	}
+	if node.latestError() == nil {
+		t.Error("expected a failed finality fetch to be recorded")
+	}
+}
+
+func TestBusyProviderDoesNotCrash(t *testing.T) {
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"code":503,"message":"busy"}`))
+	}))
+	defer busy.Close()
+
+	node := &Node{endpoint: busy.URL}
+	m := &Monitor{config: &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}}}
+	m.setForkChoiceProvider(node)
+	if err := m.buildLatestForkChoiceSummary(); err == nil {
+		t.Error("expected the fork choice summary to fail")
+	}
+	if _, _, err := node.doFetchParticipation(1); err == nil {
+		t.Fatal("expected the participation fetch to fail")
+	}
+	lastError := node.latestError()
+	if lastError == nil || lastError.StatusCode != http.StatusServiceUnavailable || !strings.Contains(lastError.Body, "busy") {
+		t.Errorf("expected the status and body of the participation fetch, got %+v", lastError)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"nodes":[]}}`))
+	}))
+	defer empty.Close()
+	if _, err := (&Node{endpoint: empty.URL}).fetchProtoArray(); err == nil {
+		t.Error("expected an empty proto array to be an error")
+	}
+	if head := protoArrayHead(nil); head.Root != "" {
+		t.Errorf("expected no head for an empty proto array, got %+v", head)
+	}
+}
+
+func TestBodyExcerptKeepsRunes(t *testing.T) {
+	body := strings.Repeat("a", errorBodyExcerptLength-1) + "é"
+	excerpt := bodyExcerpt([]byte(body + "tail"))
+	if !utf8.ValidString(excerpt) || excerpt != strings.Repeat("a", errorBodyExcerptLength-1)+"..." {
+		t.Errorf("expected the excerpt to end before the split rune, got %q", excerpt[len(excerpt)-8:])
+	}
+}
@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Storage keeps the history the monitor builds up: the heads observed on each
+// node, the participation of each epoch and the reorgs. Backends only need to
+// implement this interface, the monitor reads and writes the history through
+// it alone. The in-memory memoryStorage is the default, SetStorage replaces
+// it.
+type Storage interface {
+	// AppendHeadObservation adds the latest head seen on `node`. Only the
+	// most recent heads need to be kept.
+	AppendHeadObservation(node string, observation HeadObservation) error
+	// QueryHeadObservations returns the recent heads of `node`, oldest first.
+	QueryHeadObservations(node string) ([]HeadObservation, error)
+
+	// SaveParticipation stores the participation of consecutive epochs,
+	// replacing whatever is stored for the first of them and any later epoch.
+	SaveParticipation(participation ...Participation) error
+	// QueryParticipation returns the stored participation by ascending epoch.
+	QueryParticipation() ([]Participation, error)
+	// PruneParticipation drops the participation of the epochs before `epoch`.
+	PruneParticipation(epoch int) error
+
+	SaveReorg(reorg Reorg) error
+	// QueryReorgs returns the reorgs whose new head is in `epoch`, or all of
+	// them if `epoch` is negative, in the order they were detected.
+	QueryReorgs(epoch int) ([]Reorg, error)
+	// PruneReorgs drops the reorgs detected before `cutoff`.
+	PruneReorgs(cutoff time.Time) error
+
+	// Reset drops everything, e.g. when the chain restarts from a new genesis.
+	Reset() error
+}
+
+// memoryStorage keeps the history in memory, bounded only by the retention;
+// the zero value is ready to use.
+type memoryStorage struct {
+	lock          sync.Mutex
+	heads         map[string]*headHistory
+	participation []Participation
+	reorgs        []Reorg
+}
+
+func (s *memoryStorage) AppendHeadObservation(node string, observation HeadObservation) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.heads == nil {
+		s.heads = make(map[string]*headHistory)
+	}
+	history, ok := s.heads[node]
+	if !ok {
+		history = &headHistory{}
+		s.heads[node] = history
+	}
+	history.add(observation)
+	return nil
+}
+
+func (s *memoryStorage) QueryHeadObservations(node string) ([]HeadObservation, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	history, ok := s.heads[node]
+	if !ok {
+		return []HeadObservation{}, nil
+	}
+	return history.list(), nil
+}
+
+func (s *memoryStorage) SaveParticipation(participation ...Participation) error {
+	if len(participation) == 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data := s.participation
+	for len(data) != 0 && data[len(data)-1].Epoch >= participation[0].Epoch {
+		data = data[:len(data)-1]
+	}
+	s.participation = append(data, participation...)
+	return nil
+}
+
+func (s *memoryStorage) QueryParticipation() ([]Participation, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Participation{}, s.participation...), nil
+}
+
+func (s *memoryStorage) PruneParticipation(epoch int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := 0
+	for i < len(s.participation) && s.participation[i].Epoch < epoch {
+		i++
+	}
+	s.participation = s.participation[i:]
+	return nil
+}
+
+func (s *memoryStorage) SaveReorg(reorg Reorg) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reorgs = append(s.reorgs, reorg)
+	return nil
+}
+
+func (s *memoryStorage) QueryReorgs(epoch int) ([]Reorg, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	reorgs := []Reorg{}
+	for _, reorg := range s.reorgs {
+		if epoch < 0 || reorg.Epoch == epoch {
+			reorgs = append(reorgs, reorg)
+		}
+	}
+	return reorgs, nil
+}
+
+func (s *memoryStorage) PruneReorgs(cutoff time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := 0
+	for i < len(s.reorgs) && s.reorgs[i].DetectedAt.Before(cutoff) {
+		i++
+	}
+	s.reorgs = s.reorgs[i:]
+	return nil
+}
+
+func (s *memoryStorage) Reset() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.heads = nil
+	s.participation = nil
+	s.reorgs = nil
+	return nil
+}
+
+// SetStorage keeps the history in `storage` instead of in memory. It must
+// be called before the monitor is started.
+func (m *Monitor) SetStorage(storage Storage) {
+	m.storage = storage
+}
+
+// store is the configured storage backend, by default the history is kept
+// in memory.
+func (m *Monitor) store() Storage {
+	if m.storage != nil {
+		return m.storage
+	}
+	return &m.memory
+}
+
+// The helpers below read the history for the API and the other subsystems,
+// logging a failing backend and carrying on without the data.
+
+func (m *Monitor) headObservations(node string) []HeadObservation {
+	observations, err := m.store().QueryHeadObservations(node)
+	if err != nil {
+		log.Println(err)
+	}
+	return observations
+}
+
+func (m *Monitor) participationHistory() []Participation {
+	participation, err := m.store().QueryParticipation()
+	if err != nil {
+		log.Println(err)
+	}
+	return participation
+}
+
+func (m *Monitor) reorgHistory(epoch int) []Reorg {
+	reorgs, err := m.store().QueryReorgs(epoch)
+	if err != nil {
+		log.Println(err)
+		return []Reorg{}
+	}
+	return reorgs
+}
+
+// firstEpochFrom returns the first epoch starting at or after `t`.
+func (m *Monitor) firstEpochFrom(t time.Time) int {
+	config := m.eth2()
+	epochDuration := time.Duration(config.SlotsPerEpoch*config.SecondsPerSlot) * time.Second
+	elapsed := t.Sub(time.Unix(int64(config.GenesisTime), 0))
+	return int(math.Ceil(float64(elapsed) / float64(epochDuration)))
+}
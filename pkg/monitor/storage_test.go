@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	var store memoryStorage
+	now := time.Now()
+
+	store.SaveParticipation(Participation{Epoch: 1}, Participation{Epoch: 2, ParticipationRate: 10})
+	store.SaveParticipation(Participation{Epoch: 2, ParticipationRate: 90}, Participation{Epoch: 3})
+	participation, _ := store.QueryParticipation()
+	expected := []Participation{{Epoch: 1}, {Epoch: 2, ParticipationRate: 90}, {Epoch: 3}}
+	if !reflect.DeepEqual(participation, expected) {
+		t.Errorf("expected later saves to replace epochs, got %v", participation)
+	}
+	store.PruneParticipation(2)
+	if participation, _ := store.QueryParticipation(); len(participation) != 2 || participation[0].Epoch != 2 {
+		t.Errorf("unexpected participation after prune: %v", participation)
+	}
+
+	store.SaveReorg(Reorg{Epoch: 1, DetectedAt: now.Add(-time.Hour)})
+	store.SaveReorg(Reorg{Epoch: 2, DetectedAt: now})
+	if reorgs, _ := store.QueryReorgs(1); len(reorgs) != 1 {
+		t.Errorf("unexpected reorgs in epoch 1: %v", reorgs)
+	}
+	store.PruneReorgs(now.Add(-time.Minute))
+	if reorgs, _ := store.QueryReorgs(-1); len(reorgs) != 1 || reorgs[0].Epoch != 2 {
+		t.Errorf("unexpected reorgs after prune: %v", reorgs)
+	}
+
+	store.Reset()
+	if reorgs, _ := store.QueryReorgs(-1); len(reorgs) != 0 {
+		t.Error("expected reset to drop the reorgs")
+	}
+}
+
+func TestRecordHeads(t *testing.T) {
+	m := &Monitor{}
+	m.subscribeConsumers()
+	node := &Node{id: "a"}
+	nodes := []*Node{node}
+
+	m.publishHeads(nodes)
+	if len(m.headObservations("a")) != 0 {
+		t.Error("expected no observation before the first head")
+	}
+
+	node.setHead(HeadRef{slot: 1, root: "0x01"})
+	m.publishHeads(nodes)
+	m.publishHeads(nodes)
+	node.setHead(HeadRef{slot: 2, root: "0x02"})
+	m.publishHeads(nodes)
+
+	observations := m.headObservations("a")
+	if len(observations) != 2 || observations[0].Root != "0x01" || observations[1].Slot != 2 {
+		t.Errorf("expected each head to be recorded once, got %v", observations)
+	}
+}
+
+// recordingStorage keeps the history in memory and counts the head
+// observations it was handed.
+type recordingStorage struct {
+	memoryStorage
+	appended int
+}
+
+func (s *recordingStorage) AppendHeadObservation(node string, observation HeadObservation) error {
+	s.appended++
+	return s.memoryStorage.AppendHeadObservation(node, observation)
+}
+
+func TestSetStorage(t *testing.T) {
+	m := &Monitor{}
+	store := &recordingStorage{}
+	m.SetStorage(store)
+	m.subscribeConsumers()
+	node := &Node{id: "a"}
+
+	node.setHead(HeadRef{slot: 1, root: "0x01"})
+	m.publishHeads([]*Node{node})
+
+	if store.appended != 1 {
+		t.Errorf("expected the head to be written to the configured backend, got %d writes", store.appended)
+	}
+	if observations := m.headObservations("a"); len(observations) != 1 || observations[0].Root != "0x01" {
+		t.Errorf("expected the history to be read from the configured backend, got %v", observations)
+	}
+	if observations, _ := m.memory.QueryHeadObservations("a"); len(observations) != 0 {
+		t.Error("expected the default backend to be left alone")
+	}
+}
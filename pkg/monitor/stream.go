@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// large responses are sent in chunks of this size, flushing after each so
+// the body goes out with chunked transfer encoding
+const responseChunkSize = 32 * 1024
+
+// responseCache keeps the encoding of the latest version of a large response
+// so concurrent requests share a single copy rather than each encoding their own.
+// The zero value is ready to use.
+type responseCache struct {
+	lock sync.Mutex
+	key  string
+	body []byte
+}
+
+// get returns the body for `key`, encoding it with `encode` unless it is the
+// body cached last. Concurrent callers wait for the first one to finish encoding.
+func (c *responseCache) get(key string, encode func(w io.Writer) error) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.body != nil && c.key == key {
+		return c.body, nil
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	err := encode(w)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+	c.body = buf.Bytes()
+	return c.body, nil
+}
+
+// writeChunked sends `body` in chunks, flushing each to the client.
+func writeChunked(w http.ResponseWriter, body []byte) error {
+	flusher, _ := w.(http.Flusher)
+	for len(body) > 0 {
+		n := responseChunkSize
+		if n > len(body) {
+			n = len(body)
+		}
+		_, err := w.Write(body[:n])
+		if err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		body = body[n:]
+	}
+	return nil
+}
+
+// jsonSafe marks the bytes encoding/json writes to a string unescaped
+var jsonSafe = func() (safe [256]bool) {
+	for c := 0x20; c < 0x7f; c++ {
+		safe[c] = c != '"' && c != '\\' && c != '<' && c != '>' && c != '&'
+	}
+	return
+}()
+
+// appendJSONString appends `s` as a JSON string, slots and roots need no
+// escaping so only other strings go through the full encoder.
+func appendJSONString(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if !jsonSafe[s[i]] {
+			encoded, _ := json.Marshal(s)
+			return append(buf, encoded...)
+		}
+	}
+	buf = append(buf, '"')
+	buf = append(buf, s...)
+	return append(buf, '"')
+}
+
+// appendJSONFloat appends `f` formatted the way encoding/json does.
+func appendJSONFloat(buf []byte, f float64) []byte {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return append(buf, "null"...)
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(buf) - start
+		if n >= 4 && buf[len(buf)-4] == 'e' && buf[len(buf)-3] == '-' && buf[len(buf)-2] == '0' {
+			buf[len(buf)-2] = buf[len(buf)-1]
+			buf = buf[:len(buf)-1]
+		}
+	}
+	return buf
+}
+
+// encodeJSON streams the block tree rooted at `index` to `w` in the same form
+// as the equivalent `ForkChoiceNode` without building it.
+// The tree is walked with an explicit stack as the canonical chain can be
+// tens of thousands of blocks deep.
+func (t *forkChoiceTree) encodeJSON(w io.Writer, index int) error {
+	type frame struct {
+		index int
+		// next child to encode
+		next int
+	}
+
+	buf := make([]byte, 0, 2*responseChunkSize)
+	stack := []frame{{index: index}}
+	buf = append(buf, `{"children":`...)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		children := t.childrenOf(top.index)
+		if top.next < len(children) {
+			if top.next == 0 {
+				buf = append(buf, '[')
+			} else {
+				buf = append(buf, ',')
+			}
+			child := children[top.next]
+			top.next++
+			stack = append(stack, frame{index: child})
+			buf = append(buf, `{"children":`...)
+			continue
+		}
+
+		if len(children) == 0 {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, ']')
+		}
+		node := &t.protoArray[top.index]
+		buf = append(buf, `,"slot":`...)
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(node.Slot), 10)
+		buf = append(buf, '"')
+		buf = append(buf, `,"root":`...)
+		buf = appendJSONString(buf, node.Root)
+		buf = append(buf, `,"weight":`...)
+		buf = appendJSONFloat(buf, node.Weight)
+		buf = append(buf, `,"is_canonical":`...)
+		buf = strconv.AppendBool(buf, t.canonical[top.index])
+		buf = append(buf, '}')
+		stack = stack[:len(stack)-1]
+
+		if len(buf) >= responseChunkSize {
+			_, err := w.Write(buf)
+			if err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEncodeTreeMatchesMarshal(t *testing.T) {
+	zero := float64(0)
+	one := float64(1)
+	protoArrayData := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 32000000000000000, BestDescendant: 2},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 0.5, BestDescendant: 2},
+		{Slot: 2, Root: "<\"escaped\">", ParentIndex: &one, Weight: 1e-7},
+		{Slot: 1, Root: hash("1'"), ParentIndex: &zero, Weight: 1e22},
+	}
+	tree := buildForkChoiceTree(protoArrayData, 2)
+
+	for index := range protoArrayData {
+		expected, err := json.Marshal(tree.materialize(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err = tree.encodeJSON(&buf, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(expected) {
+			t.Errorf("streamed tree differs:\n%s\n%s", buf.String(), expected)
+		}
+	}
+}
+
+func TestSendForkChoiceStreamsCachedTree(t *testing.T) {
+	zero := float64(0)
+	protoArrayData := []ProtoArrayNode{
+		{Slot: 0, Root: hash("0"), Weight: 100, BestDescendant: 1},
+		{Slot: 1, Root: hash("1"), ParentIndex: &zero, Weight: 100},
+	}
+	m := &Monitor{
+		config:            &Config{Eth2: Eth2Config{SecondsPerSlot: 12, SlotsPerEpoch: 32, GenesisTime: int(time.Now().Unix())}},
+		forkChoiceSummary: buildForkChoiceTree(protoArrayData, 1),
+	}
+
+	w := httptest.NewRecorder()
+	m.sendForkChoice(w, httptest.NewRequest(http.MethodGet, "/fork-choice", nil))
+	resp := struct {
+		BlockTree    ForkChoiceNode `json:"block_tree"`
+		Initializing bool           `json:"initializing"`
+	}{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BlockTree.Root != hash("0") || len(resp.BlockTree.Children) != 1 || !resp.Initializing {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	first, _ := m.blockTreeJSON(m.forkChoiceSummary, 0)
+	second, _ := m.blockTreeJSON(m.forkChoiceSummary, 0)
+	if &first[0] != &second[0] {
+		t.Error("expected requests to share the cached encoding")
+	}
+	encodes := 0
+	m.forkChoiceCache.get("other", func(w io.Writer) error {
+		encodes++
+		return nil
+	})
+	if encodes != 1 {
+		t.Error("expected a new key to be encoded")
+	}
+}
@@ -0,0 +1,213 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const emptySubnetsAlertPrefix = "empty_subnets:"
+
+// Lighthouse reports the peers subscribed to each attestation subnet under
+// this metric, other clients can be configured with `subnet_metric`
+const defaultSubnetMetricName = "gossipsub_subscribed_peers_subnet_topic"
+const defaultSubnetMetricLabel = "subnet_id"
+
+// ATTESTATION_SUBNET_COUNT in the consensus specs
+const attestationSubnetCount = 64
+
+// SubnetMetricConfig names the Prometheus metric holding the peer count of
+// each attestation subnet and the label carrying the subnet.
+type SubnetMetricConfig struct {
+	Name  string `yaml:"name"`
+	Label string `yaml:"label"`
+}
+
+func (c *Config) hasMetricsEndpoints() bool {
+	for _, endpoint := range c.Endpoints {
+		if endpoint.Metrics != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) subnetMetric() SubnetMetricConfig {
+	metric := m.config.SubnetMetric
+	if metric.Name == "" {
+		metric.Name = defaultSubnetMetricName
+	}
+	if metric.Label == "" {
+		metric.Label = defaultSubnetMetricLabel
+	}
+	return metric
+}
+
+// parseMetricLabels reads the labels of a sample, e.g. `subnet_id="3",topic="x"`.
+func parseMetricLabels(raw string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		labels[parts[0]] = strings.Trim(parts[1], `"`)
+	}
+	return labels
+}
+
+// parseSubnetPeers extracts the peer count per subnet from metrics in the
+// Prometheus text format.
+func parseSubnetPeers(r io.Reader, metric SubnetMetricConfig) (map[string]int, error) {
+	peers := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, metric.Name+"{") {
+			continue
+		}
+		end := strings.LastIndex(line, "}")
+		if end < 0 {
+			return nil, fmt.Errorf("malformed metric sample %q", line)
+		}
+		subnet, ok := parseMetricLabels(line[len(metric.Name)+1 : end])[metric.Label]
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(line[end+1:])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("metric sample without a value %q", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		peers[subnet] += int(value)
+	}
+	return peers, scanner.Err()
+}
+
+func (n *Node) fetchSubnetPeers(metric SubnetMetricConfig) (_ map[string]int, err error) {
+	defer n.recordError(&err)
+	resp, err := n.client.Get(n.config.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	err = checkStatus(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseSubnetPeers(resp.Body, metric)
+}
+
+// subnetSort orders subnet ids numerically where possible.
+func subnetSort(subnets []string) {
+	sort.Slice(subnets, func(i, j int) bool {
+		a, errA := strconv.Atoi(subnets[i])
+		b, errB := strconv.Atoi(subnets[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return subnets[i] < subnets[j]
+	})
+}
+
+// SubnetCoverage is the number of peers a node has on each attestation
+// subnet. Subnets the node does not report have no peers. Subnets without
+// peers silently degrade attestation performance.
+type SubnetCoverage struct {
+	Peers     map[string]int `json:"peers"`
+	Empty     []string       `json:"empty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func newSubnetCoverage(peers map[string]int, now time.Time) SubnetCoverage {
+	coverage := SubnetCoverage{Peers: make(map[string]int), Empty: []string{}, UpdatedAt: now}
+	for i := 0; i < attestationSubnetCount; i++ {
+		coverage.Peers[strconv.Itoa(i)] = 0
+	}
+	for subnet, count := range peers {
+		coverage.Peers[subnet] = count
+	}
+	for subnet, count := range coverage.Peers {
+		if count == 0 {
+			coverage.Empty = append(coverage.Empty, subnet)
+		}
+	}
+	subnetSort(coverage.Empty)
+	return coverage
+}
+
+type subnetSamples struct {
+	lock  sync.Mutex
+	nodes map[string]SubnetCoverage
+}
+
+func (s *subnetSamples) set(id string, coverage SubnetCoverage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.nodes == nil {
+		s.nodes = make(map[string]SubnetCoverage)
+	}
+	s.nodes[id] = coverage
+}
+
+func (s *subnetSamples) all() map[string]SubnetCoverage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	all := make(map[string]SubnetCoverage, len(s.nodes))
+	for id, coverage := range s.nodes {
+		all[id] = coverage
+	}
+	return all
+}
+
+func (m *Monitor) updateSubnetCoverage() {
+	metric := m.subnetMetric()
+	for _, node := range m.nodeList() {
+		if node.config.Metrics == "" || !node.requests.allowsOptional(time.Now()) {
+			continue
+		}
+		m.fetches.acquire(cosmeticFetch)
+		peers, err := node.fetchSubnetPeers(metric)
+		m.fetches.release()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		coverage := newSubnetCoverage(peers, time.Now())
+		m.subnets.set(node.id, coverage)
+
+		name := emptySubnetsAlertPrefix + node.id
+		if len(coverage.Empty) == 0 {
+			m.alerts.resolve(name)
+		} else {
+			message := fmt.Sprintf("node %s has no peers on attestation subnets %s", node.id, strings.Join(coverage.Empty, ", "))
+			m.alerts.raise(name, SeverityWarning, message)
+		}
+	}
+}
+
+func (m *Monitor) startSubnetMonitor(beat func() bool) {
+	config := m.eth2()
+	for beat() {
+		m.updateSubnetCoverage()
+		waitUntilNextEpoch(config.GenesisTime, config.SecondsPerSlot, config.SlotsPerEpoch)
+	}
+}
+
+type subnetsResp struct {
+	Nodes map[string]SubnetCoverage `json:"nodes"`
+}
+
+func (m *Monitor) sendSubnets(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, &subnetsResp{Nodes: m.subnets.all()})
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const subnetMetrics = `# HELP gossipsub_subscribed_peers_subnet_topic Peers subscribed per subnet
+# TYPE gossipsub_subscribed_peers_subnet_topic gauge
+gossipsub_subscribed_peers_subnet_topic{subnet_id="0"} 12
+gossipsub_subscribed_peers_subnet_topic{subnet_id="10"} 0
+gossipsub_subscribed_peers_subnet_topic{subnet_id="2"} 0
+gossipsub_subscribed_peers_subnet_topic_total{subnet_id="3"} 7
+libp2p_peers 55
+`
+
+func TestParseSubnetPeers(t *testing.T) {
+	metric := SubnetMetricConfig{Name: defaultSubnetMetricName, Label: defaultSubnetMetricLabel}
+	peers, err := parseSubnetPeers(strings.NewReader(subnetMetrics), metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"0": 12, "10": 0, "2": 0}
+	if !reflect.DeepEqual(peers, expected) {
+		t.Errorf("unexpected subnet peers %v", peers)
+	}
+
+	// subnets missing from the metrics have no peers
+	coverage := newSubnetCoverage(peers, time.Now())
+	if len(coverage.Peers) != attestationSubnetCount || coverage.Peers["0"] != 12 {
+		t.Errorf("unexpected coverage %v", coverage.Peers)
+	}
+	if len(coverage.Empty) != attestationSubnetCount-1 || coverage.Empty[0] != "1" || coverage.Empty[1] != "2" {
+		t.Errorf("unexpected empty subnets %v", coverage.Empty)
+	}
+}
+
+func TestEmptySubnetsAlert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(subnetMetrics))
+	}))
+	defer server.Close()
+
+	node := &Node{id: "a", config: Endpoint{Metrics: server.URL}}
+	m := &Monitor{config: &Config{}, nodes: []*Node{node, {id: "b"}}, alerts: newAlertSet()}
+	m.updateSubnetCoverage()
+
+	alerts := m.alerts.list()
+	if len(alerts) != 1 || alerts[0].Name != emptySubnetsAlertPrefix+"a" {
+		t.Fatalf("expected an empty subnets alert, have %v", alerts)
+	}
+	coverage := m.subnets.all()
+	if len(coverage) != 1 || coverage["a"].Peers["0"] != 12 {
+		t.Errorf("unexpected coverage %v", coverage)
+	}
+}
@@ -0,0 +1,283 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const attesterDutiesPathFmt = "/eth/v1/validator/duties/attester/%d"
+
+const surroundRiskAlert = "surround_risk"
+
+// number of assessed reorgs to keep
+const surroundRiskHistory = 256
+
+// ExposedVote is an attestation a watched validator cast while the orphaned
+// branch was canonical, with the source and target it took from that branch.
+type ExposedVote struct {
+	Validator   int `json:"validator_index"`
+	Slot        int `json:"slot"`
+	SourceEpoch int `json:"source_epoch"`
+	TargetEpoch int `json:"target_epoch"`
+}
+
+// SurroundRisk assesses a reorg that changed the target checkpoint of recent
+// epochs. Attesters that voted on the orphaned branch used its justified
+// checkpoint as source. If the new branch justifies an earlier checkpoint,
+// their next votes following it have an earlier source and a later target,
+// surrounding the earlier votes, so only slashing protection keeps them from
+// being slashed.
+type SurroundRisk struct {
+	DetectedAt     time.Time `json:"detected_at"`
+	OldHead        BlockRef  `json:"old_head"`
+	NewHead        BlockRef  `json:"new_head"`
+	CommonAncestor BlockRef  `json:"common_ancestor"`
+	// epochs whose target checkpoint differs between the branches
+	AffectedEpochs []int `json:"affected_target_epochs"`
+	// the justified checkpoints of the branches, if the provider reports them
+	OldSource *Checkpoint `json:"old_source"`
+	NewSource *Checkpoint `json:"new_source"`
+	// epochs the new source is ahead of the old one, negative values surround
+	SourceMarginEpochs *int `json:"source_margin_epochs"`
+	AtRisk             bool `json:"at_risk"`
+	// the votes of watched validators on the orphaned branch, null if no
+	// validators are watched or their attester duties could not be fetched
+	ExposedVotes []ExposedVote `json:"exposed_votes"`
+}
+
+// targetRoot returns the target checkpoint root for the epoch starting at
+// `boundary` on the branch of `ancestors`, the ancestor indices from the tip
+// down: the latest block at or before the boundary.
+func targetRoot(protoArray []ProtoArrayNode, ancestors []int, boundary int) string {
+	for _, i := range ancestors {
+		if protoArray[i].Slot <= boundary {
+			return protoArray[i].Root
+		}
+	}
+	return ""
+}
+
+// computeSurroundRisk returns the assessment of `reorg` in `protoArray`, or
+// nil if the reorg is too shallow to change the target of any epoch.
+func computeSurroundRisk(protoArray []ProtoArrayNode, reorg Reorg, slotsPerEpoch int) *SurroundRisk {
+	oldIndex := protoArrayIndex(protoArray, reorg.OldHead.Root)
+	newIndex := protoArrayIndex(protoArray, reorg.NewHead.Root)
+	if oldIndex < 0 || newIndex < 0 || slotsPerEpoch == 0 {
+		return nil
+	}
+	oldBranch := ancestorIndices(protoArray, oldIndex)
+	newBranch := ancestorIndices(protoArray, newIndex)
+
+	lastSlot := reorg.OldHead.Slot
+	if reorg.NewHead.Slot > lastSlot {
+		lastSlot = reorg.NewHead.Slot
+	}
+	affected := []int{}
+	for epoch := reorg.CommonAncestor.Slot/slotsPerEpoch + 1; epoch*slotsPerEpoch <= lastSlot; epoch++ {
+		boundary := epoch * slotsPerEpoch
+		if targetRoot(protoArray, oldBranch, boundary) != targetRoot(protoArray, newBranch, boundary) {
+			affected = append(affected, epoch)
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+
+	risk := &SurroundRisk{
+		DetectedAt:     reorg.DetectedAt,
+		OldHead:        reorg.OldHead,
+		NewHead:        reorg.NewHead,
+		CommonAncestor: reorg.CommonAncestor,
+		AffectedEpochs: affected,
+		OldSource:      protoArray[oldIndex].JustifiedCheckpoint,
+		NewSource:      protoArray[newIndex].JustifiedCheckpoint,
+	}
+	if risk.OldSource != nil && risk.NewSource != nil {
+		margin := risk.NewSource.Epoch - risk.OldSource.Epoch
+		risk.SourceMarginEpochs = &margin
+		risk.AtRisk = margin < 0
+	}
+	return risk
+}
+
+// exposeVotes finds the watched validators whose attester duty in an
+// affected epoch fell after the common ancestor and no later than the old
+// head, when the orphaned branch was canonical. `duties` maps each epoch to
+// the duty slot of each validator.
+func (r *SurroundRisk) exposeVotes(duties map[int]map[int]int, slotsPerEpoch int) {
+	r.ExposedVotes = []ExposedVote{}
+	sourceEpoch := 0
+	if r.OldSource != nil {
+		sourceEpoch = r.OldSource.Epoch
+	}
+	for _, epoch := range r.AffectedEpochs {
+		for validator, slot := range duties[epoch] {
+			if slot <= r.CommonAncestor.Slot || slot > r.OldHead.Slot || slot/slotsPerEpoch != epoch {
+				continue
+			}
+			r.ExposedVotes = append(r.ExposedVotes, ExposedVote{Validator: validator, Slot: slot, SourceEpoch: sourceEpoch, TargetEpoch: epoch})
+		}
+	}
+	sort.Slice(r.ExposedVotes, func(i, j int) bool {
+		a, b := r.ExposedVotes[i], r.ExposedVotes[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.Validator < b.Validator
+	})
+}
+
+type attesterDutiesResp struct {
+	Data []struct {
+		ValidatorIndex int `json:"validator_index,string"`
+		Slot           int `json:"slot,string"`
+	} `json:"data"`
+}
+
+// fetchAttesterDuties returns the duty slot of each of `validators` in `epoch`.
+func (n *Node) fetchAttesterDuties(epoch int, validators []int) (_ map[int]int, err error) {
+	defer n.recordError(&err)
+	indices := make([]string, len(validators))
+	for i, validator := range validators {
+		indices[i] = strconv.Itoa(validator)
+	}
+	request, err := json.Marshal(indices)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.client.Post(n.endpoint+fmt.Sprintf(attesterDutiesPathFmt, epoch), "application/json", bytes.NewBuffer(request))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data := attesterDutiesResp{}
+	err = decodeResponse(resp, &data)
+	if err != nil {
+		return nil, err
+	}
+	duties := make(map[int]int)
+	for _, duty := range data.Data {
+		duties[duty.ValidatorIndex] = duty.Slot
+	}
+	return duties, nil
+}
+
+// surroundRiskLog keeps the assessed reorgs and the source epoch the
+// justified checkpoint has to reach again to clear the alert; the zero
+// value is ready to use.
+type surroundRiskLog struct {
+	lock  sync.Mutex
+	risks []SurroundRisk
+	// the alert is resolved once the justified checkpoint reaches this epoch
+	clearsAt *int
+}
+
+func (l *surroundRiskLog) add(risk SurroundRisk) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.risks = append(l.risks, risk)
+	if len(l.risks) > surroundRiskHistory {
+		l.risks = append([]SurroundRisk{}, l.risks[len(l.risks)-surroundRiskHistory:]...)
+	}
+	if risk.AtRisk && (l.clearsAt == nil || risk.OldSource.Epoch > *l.clearsAt) {
+		epoch := risk.OldSource.Epoch
+		l.clearsAt = &epoch
+	}
+}
+
+// justified notes the justified epoch and returns whether it clears the risk.
+func (l *surroundRiskLog) justified(epoch int) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.clearsAt == nil || epoch < *l.clearsAt {
+		return false
+	}
+	l.clearsAt = nil
+	return true
+}
+
+func (l *surroundRiskLog) list() []SurroundRisk {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]SurroundRisk{}, l.risks...)
+}
+
+func (l *surroundRiskLog) clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.risks = nil
+	l.clearsAt = nil
+}
+
+// assessSurroundRisk records the surround risk of a detected reorg. The
+// attester duties of the watched validators are fetched off the poller.
+func (m *Monitor) assessSurroundRisk(detected ReorgDetected) {
+	risk := computeSurroundRisk(detected.ProtoArray, detected.Reorg, m.config.Eth2.SlotsPerEpoch)
+	if risk == nil {
+		return
+	}
+	if len(m.config.WatchedValidators) == 0 {
+		m.recordSurroundRisk(*risk)
+		return
+	}
+	go func(risk SurroundRisk, source string) {
+		node := m.nodeByID(source)
+		if node == nil {
+			node = m.providerFor(forkChoiceQuery)
+		}
+		if node == nil {
+			m.recordSurroundRisk(risk)
+			return
+		}
+		duties := make(map[int]map[int]int)
+		for _, epoch := range risk.AffectedEpochs {
+			m.fetches.acquire(forkChoiceFetch)
+			epochDuties, err := node.fetchAttesterDuties(epoch, m.config.WatchedValidators)
+			m.fetches.release()
+			if err != nil {
+				log.Printf("could not fetch the attester duties of epoch %d from %s: %v", epoch, node.id, err)
+				m.recordSurroundRisk(risk)
+				return
+			}
+			duties[epoch] = epochDuties
+		}
+		risk.exposeVotes(duties, m.config.Eth2.SlotsPerEpoch)
+		m.recordSurroundRisk(risk)
+	}(*risk, detected.Reorg.Source)
+}
+
+func (m *Monitor) recordSurroundRisk(risk SurroundRisk) {
+	m.surroundRisks.add(risk)
+	log.Printf("reorg from %s to %s changed the targets of epochs %v", risk.OldHead.Root, risk.NewHead.Root, risk.AffectedEpochs)
+	if !risk.AtRisk {
+		return
+	}
+	message := fmt.Sprintf("reorg to slot %d changed the targets of epochs %v and moved the justified checkpoint back from epoch %d to %d, votes on the orphaned branch are surrounded by votes following the new one", risk.NewHead.Slot, risk.AffectedEpochs, risk.OldSource.Epoch, risk.NewSource.Epoch)
+	if len(risk.ExposedVotes) == 0 {
+		m.alerts.raise(surroundRiskAlert, SeverityWarning, message)
+		return
+	}
+	validators := make([]int, len(risk.ExposedVotes))
+	for i, vote := range risk.ExposedVotes {
+		validators[i] = vote.Validator
+	}
+	message += fmt.Sprintf("; watched validators %v voted on the orphaned branch and must keep slashing protection enabled", validators)
+	m.alerts.raise(surroundRiskAlert, SeverityCritical, message)
+}
+
+type surroundRiskResp struct {
+	Reorgs []SurroundRisk `json:"reorgs"`
+}
+
+// sendSurroundRisk serves the reorgs that changed target checkpoints, most
+// recent last.
+func (m *Monitor) sendSurroundRisk(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, &surroundRiskResp{Reorgs: m.surroundRisks.list()})
+}
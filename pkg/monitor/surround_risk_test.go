@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestComputeSurroundRisk(t *testing.T) {
+	// with 4 slots per epoch, the branches fork after slot 3:
+	// 0 <- 3 <- 4 <- 5 <- 9
+	//        \- 7 <- 8
+	blocks := [][2]int{{0, -1}, {3, 0}, {4, 1}, {5, 2}, {9, 3}, {7, 1}, {8, 5}}
+	protoArray := protoArrayWithHead(6, blocks...)
+	protoArray[4].JustifiedCheckpoint = &Checkpoint{Epoch: 1, Root: hash("1")}
+	protoArray[6].JustifiedCheckpoint = &Checkpoint{Epoch: 0, Root: hash("0")}
+
+	reorg := detectReorg(protoArray, hash(strconv.Itoa(4)))
+	if reorg == nil {
+		t.Fatal("expected a reorg")
+	}
+	risk := computeSurroundRisk(protoArray, *reorg, 4)
+	if risk == nil {
+		t.Fatal("expected the reorg to change targets")
+	}
+	if !reflect.DeepEqual(risk.AffectedEpochs, []int{1, 2}) {
+		t.Errorf("unexpected affected epochs %v", risk.AffectedEpochs)
+	}
+	if !risk.AtRisk || *risk.SourceMarginEpochs != -1 {
+		t.Errorf("expected the earlier source to be a surround risk: %+v", risk)
+	}
+
+	duties := map[int]map[int]int{
+		1: {10: 5, 11: 2},
+		2: {10: 9, 12: 10},
+	}
+	risk.exposeVotes(duties, 4)
+	expected := []ExposedVote{
+		{Validator: 10, Slot: 5, SourceEpoch: 1, TargetEpoch: 1},
+		{Validator: 10, Slot: 9, SourceEpoch: 1, TargetEpoch: 2},
+	}
+	if !reflect.DeepEqual(risk.ExposedVotes, expected) {
+		t.Errorf("expected the votes on the orphaned branch, got %v", risk.ExposedVotes)
+	}
+
+	// 0 <- 3 <- 4 <- 5
+	//             \- 6
+	shallow := protoArrayWithHead(4, [][2]int{{0, -1}, {3, 0}, {4, 1}, {5, 2}, {6, 2}}...)
+	reorg = detectReorg(shallow, hash(strconv.Itoa(3)))
+	if reorg == nil {
+		t.Fatal("expected a reorg")
+	}
+	if computeSurroundRisk(shallow, *reorg, 4) != nil {
+		t.Error("expected a reorg within an epoch to leave the targets alone")
+	}
+}
+
+func TestSurroundRiskAlert(t *testing.T) {
+	m := &Monitor{config: &Config{}, alerts: newAlertSet()}
+	m.subscribeConsumers()
+	margin := -1
+	m.recordSurroundRisk(SurroundRisk{
+		AffectedEpochs:     []int{1},
+		OldSource:          &Checkpoint{Epoch: 1},
+		NewSource:          &Checkpoint{Epoch: 0},
+		SourceMarginEpochs: &margin,
+		AtRisk:             true,
+		ExposedVotes:       []ExposedVote{{Validator: 10, Slot: 5, SourceEpoch: 1, TargetEpoch: 1}},
+	})
+	if alerts := m.alerts.list(); len(alerts) != 1 || alerts[0].Severity != SeverityCritical {
+		t.Fatalf("expected a critical alert for the watched validator, got %v", alerts)
+	}
+
+	m.events.publish(CheckpointAdvanced{Justified: Checkpoint{Epoch: 0}})
+	if len(m.alerts.list()) != 1 {
+		t.Error("expected the alert to stay until the old source is justified again")
+	}
+	m.events.publish(CheckpointAdvanced{Justified: Checkpoint{Epoch: 1}})
+	if len(m.alerts.list()) != 0 {
+		t.Error("expected the alert to be resolved")
+	}
+	if len(m.surroundRisks.list()) != 1 {
+		t.Error("expected the assessment to be kept")
+	}
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"math"
+	"time"
+)
+
+// weight given to the latest observation in the catch up rate average
+const syncRateSmoothing = 0.2
+
+// syncTracker follows how quickly a syncing node closes its sync distance.
+type syncTracker struct {
+	observedAt   time.Time
+	headSlot     int
+	syncDistance int
+	// smoothed decrease in sync distance per second
+	rate float64
+}
+
+func (s *syncTracker) observe(headSlot int, syncDistance int, now time.Time) {
+	if !s.observedAt.IsZero() {
+		elapsed := now.Sub(s.observedAt).Seconds()
+		if elapsed > 0 {
+			rate := float64(s.syncDistance-syncDistance) / elapsed
+			if s.rate == 0 {
+				s.rate = rate
+			} else {
+				s.rate = syncRateSmoothing*rate + (1-syncRateSmoothing)*s.rate
+			}
+		}
+	}
+	s.observedAt = now
+	s.headSlot = headSlot
+	s.syncDistance = syncDistance
+}
+
+type syncProgress struct {
+	HeadSlot            int        `json:"head_slot"`
+	SyncDistance        int        `json:"sync_distance"`
+	SlotsPerSecond      float64    `json:"slots_per_second"`
+	EstimatedCompletion *time.Time `json:"estimated_completion"`
+}
+
+// progress summarizes the sync, estimating completion only once the node is
+// observed to be catching up.
+func (s *syncTracker) progress() *syncProgress {
+	if s.observedAt.IsZero() {
+		return nil
+	}
+	progress := &syncProgress{
+		HeadSlot:       s.headSlot,
+		SyncDistance:   s.syncDistance,
+		SlotsPerSecond: math.Round(s.rate*100) / 100,
+	}
+	if s.rate > 0 {
+		remaining := time.Duration(float64(s.syncDistance) / s.rate * float64(time.Second))
+		completion := s.observedAt.Add(remaining)
+		progress.EstimatedCompletion = &completion
+	}
+	return progress
+}
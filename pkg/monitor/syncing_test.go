@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncProgress(t *testing.T) {
+	tracker := syncTracker{}
+	if tracker.progress() != nil {
+		t.Error("expected no progress before any observation")
+	}
+
+	start := time.Now()
+	tracker.observe(100, 1000, start)
+	progress := tracker.progress()
+	if progress.SyncDistance != 1000 || progress.EstimatedCompletion != nil {
+		t.Errorf("unexpected progress after first observation: %+v", progress)
+	}
+
+	tracker.observe(150, 950, start.Add(10*time.Second))
+	progress = tracker.progress()
+	if progress.HeadSlot != 150 || progress.SlotsPerSecond != 5 {
+		t.Errorf("unexpected catch up rate: %+v", progress)
+	}
+	expected := start.Add(10 * time.Second).Add(190 * time.Second)
+	if progress.EstimatedCompletion == nil || !progress.EstimatedCompletion.Equal(expected) {
+		t.Errorf("unexpected completion estimate: %v", progress.EstimatedCompletion)
+	}
+
+	// falling further behind never completes
+	tracker.observe(150, 2000, start.Add(20*time.Second))
+	if tracker.progress().EstimatedCompletion != nil {
+		t.Error("expected no completion estimate when falling behind")
+	}
+}
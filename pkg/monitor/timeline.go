@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"net/http"
+	"time"
+)
+
+const defaultTimelineSlots = 32
+
+// the head histories keep the last `headHistoryLength` heads of each node,
+// with at least a slot between them, so earlier slots could only come out
+// without heads
+const maxTimelineSlots = headHistoryLength
+
+type timelineSlot struct {
+	Slot int `json:"slot"`
+	// node id to the head it had at the end of the slot, if known
+	Heads map[string]BlockRef `json:"heads"`
+	// nodes whose head root differs from the previous slot
+	Switched []string `json:"switched"`
+}
+
+type timelineResp struct {
+	Slots []timelineSlot `json:"slots"`
+}
+
+// buildTimeline replays the head histories of the nodes to find the head each
+// node had at the end of every slot in [fromSlot, toSlot].
+func buildTimeline(ids []string, histories map[string][]HeadObservation, config Eth2Config, fromSlot int, toSlot int) []timelineSlot {
+	slotEnd := func(slot int) time.Time {
+		return time.Unix(int64(config.GenesisTime+(slot+1)*config.SecondsPerSlot), 0)
+	}
+
+	// position in each history of the first observation after the current
+	// slot, starting with the heads from before the timeline
+	positions := make(map[string]int)
+	previous := make(map[string]BlockRef)
+	for _, id := range ids {
+		history := histories[id]
+		for positions[id] < len(history) && history[positions[id]].ObservedAt.Before(slotEnd(fromSlot-1)) {
+			positions[id]++
+		}
+		if positions[id] > 0 {
+			observation := history[positions[id]-1]
+			previous[id] = BlockRef{Slot: observation.Slot, Root: observation.Root}
+		}
+	}
+
+	timeline := []timelineSlot{}
+	for slot := fromSlot; slot <= toSlot; slot++ {
+		entry := timelineSlot{Slot: slot, Heads: make(map[string]BlockRef), Switched: []string{}}
+		end := slotEnd(slot)
+		for _, id := range ids {
+			history := histories[id]
+			position := positions[id]
+			for position < len(history) && history[position].ObservedAt.Before(end) {
+				position++
+			}
+			positions[id] = position
+			if position == 0 {
+				continue
+			}
+			observation := history[position-1]
+			head := BlockRef{Slot: observation.Slot, Root: observation.Root}
+			entry.Heads[id] = head
+			if last, ok := previous[id]; ok && last.Root != head.Root {
+				entry.Switched = append(entry.Switched, id)
+			}
+			previous[id] = head
+		}
+		timeline = append(timeline, entry)
+	}
+	return timeline
+}
+
+// sendTimeline serves the heads of all nodes over the last `?slots=` slots.
+func (m *Monitor) sendTimeline(w http.ResponseWriter, r *http.Request) {
+	slots, err := parsePositiveInt(r.URL.Query(), "slots", defaultTimelineSlots)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if slots > maxTimelineSlots {
+		slots = maxTimelineSlots
+	}
+
+	nodes := m.nodeList()
+	ids := make([]string, 0, len(nodes))
+	histories := make(map[string][]HeadObservation)
+	for _, node := range nodes {
+		ids = append(ids, node.id)
+		histories[node.id] = m.headObservations(node.id)
+	}
+
+	config := m.eth2()
+	currentSlot := computeCurrentSlot(config.GenesisTime, config.SecondsPerSlot)
+	fromSlot := currentSlot - slots + 1
+	if fromSlot < 0 {
+		fromSlot = 0
+	}
+	resp := timelineResp{Slots: buildTimeline(ids, histories, config, fromSlot, currentSlot)}
+	writeJSON(w, r, &resp)
+}
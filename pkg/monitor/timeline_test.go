@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	config := Eth2Config{GenesisTime: 1000, SecondsPerSlot: 12, SlotsPerEpoch: 32}
+	at := func(slot int, second int) time.Time {
+		return time.Unix(int64(1000+slot*12+second), 0)
+	}
+	histories := map[string][]HeadObservation{
+		"a": {
+			{Slot: 9, Root: "x", ObservedAt: at(9, 4)},
+			{Slot: 10, Root: "y", ObservedAt: at(10, 4)},
+			{Slot: 10, Root: "z", ObservedAt: at(11, 2)},
+		},
+		"b": {
+			{Slot: 10, Root: "z", ObservedAt: at(10, 6)},
+		},
+	}
+
+	timeline := buildTimeline([]string{"a", "b"}, histories, config, 10, 12)
+	if len(timeline) != 3 {
+		t.Fatalf("expected 3 slots, got %d", len(timeline))
+	}
+	if timeline[0].Heads["a"].Root != "y" || timeline[0].Heads["b"].Root != "z" {
+		t.Errorf("unexpected heads in slot 10: %v", timeline[0].Heads)
+	}
+	if !reflect.DeepEqual(timeline[0].Switched, []string{"a"}) {
+		t.Errorf("expected node a to switch from its earlier head in slot 10, got %v", timeline[0].Switched)
+	}
+	if !reflect.DeepEqual(timeline[1].Switched, []string{"a"}) || timeline[1].Heads["a"].Root != "z" {
+		t.Errorf("expected node a to switch in slot 11, got %+v", timeline[1])
+	}
+	if len(timeline[2].Switched) != 0 {
+		t.Errorf("expected no switches in slot 12, got %v", timeline[2].Switched)
+	}
+}
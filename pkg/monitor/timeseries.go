@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultTimeseriesResolution = 60 * time.Second
+const defaultTimeseriesWindow = 1 * time.Hour
+
+type nodeSample struct {
+	Time    time.Time `json:"time"`
+	Lag     int       `json:"lag"`
+	Healthy bool      `json:"healthy"`
+	// whether the node followed the majority head
+	Canonical bool `json:"canonical"`
+}
+
+// sampleStore keeps the recent samples of every node, oldest first.
+type sampleStore struct {
+	lock    sync.Mutex
+	samples map[string][]nodeSample
+}
+
+func newSampleStore() *sampleStore {
+	return &sampleStore{samples: make(map[string][]nodeSample)}
+}
+
+func (s *sampleStore) append(id string, sample nodeSample) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.samples[id] = append(s.samples[id], sample)
+}
+
+func (s *sampleStore) since(id string, start time.Time) []nodeSample {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var result []nodeSample
+	for _, sample := range s.samples[id] {
+		if !sample.Time.Before(start) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+func (s *sampleStore) pruneBefore(cutoff time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id, samples := range s.samples {
+		i := 0
+		for i < len(samples) && samples[i].Time.Before(cutoff) {
+			i++
+		}
+		s.samples[id] = samples[i:]
+	}
+}
+
+// stats returns the number of samples held and the time of the oldest one
+func (s *sampleStore) stats() (int, time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	count := 0
+	oldest := time.Time{}
+	for _, samples := range s.samples {
+		count += len(samples)
+		if len(samples) > 0 && (oldest.IsZero() || samples[0].Time.Before(oldest)) {
+			oldest = samples[0].Time
+		}
+	}
+	return count, oldest
+}
+
+func (m *Monitor) timeseriesResolution() time.Duration {
+	if m.config.TimeseriesResolutionSeconds > 0 {
+		return time.Duration(m.config.TimeseriesResolutionSeconds) * time.Second
+	}
+	return defaultTimeseriesResolution
+}
+
+func (m *Monitor) recordSamples(now time.Time) {
+	currentSlot := computeCurrentSlot(m.genesisTime(), m.config.Eth2.SecondsPerSlot)
+	nodes := m.nodeList()
+	head, _ := majorityHead(nodes)
+	for _, node := range nodes {
+		if node.latestHead.root == "" {
+			continue
+		}
+		m.samples.append(node.id, nodeSample{
+			Time:      now,
+			Lag:       currentSlot - node.latestHead.slot,
+			Healthy:   node.isHealthy,
+			Canonical: node.isHealthy && node.latestHead == head,
+		})
+	}
+}
+
+func (m *Monitor) startSampler(beat func() bool) {
+	resolution := m.timeseriesResolution()
+	for beat() {
+		m.recordSamples(time.Now())
+		time.Sleep(resolution)
+	}
+}
+
+type timeseriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value int       `json:"value"`
+}
+
+type timeseriesResp struct {
+	ID                string            `json:"id"`
+	Metric            string            `json:"metric"`
+	ResolutionSeconds int               `json:"resolution_seconds"`
+	Points            []timeseriesPoint `json:"points"`
+}
+
+var timeseriesMetrics = map[string]func(sample nodeSample) int{
+	"lag": func(sample nodeSample) int { return sample.Lag },
+	"health": func(sample nodeSample) int {
+		if sample.Healthy {
+			return 1
+		}
+		return 0
+	},
+	"canonical": func(sample nodeSample) int {
+		if sample.Canonical {
+			return 1
+		}
+		return 0
+	},
+}
+
+func (m *Monitor) sendNodeTimeseries(w http.ResponseWriter, r *http.Request, node *Node) {
+	query := r.URL.Query()
+
+	metricName := query.Get("metric")
+	if metricName == "" {
+		metricName = "lag"
+	}
+	metric, ok := timeseriesMetrics[metricName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown metric %q", metricName), http.StatusBadRequest)
+		return
+	}
+
+	window := defaultTimeseriesWindow
+	if raw := query.Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "window must be a positive duration like `24h`", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	resp := timeseriesResp{
+		ID:                node.id,
+		Metric:            metricName,
+		ResolutionSeconds: int(m.timeseriesResolution() / time.Second),
+		Points:            []timeseriesPoint{},
+	}
+	for _, sample := range m.samples.since(node.id, time.Now().Add(-window)) {
+		resp.Points = append(resp.Points, timeseriesPoint{Time: sample.Time, Value: metric(sample)})
+	}
+
+	writeJSON(w, r, &resp)
+}
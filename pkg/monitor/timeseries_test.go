@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNodeTimeseries(t *testing.T) {
+	m := &Monitor{
+		config:  &Config{},
+		nodes:   []*Node{{id: "abcd1234"}},
+		samples: newSampleStore(),
+	}
+	now := time.Now()
+	m.samples.append("abcd1234", nodeSample{Time: now.Add(-3 * time.Hour), Lag: 9})
+	m.samples.append("abcd1234", nodeSample{Time: now.Add(-30 * time.Minute), Lag: 2, Healthy: true})
+	m.samples.append("abcd1234", nodeSample{Time: now.Add(-time.Minute), Lag: 0, Healthy: true})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/nodes/abcd1234/timeseries?metric=lag&window=2h", nil)
+	w := httptest.NewRecorder()
+	m.sendNodeAPI(w, r)
+
+	resp := timeseriesResp{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Points) != 2 || resp.Points[0].Value != 2 || resp.Points[1].Value != 0 {
+		t.Errorf("unexpected points: %v", resp.Points)
+	}
+
+	m.samples.pruneBefore(now.Add(-time.Hour))
+	if samples := m.samples.since("abcd1234", time.Time{}); len(samples) != 2 {
+		t.Errorf("expected old samples to be pruned, have %d", len(samples))
+	}
+
+	for _, path := range []string{"/api/v1/nodes/unknown/timeseries", "/api/v1/nodes/abcd1234/other"} {
+		w := httptest.NewRecorder()
+		m.sendNodeAPI(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected not found, got %d", path, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	m.sendNodeAPI(w, httptest.NewRequest(http.MethodGet, "/api/v1/nodes/abcd1234/timeseries?metric=color", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected unknown metric to be rejected, got %d", w.Code)
+	}
+}
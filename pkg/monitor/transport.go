@@ -0,0 +1,204 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// setting an endpoint's `proxy` to this ignores HTTP(S)_PROXY for that node
+const proxyDirect = "direct"
+
+// normalizeAddr checks that `addr` is an absolute http(s) URL and drops any
+// trailing slash so API paths can be appended to it. IPv6 literals must be
+// bracketed as in "http://[::1]:5052", otherwise the port is ambiguous.
+func normalizeAddr(addr string) (string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		// the error would repeat any credentials in the URL
+		return "", fmt.Errorf("malformed endpoint URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("endpoint %q must start with http:// or https://", redactURL(addr))
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("endpoint %q has no host", redactURL(addr))
+	}
+	if strings.Contains(u.Hostname(), ":") && !strings.HasPrefix(u.Host, "[") {
+		return "", fmt.Errorf("IPv6 address in endpoint %q must be enclosed in brackets, e.g. http://[::1]:5052", redactURL(addr))
+	}
+	return strings.TrimRight(addr, "/"), nil
+}
+
+// proxyFunc selects how requests to a node are proxied. Without a `proxy`
+// the usual HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables apply. SOCKS5
+// proxies resolve the node's host name themselves, so names only known
+// inside a private network still work.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case proxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		// the error would repeat any credentials in the URL
+		return nil, fmt.Errorf("malformed proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy %q must use http, https or socks5", redactURL(proxy))
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", redactURL(proxy))
+	}
+	return http.ProxyURL(u), nil
+}
+
+// normalizeResolver returns the DNS server `resolver` as "host:port",
+// defaulting to port 53.
+func normalizeResolver(resolver string) (string, error) {
+	if _, _, err := net.SplitHostPort(resolver); err == nil {
+		return resolver, nil
+	}
+	if ip := net.ParseIP(strings.Trim(resolver, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), nil
+	}
+	if resolver == "" || strings.Contains(resolver, ":") {
+		return "", fmt.Errorf("malformed resolver %q", resolver)
+	}
+	return net.JoinHostPort(resolver, "53"), nil
+}
+
+// tlsConfig loads the CA bundle and client certificate of `settings`. It
+// returns nil if nothing deviates from the default verification.
+func tlsConfig(settings EndpointTLS) (*tls.Config, error) {
+	if settings == (EndpointTLS{}) {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+	if settings.CAFile != "" {
+		data, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in CA file %s", settings.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate requires both `cert_file` and `key_file`")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate %s: %v", settings.CertFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// checkEndpoint validates the address, proxy, name resolution and TLS
+// settings of `endpoint`, returning it with the address and resolver normalized.
+func checkEndpoint(endpoint Endpoint) (Endpoint, error) {
+	addr, err := normalizeAddr(endpoint.Addr)
+	if err != nil {
+		return endpoint, err
+	}
+	endpoint.Addr = addr
+	_, err = proxyFunc(endpoint.Proxy)
+	if err != nil {
+		return endpoint, err
+	}
+	for _, ip := range endpoint.PinnedIPs {
+		if net.ParseIP(ip) == nil {
+			return endpoint, fmt.Errorf("pinned IP %q of endpoint %q is not an IP address", ip, redactURL(addr))
+		}
+	}
+	if endpoint.Resolver != "" {
+		endpoint.Resolver, err = normalizeResolver(endpoint.Resolver)
+		if err != nil {
+			return endpoint, err
+		}
+	}
+	_, err = tlsConfig(endpoint.TLS)
+	return endpoint, err
+}
+
+type dialFunc func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+// pinnedDial connects to `pinnedIPs` in order whenever `host` is dialed and
+// uses `dial` for any other address, like a proxy or a metrics endpoint.
+func pinnedDial(dial dialFunc, host string, pinnedIPs []string) dialFunc {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		dialHost, port, err := net.SplitHostPort(addr)
+		if err != nil || !strings.EqualFold(dialHost, host) {
+			return dial(ctx, network, addr)
+		}
+		for _, ip := range pinnedIPs {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// newTransport returns a transport for a single node. Each node gets its own
+// so proxies and connection pools are not shared; host names resolving to
+// both IPv4 and IPv6 addresses are dialed over both families as usual.
+// Pinned IPs only apply when connecting to the node directly, a SOCKS5 proxy
+// still resolves the host itself. TLS is verified against the host name.
+func newTransport(endpoint Endpoint) (*http.Transport, error) {
+	proxy, err := proxyFunc(endpoint.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsClientConfig, err := tlsConfig(endpoint.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = tlsClientConfig
+
+	// same as the default transport
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if endpoint.Resolver != "" {
+		resolver := endpoint.Resolver
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, resolver)
+			},
+		}
+	}
+	dial := dialFunc(dialer.DialContext)
+	if len(endpoint.PinnedIPs) > 0 {
+		u, err := url.Parse(endpoint.Addr)
+		if err != nil {
+			return nil, err
+		}
+		dial = pinnedDial(dial, u.Hostname(), endpoint.PinnedIPs)
+	}
+	transport.DialContext = dial
+	return transport, nil
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+type upcomingEvent struct {
+	Name             string    `json:"name"`
+	Detail           string    `json:"detail,omitempty"`
+	Epoch            int       `json:"epoch"`
+	At               time.Time `json:"at"`
+	SecondsRemaining float64   `json:"seconds_remaining"`
+}
+
+type upcomingResp struct {
+	Events []upcomingEvent `json:"events"`
+}
+
+func newUpcomingEvent(config Eth2Config, now time.Time, name string, detail string, epoch int) upcomingEvent {
+	at := time.Unix(int64(config.GenesisTime+epoch*config.SlotsPerEpoch*config.SecondsPerSlot), 0)
+	return upcomingEvent{
+		Name:             name,
+		Detail:           detail,
+		Epoch:            epoch,
+		At:               at.UTC(),
+		SecondsRemaining: at.Sub(now).Seconds(),
+	}
+}
+
+// upcomingEvents lists the next protocol events after `now` in the order they happen.
+func upcomingEvents(config Eth2Config, now time.Time) []upcomingEvent {
+	nextEpoch := computeClock(config, now).CurrentEpoch + 1
+	if now.Before(time.Unix(int64(config.GenesisTime), 0)) {
+		nextEpoch = 0
+	}
+
+	events := []upcomingEvent{newUpcomingEvent(config, now, "epoch", "", nextEpoch)}
+
+	if period := config.EpochsPerSyncCommitteePeriod; period > 0 {
+		nextPeriod := (nextEpoch + period - 1) / period
+		events = append(events, newUpcomingEvent(config, now, "sync_committee_period", "", nextPeriod*period))
+	}
+
+	nextFork := ""
+	nextForkEpoch := 0
+	for fork, epoch := range config.ForkEpochs {
+		if epoch < nextEpoch {
+			continue
+		}
+		if nextFork == "" || epoch < nextForkEpoch || (epoch == nextForkEpoch && fork < nextFork) {
+			nextFork = fork
+			nextForkEpoch = epoch
+		}
+	}
+	if nextFork != "" {
+		events = append(events, newUpcomingEvent(config, now, "fork", nextFork, nextForkEpoch))
+	}
+	return events
+}
+
+func (m *Monitor) sendUpcoming(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	events := upcomingEvents(m.eth2(), now)
+
+	m.weakSubjectivityLock.Lock()
+	data := m.weakSubjectivityData
+	m.weakSubjectivityLock.Unlock()
+	epoch, _, err := wsEpochsRemaining(data, m.getCurrentEpoch())
+	if err == nil {
+		events = append(events, newUpcomingEvent(m.eth2(), now, "weak_subjectivity_expiry", data.Checkpoint, epoch+data.WSPeriod))
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Epoch < events[j].Epoch })
+	writeJSON(w, r, &upcomingResp{Events: events})
+}
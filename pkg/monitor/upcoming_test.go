@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpcomingEvents(t *testing.T) {
+	config := Eth2Config{
+		GenesisTime:                  1606824023,
+		SecondsPerSlot:               12,
+		SlotsPerEpoch:                32,
+		EpochsPerSyncCommitteePeriod: 256,
+		ForkEpochs:                   map[string]int{"altair": 300, "bellatrix": 600, "phase0": 0},
+	}
+	// halfway through epoch 260
+	now := time.Unix(1606824023, 0).Add(260*32*12*time.Second + 16*12*time.Second)
+
+	events := upcomingEvents(config, now)
+	expected := []struct {
+		name   string
+		detail string
+		epoch  int
+	}{
+		{"epoch", "", 261},
+		{"sync_committee_period", "", 512},
+		{"fork", "altair", 300},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Name != expected[i].name || event.Detail != expected[i].detail || event.Epoch != expected[i].epoch {
+			t.Errorf("unexpected event %+v", event)
+		}
+	}
+	if events[0].SecondsRemaining != 16*12 {
+		t.Errorf("expected the next epoch in %d seconds, got %f", 16*12, events[0].SecondsRemaining)
+	}
+}
@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const watchdogInterval = 30 * time.Second
+
+// a poller is stuck once it misses this many heartbeats, and never sooner
+// than `minStuckAfter` so slow requests without a timeout are not cut short
+const watchdogMissedBeats = 3
+const minStuckAfter = 2 * time.Minute
+
+// alert once a poller is restarted this often within `pollerRestartWindow`
+const pollerRestartAlertThreshold = 3
+const pollerRestartWindow = time.Hour
+
+const pollerRestartsAlertPrefix = "poller_restarts:"
+
+// a poller runs `loop` until the heartbeat returns false, which it does once
+// the watchdog has replaced a stuck instance with a fresh one
+type pollerLoop func(beat func() bool)
+
+type poller struct {
+	name       string
+	period     time.Duration
+	loop       pollerLoop
+	generation int
+	lastBeat   time.Time
+	restarts   []time.Time
+}
+
+func (p *poller) stuckAfter() time.Duration {
+	stuckAfter := watchdogMissedBeats * p.period
+	if stuckAfter < minStuckAfter {
+		return minStuckAfter
+	}
+	return stuckAfter
+}
+
+// watchdog restarts pollers that stop sending heartbeats. A goroutine cannot
+// be killed, so a stuck instance is abandoned and exits at its next heartbeat
+// should it ever wake up; the zero value is ready to use.
+type watchdog struct {
+	lock    sync.Mutex
+	pollers map[string]*poller
+}
+
+// beat returns the heartbeat of the instance started as `generation`.
+func (w *watchdog) beat(p *poller, generation int) func() bool {
+	return func() bool {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		if p.generation != generation {
+			return false
+		}
+		p.lastBeat = time.Now()
+		return true
+	}
+}
+
+// supervise runs `loop` under the watchdog, expecting a heartbeat at least
+// every `period`.
+func (w *watchdog) supervise(name string, period time.Duration, loop pollerLoop) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.pollers == nil {
+		w.pollers = make(map[string]*poller)
+	}
+	p := &poller{name: name, period: period, loop: loop, lastBeat: time.Now()}
+	w.pollers[name] = p
+	go loop(w.beat(p, p.generation))
+}
+
+// restartStuck starts a new instance of every poller without a heartbeat
+// for too long and returns the restarts of each poller within the window.
+func (w *watchdog) restartStuck(now time.Time) map[string]int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	restarts := make(map[string]int, len(w.pollers))
+	for name, p := range w.pollers {
+		if now.Sub(p.lastBeat) > p.stuckAfter() {
+			log.Printf("poller %s missed its heartbeat since %s, restarting", name, p.lastBeat.Format(time.RFC3339))
+			p.generation++
+			p.lastBeat = now
+			p.restarts = append(p.restarts, now)
+			go p.loop(w.beat(p, p.generation))
+		}
+		for len(p.restarts) > 0 && now.Sub(p.restarts[0]) > pollerRestartWindow {
+			p.restarts = p.restarts[1:]
+		}
+		restarts[name] = len(p.restarts)
+	}
+	return restarts
+}
+
+func (m *Monitor) startWatchdog() {
+	for {
+		time.Sleep(watchdogInterval)
+
+		for name, restarts := range m.pollers.restartStuck(time.Now()) {
+			alert := pollerRestartsAlertPrefix + name
+			if restarts >= pollerRestartAlertThreshold {
+				message := fmt.Sprintf("poller %s was restarted %d times in the last %s", name, restarts, pollerRestartWindow)
+				m.alerts.raise(alert, SeverityCritical, message)
+			} else {
+				m.alerts.resolve(alert)
+			}
+		}
+	}
+}
+
+type pollerStatus struct {
+	Name          string    `json:"name"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	Restarts      int       `json:"restarts"`
+}
+
+type pollersResp struct {
+	Pollers []pollerStatus `json:"pollers"`
+}
+
+func (w *watchdog) status() []pollerStatus {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	statuses := []pollerStatus{}
+	for name, p := range w.pollers {
+		statuses = append(statuses, pollerStatus{Name: name, LastHeartbeat: p.lastBeat, Restarts: len(p.restarts)})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// sendPollers lists the supervised pollers with their last heartbeat and
+// recent restarts.
+func (m *Monitor) sendPollers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, &pollersResp{Pollers: m.pollers.status()})
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogRestartsStuckPoller(t *testing.T) {
+	var pollers watchdog
+	started := make(chan func() bool, 10)
+	pollers.supervise("stuck", time.Second, func(beat func() bool) {
+		started <- beat
+	})
+	first := <-started
+	if !first() {
+		t.Fatal("expected the first instance to be current")
+	}
+
+	now := time.Now()
+	if restarts := pollers.restartStuck(now); restarts["stuck"] != 0 {
+		t.Fatalf("expected no restart while the poller is fresh, got %d", restarts["stuck"])
+	}
+
+	for i := 1; i <= pollerRestartAlertThreshold; i++ {
+		now = now.Add(minStuckAfter + time.Second)
+		if restarts := pollers.restartStuck(now); restarts["stuck"] != i {
+			t.Fatalf("expected %d restarts, got %d", i, restarts["stuck"])
+		}
+		<-started
+	}
+	if first() {
+		t.Error("expected the abandoned instance to stop at its next heartbeat")
+	}
+
+	// restarts age out of the window
+	now = now.Add(pollerRestartWindow + time.Second)
+	pollers.lock.Lock()
+	pollers.pollers["stuck"].lastBeat = now
+	pollers.lock.Unlock()
+	if restarts := pollers.restartStuck(now); restarts["stuck"] != 0 {
+		t.Errorf("expected old restarts to be forgotten, got %d", restarts["stuck"])
+	}
+
+	status := pollers.status()
+	if len(status) != 1 || status[0].Name != "stuck" {
+		t.Errorf("unexpected poller status %v", status)
+	}
+}
+
+func TestStuckAfter(t *testing.T) {
+	slot := &poller{period: 12 * time.Second}
+	epoch := &poller{period: 384 * time.Second}
+	if slot.stuckAfter() != minStuckAfter || epoch.stuckAfter() != 3*384*time.Second {
+		t.Errorf("unexpected deadlines %s and %s", slot.stuckAfter(), epoch.stuckAfter())
+	}
+}
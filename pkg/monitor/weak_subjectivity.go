@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const wsExpiryAlert = "weak_subjectivity_expiry"
+
+type wsResp struct {
+	WeakSubjectivityData
+	CheckpointEpoch *int        `json:"ws_checkpoint_epoch"`
+	EpochsRemaining *int        `json:"epochs_remaining"`
+	ExpiresAt       *time.Time  `json:"expires_at"`
+	Source          *Provenance `json:"source"`
+	Initializing    bool        `json:"initializing"`
+}
+
+// checkpointEpoch extracts the epoch from a checkpoint formatted as `root:epoch`
+func checkpointEpoch(checkpoint string) (int, error) {
+	parts := strings.Split(checkpoint, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("malformed weak subjectivity checkpoint %q", checkpoint)
+	}
+	return strconv.Atoi(parts[1])
+}
+
+// wsEpochsRemaining returns the epoch of the given checkpoint and how many
+// epochs are left until it falls outside of the weak subjectivity period.
+func wsEpochsRemaining(data WeakSubjectivityData, currentEpoch int) (int, int, error) {
+	epoch, err := checkpointEpoch(data.Checkpoint)
+	if err != nil {
+		return 0, 0, err
+	}
+	return epoch, epoch + data.WSPeriod - currentEpoch, nil
+}
+
+// checkWSExpiry warns operators that offline nodes relying on the current
+// checkpoint will need a fresh one soon.
+func (m *Monitor) checkWSExpiry(data WeakSubjectivityData) {
+	threshold := m.config.WSExpiryWarningEpochs
+	if threshold <= 0 {
+		return
+	}
+	_, remaining, err := wsEpochsRemaining(data, m.getCurrentEpoch())
+	if err != nil {
+		return
+	}
+	if remaining <= threshold {
+		message := fmt.Sprintf("checkpoint %s leaves the weak subjectivity period in %d epochs, refresh the checkpoint of any offline nodes", data.Checkpoint, remaining)
+		m.alerts.raise(wsExpiryAlert, SeverityWarning, message)
+	} else {
+		m.alerts.resolve(wsExpiryAlert)
+	}
+}
+
+func (m *Monitor) sendWSData(w http.ResponseWriter, r *http.Request) {
+	m.weakSubjectivityLock.Lock()
+	data := m.weakSubjectivityData
+	m.weakSubjectivityLock.Unlock()
+
+	resp := wsResp{WeakSubjectivityData: data, Source: m.sources.get(weakSubjectivitySource), Initializing: m.isInitializing(weakSubjectivitySource)}
+	epoch, remaining, err := wsEpochsRemaining(data, m.getCurrentEpoch())
+	if err == nil {
+		expiresAt := m.epochStartTime(epoch + data.WSPeriod)
+		resp.CheckpointEpoch = &epoch
+		resp.EpochsRemaining = &remaining
+		resp.ExpiresAt = &expiresAt
+	}
+
+	m.writeSignedJSON(w, r, &resp)
+}
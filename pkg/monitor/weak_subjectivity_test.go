@@ -0,0 +1,18 @@
+package monitor
+
+import "testing"
+
+func TestWSExpiryCountdown(t *testing.T) {
+	data := WeakSubjectivityData{CurrentEpoch: 1000, Checkpoint: "0xabcd:900", WSPeriod: 256}
+	epoch, remaining, err := wsEpochsRemaining(data, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch != 900 || remaining != 156 {
+		t.Errorf("unexpected countdown: epoch %d with %d remaining", epoch, remaining)
+	}
+
+	if _, _, err := wsEpochsRemaining(WeakSubjectivityData{Checkpoint: "0xabcd"}, 1000); err == nil {
+		t.Error("expected malformed checkpoint to be rejected")
+	}
+}
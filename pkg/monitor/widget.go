@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// widgets are small self-contained views meant to be embedded elsewhere,
+// e.g. in an iframe; they are served as HTML or, with `?format=json`, as JSON.
+var widgetTemplate = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0.5em; }
+h1 { font-size: 1em; margin: 0 0 0.3em 0; }
+.ok { color: #1a7f37; }
+.bad { color: #cf222e; }
+td { padding: 0 0.5em 0 0; }
+</style>
+</head>
+<body>
+<h1 class="{{if .OK}}ok{{else}}bad{{end}}">{{.Title}}</h1>
+<table>
+{{range .Rows}}<tr><td>{{.Label}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type widgetRow struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type widget struct {
+	Title   string      `json:"title"`
+	OK      bool        `json:"ok"`
+	Rows    []widgetRow `json:"rows"`
+	Refresh int         `json:"-"`
+}
+
+func (m *Monitor) statusWidget() widget {
+	nodes := m.nodeList()
+	head, inConsensus := majorityHead(nodes)
+	healthy := 0
+	for _, node := range nodes {
+		if node.isHealthy {
+			healthy++
+		}
+	}
+	title := "nodes in consensus"
+	if !inConsensus {
+		title = "nodes disagree on head"
+	}
+	return widget{
+		Title: title,
+		OK:    inConsensus && healthy == len(nodes),
+		Rows: []widgetRow{
+			{"healthy", strconv.Itoa(healthy) + "/" + strconv.Itoa(len(nodes))},
+			{"head slot", strconv.Itoa(head.slot)},
+		},
+	}
+}
+
+func (m *Monitor) participationWidget() widget {
+	var latest *Participation
+	for _, participation := range m.participationHistory() {
+		participation := participation
+		if latest == nil || participation.Epoch > latest.Epoch {
+			latest = &participation
+		}
+	}
+	if latest == nil {
+		return widget{Title: "participation unavailable"}
+	}
+	formatRate := func(rate float64) string {
+		return strconv.FormatFloat(rate, 'f', 1, 64) + "%"
+	}
+	rows := []widgetRow{
+		{"epoch", strconv.Itoa(latest.Epoch)},
+		{"source", formatRate(latest.SourceRate)},
+		{"target", formatRate(latest.TargetRate)},
+	}
+	if latest.HeadRate != nil {
+		rows = append(rows, widgetRow{"head", formatRate(*latest.HeadRate)})
+	}
+	return widget{
+		Title: "participation",
+		OK:    latest.TargetRate >= justificationThreshold,
+		Rows:  rows,
+	}
+}
+
+func (m *Monitor) finalityWidget() widget {
+	if m.finalizedCheckpoint.Root == "" {
+		return widget{Title: "finality unavailable"}
+	}
+	sinceFinality := m.getCurrentEpoch() - m.finalizedCheckpoint.Epoch
+	return widget{
+		Title: "finality",
+		// finality normally trails the current epoch by two epochs
+		OK: sinceFinality <= 3,
+		Rows: []widgetRow{
+			{"justified epoch", strconv.Itoa(m.justifiedCheckpoint.Epoch)},
+			{"finalized epoch", strconv.Itoa(m.finalizedCheckpoint.Epoch)},
+			{"epochs since finality", strconv.Itoa(sinceFinality)},
+		},
+	}
+}
+
+// sendWidget serves `/widget/{status|participation|finality}`.
+func (m *Monitor) sendWidget(w http.ResponseWriter, r *http.Request) {
+	var view widget
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, "/widget/"), "/") {
+	case "status":
+		view = m.statusWidget()
+	case "participation":
+		view = m.participationWidget()
+	case "finality":
+		view = m.finalityWidget()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		writeJSON(w, r, &view)
+		return
+	}
+
+	view.Refresh = m.config.Eth2.SecondsPerSlot
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := widgetTemplate.Execute(w, &view)
+	if err != nil {
+		log.Println(err)
+	}
+}
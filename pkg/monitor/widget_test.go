@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendWidget(t *testing.T) {
+	m := &Monitor{
+		config: &Config{Eth2: Eth2Config{GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32}},
+		nodes: []*Node{
+			{id: "a", latestHead: HeadRef{slot: 10, root: "x"}, isHealthy: true},
+			{id: "b", latestHead: HeadRef{slot: 10, root: "y"}, isHealthy: true},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	m.sendWidget(recorder, httptest.NewRequest(http.MethodGet, "/widget/status", nil))
+	body := recorder.Body.String()
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") || !strings.Contains(body, "nodes disagree on head") {
+		t.Errorf("unexpected status widget %s", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	m.sendWidget(recorder, httptest.NewRequest(http.MethodGet, "/widget/participation?format=json", nil))
+	if recorder.Header().Get("Content-Type") != "application/json" || !strings.Contains(recorder.Body.String(), "participation unavailable") {
+		t.Errorf("unexpected participation widget %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	m.sendWidget(recorder, httptest.NewRequest(http.MethodGet, "/widget/unknown", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected unknown widgets to be missing, got %d", recorder.Code)
+	}
+}